@@ -87,6 +87,12 @@ func (h *HPAWebhook) Default(ctx context.Context, obj runtime.Object) error {
 		return nil
 	}
 
+	if tortoise.Spec.TargetRefs.ScaledObjectName != nil {
+		// This HPA is generated by KEDA from the ScaledObject, and Tortoise updates the ScaledObject instead.
+		// Mutating it here would conflict with KEDA reconciling the HPA from the ScaledObject.
+		return nil
+	}
+
 	if tortoise.Spec.UpdateMode == v1beta3.UpdateModeOff {
 		// DryRun, don't update HPA
 		return nil
@@ -168,6 +174,11 @@ func (h *HPAWebhook) ValidateDelete(ctx context.Context, obj runtime.Object) (wa
 		return nil, nil
 	}
 
+	if tortoise.Spec.TargetRefs.ScaledObjectName != nil {
+		// expected scenario - this HPA is generated by KEDA from the ScaledObject, and KEDA manages its lifecycle.
+		return nil, nil
+	}
+
 	if !tortoise.ObjectMeta.DeletionTimestamp.IsZero() {
 		// expected scenario - tortoise is being deleted before HPA is deleted.
 		return nil, nil
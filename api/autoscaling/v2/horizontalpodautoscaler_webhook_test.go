@@ -174,6 +174,9 @@ var _ = Describe("v2.HPA Webhook", func() {
 		It("HPA is not mutated because of invalid annotation", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "no-tortoise-for-this-hpa"))
 		})
+		It("HPA generated by KEDA is not mutated", func() {
+			mutateTest(filepath.Join("testdata", "mutating", "no-mutate-for-scaledobject"))
+		})
 	})
 	Context("validating", func() {
 		It("invalid: HPA cannot be deleted when Tortoise (Auto) exists", func() {
@@ -185,6 +188,9 @@ var _ = Describe("v2.HPA Webhook", func() {
 		It("valid: HPA can be deleted when Tortoise (Auto) is deleted (no tortoise refers to this HPA)", func() {
 			validateDeletionTest(filepath.Join("testdata", "validating", "hpa-with-auto-deleted"), true)
 		})
+		It("valid: HPA generated by KEDA can be deleted when Tortoise (Auto) with ScaledObject exists", func() {
+			validateDeletionTest(filepath.Join("testdata", "validating", "hpa-with-scaledobject"), true)
+		})
 		It("valid: HPA can be deleted when Tortoise (Auto) is being deleted", func() {
 			// create tortoise
 			y, err := os.ReadFile(filepath.Join("testdata", "validating", "hpa-with-auto-being-deleted", "tortoise.yaml"))
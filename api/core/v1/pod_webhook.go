@@ -70,18 +70,18 @@ var _ admission.CustomDefaulter = &PodWebhook{}
 func (h *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
 	pod := obj.(*v1.Pod)
 
-	deploymentName, err := h.podService.GetDeploymentForPod(pod)
+	scaleTarget, err := h.podService.GetScaleTargetForPod(pod)
 	if err != nil {
 		// Block updating HPA may be critical. Just ignore it with error logs.
-		log.FromContext(ctx).Error(err, "failed to get deployment for pod in the Pod mutating webhook", "pod", klog.KObj(pod))
+		log.FromContext(ctx).Error(err, "failed to get the scale target for pod in the Pod mutating webhook", "pod", klog.KObj(pod))
 		return nil
 	}
 	if pod.Annotations == nil {
 		pod.Annotations = map[string]string{}
 	}
-	if deploymentName == "" {
-		// This Pod isn't managed by any deployment.
-		pod.Annotations[annotation.PodMutationAnnotation] = "this pod is not managed by deployment"
+	if scaleTarget == nil {
+		// This Pod isn't managed by any deployment or statefulset.
+		pod.Annotations[annotation.PodMutationAnnotation] = "this pod is not managed by deployment or statefulset"
 		return nil
 	}
 
@@ -101,7 +101,7 @@ func (h *PodWebhook) Default(ctx context.Context, obj runtime.Object) error {
 
 	var tortoise *v1beta3.Tortoise
 	for _, t := range tl.Items {
-		if t.Status.Targets.ScaleTargetRef.Name == deploymentName {
+		if t.Status.Targets.ScaleTargetRef.Kind == scaleTarget.Kind && t.Status.Targets.ScaleTargetRef.Name == scaleTarget.Name {
 			tortoise = t.DeepCopy()
 			break
 		}
@@ -37,6 +37,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/mercari/tortoise/api/v1beta3"
 
@@ -50,6 +51,7 @@ func mutateTest(dirPath string) {
 	after := filepath.Join(dirPath, "after.yaml")
 	rs := filepath.Join(dirPath, "replicaset.yaml")
 	dp := filepath.Join(dirPath, "deployment.yaml")
+	sts := filepath.Join(dirPath, "statefulset.yaml")
 	ctx := context.Background()
 
 	y, err := os.ReadFile(tortoise)
@@ -67,24 +69,41 @@ func mutateTest(dirPath string) {
 	err = k8sClient.Status().Update(ctx, tor)
 	Expect(err).NotTo(HaveOccurred())
 
-	y, err = os.ReadFile(dp)
-	Expect(err).NotTo(HaveOccurred())
-	deployment := &appv1.Deployment{}
-	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(deployment)
-	Expect(err).NotTo(HaveOccurred())
-	err = k8sClient.Create(ctx, deployment)
-	Expect(err).NotTo(HaveOccurred())
+	var owner client.Object
+	var cleanupOwners []client.Object
+	if _, err := os.Stat(sts); err == nil {
+		// The Pod is managed by StatefulSet.
+		y, err = os.ReadFile(sts)
+		Expect(err).NotTo(HaveOccurred())
+		statefulset := &appv1.StatefulSet{}
+		err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(statefulset)
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Create(ctx, statefulset)
+		Expect(err).NotTo(HaveOccurred())
+		owner = statefulset
+		cleanupOwners = []client.Object{statefulset}
+	} else {
+		y, err = os.ReadFile(dp)
+		Expect(err).NotTo(HaveOccurred())
+		deployment := &appv1.Deployment{}
+		err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(deployment)
+		Expect(err).NotTo(HaveOccurred())
+		err = k8sClient.Create(ctx, deployment)
+		Expect(err).NotTo(HaveOccurred())
 
-	y, err = os.ReadFile(rs)
-	Expect(err).NotTo(HaveOccurred())
-	replicaset := &appv1.ReplicaSet{}
-	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(replicaset)
-	Expect(err).NotTo(HaveOccurred())
-	if len(replicaset.OwnerReferences) != 0 {
-		replicaset.OwnerReferences[0].UID = deployment.UID
+		y, err = os.ReadFile(rs)
+		Expect(err).NotTo(HaveOccurred())
+		replicaset := &appv1.ReplicaSet{}
+		err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(replicaset)
+		Expect(err).NotTo(HaveOccurred())
+		if len(replicaset.OwnerReferences) != 0 {
+			replicaset.OwnerReferences[0].UID = deployment.UID
+		}
+		err = k8sClient.Create(ctx, replicaset)
+		Expect(err).NotTo(HaveOccurred())
+		owner = replicaset
+		cleanupOwners = []client.Object{replicaset, deployment}
 	}
-	err = k8sClient.Create(ctx, replicaset)
-	Expect(err).NotTo(HaveOccurred())
 
 	y, err = os.ReadFile(before)
 	Expect(err).NotTo(HaveOccurred())
@@ -92,7 +111,7 @@ func mutateTest(dirPath string) {
 	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(beforepod)
 	Expect(err).NotTo(HaveOccurred())
 	if len(beforepod.OwnerReferences) != 0 {
-		beforepod.OwnerReferences[0].UID = replicaset.UID
+		beforepod.OwnerReferences[0].UID = owner.GetUID()
 	}
 	err = k8sClient.Create(ctx, beforepod)
 	Expect(err).NotTo(HaveOccurred())
@@ -105,11 +124,10 @@ func mutateTest(dirPath string) {
 		err = k8sClient.Delete(ctx, beforepod)
 		Expect(err).NotTo(HaveOccurred())
 
-		err = k8sClient.Delete(ctx, replicaset)
-		Expect(err).NotTo(HaveOccurred())
-
-		err = k8sClient.Delete(ctx, deployment)
-		Expect(err).NotTo(HaveOccurred())
+		for _, o := range cleanupOwners {
+			err = k8sClient.Delete(ctx, o)
+			Expect(err).NotTo(HaveOccurred())
+		}
 	}()
 
 	ret := &v1.Pod{}
@@ -133,6 +151,9 @@ var _ = Describe("v1.Pod Webhook", func() {
 		It("Pod with Auto Tortoise is mutated", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "auto-tortoise"))
 		})
+		It("Pod managed by StatefulSet with Auto Tortoise is mutated", func() {
+			mutateTest(filepath.Join("testdata", "mutating", "auto-tortoise-statefulset"))
+		})
 		It("Pod that isn't managed by Tortoise is ignored", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "auto-tortoise-no-tortoise"))
 		})
@@ -166,7 +166,7 @@ var _ = BeforeSuite(func() {
 	config, err := config.ParseConfig("")
 	Expect(err).NotTo(HaveOccurred())
 	eventRecorder := mgr.GetEventRecorderFor("tortoise-controller")
-	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode, nil, nil)
+	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.RangeOfMinMaxReplicasRecommendationMinutes, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode, nil, nil, nil, nil)
 	Expect(err).NotTo(HaveOccurred())
 
 	const (
@@ -181,7 +181,7 @@ var _ = BeforeSuite(func() {
 	factory := informers.NewSharedInformerFactory(kubeClient, defaultResyncPeriod)
 
 	controllerFetcher := controllerfetcher.NewControllerFetcher(mgr.GetConfig(), kubeClient, factory, scaleCacheEntryFreshnessTime, scaleCacheEntryLifetime, scaleCacheEntryJitterFactor)
-	podService, err := pod.New(map[string]int64{}, "0", controllerFetcher, nil)
+	podService, err := pod.New(map[string]int64{}, "0", controllerFetcher, config.AllSidecarInjectors(), nil)
 	Expect(err).NotTo(HaveOccurred())
 
 	podWebhook := New(tortoiseService, podService)
@@ -0,0 +1,137 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScheduledScalingSpec defines the desired state of ScheduledScaling
+type ScheduledScalingSpec struct {
+	// TargetRefs has reference to involved resources.
+	TargetRefs TargetRefs `json:"targetRefs" protobuf:"bytes,1,name=targetRefs"`
+	// Strategy describes how this ScheduledScaling scales up the target.
+	Strategy Strategy `json:"strategy" protobuf:"bytes,2,name=strategy"`
+	// Schedule is the time window when this ScheduledScaling is valid.
+	Schedule Schedule `json:"schedule" protobuf:"bytes,3,name=schedule"`
+}
+
+type TargetRefs struct {
+	// TortoiseName is the name of the target Tortoise of this ScheduledScaling.
+	// The Tortoise must be in the same namespace as this ScheduledScaling.
+	TortoiseName string `json:"tortoiseName" protobuf:"bytes,1,name=tortoiseName"`
+}
+
+type Strategy struct {
+	// Static is the strategy that gives the static constraints to the target Tortoise during the schedule.
+	// +optional
+	Static *StaticStrategy `json:"static,omitempty" protobuf:"bytes,1,opt,name=static"`
+}
+
+type StaticStrategy struct {
+	// MinimumMinReplicas is the minimum replica number that Tortoise gives to HPA during this ScheduledScaling is valid.
+	// +optional
+	MinimumMinReplicas *int32 `json:"minimumMinReplicas,omitempty" protobuf:"varint,1,opt,name=minimumMinReplicas"`
+	// MinAllocatedResources is the minimum resource request that Tortoise gives to the Pods during this ScheduledScaling is valid.
+	// +optional
+	MinAllocatedResources []ContainerResources `json:"minAllocatedResources,omitempty" protobuf:"bytes,2,opt,name=minAllocatedResources"`
+}
+
+type ContainerResources struct {
+	// ContainerName is the name of target container.
+	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
+	// Resources is the minimum amount of resources which is given to the container.
+	Resources v1.ResourceList `json:"resources" protobuf:"bytes,2,name=resources"`
+}
+
+type Schedule struct {
+	// StartAt is the time when this ScheduledScaling starts to scale up the target.
+	// If empty, it starts right after the creation.
+	// +optional
+	StartAt *metav1.Time `json:"startAt,omitempty" protobuf:"bytes,1,opt,name=startAt"`
+	// FinishAt is the time when this ScheduledScaling stops scaling up the target.
+	// After this time, Tortoise gradually reduces the number of replicas and the resource requests.
+	FinishAt metav1.Time `json:"finishAt" protobuf:"bytes,2,name=finishAt"`
+}
+
+// ScheduledScalingStatus defines the observed state of ScheduledScaling
+type ScheduledScalingStatus struct {
+	// ScheduledScalingPhase is the current phase of this ScheduledScaling.
+	// +optional
+	ScheduledScalingPhase ScheduledScalingPhase `json:"scheduledScalingPhase,omitempty" protobuf:"bytes,1,opt,name=scheduledScalingPhase"`
+	// lastTransitionTime is the last time the phase transitioned from one to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" protobuf:"bytes,2,opt,name=lastTransitionTime"`
+	// reason is the reason for the phase's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,3,opt,name=reason"`
+	// message is a human-readable explanation containing details about the transition.
+	// +optional
+	Message string `json:"message,omitempty" protobuf:"bytes,4,opt,name=message"`
+}
+
+type ScheduledScalingPhase string
+
+const (
+	// ScheduledScalingPhasePending means the schedule hasn't started yet.
+	ScheduledScalingPhasePending ScheduledScalingPhase = "Pending"
+	// ScheduledScalingPhaseApplied means the constraints are applied to the target Tortoise.
+	ScheduledScalingPhaseApplied ScheduledScalingPhase = "Applied"
+	// ScheduledScalingPhaseExpired means the schedule is finished and the constraints are removed from the target Tortoise.
+	ScheduledScalingPhaseExpired ScheduledScalingPhase = "Expired"
+	// ScheduledScalingPhaseFailed means the controller failed to apply the constraints to the target Tortoise.
+	ScheduledScalingPhaseFailed ScheduledScalingPhase = "Failed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="TORTOISE",type="string",JSONPath=".spec.targetRefs.tortoiseName"
+//+kubebuilder:printcolumn:name="PHASE",type="string",JSONPath=".status.scheduledScalingPhase"
+//+kubebuilder:printcolumn:name="START",type="string",JSONPath=".spec.schedule.startAt"
+//+kubebuilder:printcolumn:name="FINISH",type="string",JSONPath=".spec.schedule.finishAt"
+
+// ScheduledScaling is the Schema for the scheduledscalings API
+type ScheduledScaling struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ScheduledScalingSpec   `json:"spec,omitempty"`
+	Status ScheduledScalingStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScheduledScalingList contains a list of ScheduledScaling
+type ScheduledScalingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScheduledScaling `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScheduledScaling{}, &ScheduledScalingList{})
+}
@@ -0,0 +1,117 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// log is for logging in this package.
+var scheduledscalinglog = ctrl.Log.WithName("scheduledscaling-resource")
+
+func (r *ScheduledScaling) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-autoscaling-mercari-com-v1alpha1-scheduledscaling,mutating=false,failurePolicy=fail,sideEffects=None,groups=autoscaling.mercari.com,resources=scheduledscalings,verbs=create;update,versions=v1alpha1,name=vscheduledscaling.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &ScheduledScaling{}
+
+func validateScheduledScaling(s *ScheduledScaling) error {
+	fieldPath := field.NewPath("spec")
+	if s.Spec.TargetRefs.TortoiseName == "" {
+		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("targetRefs", "tortoiseName"))
+	}
+
+	if s.Spec.Schedule.FinishAt.IsZero() {
+		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("schedule", "finishAt"))
+	}
+	if s.Spec.Schedule.StartAt != nil && !s.Spec.Schedule.StartAt.Before(&s.Spec.Schedule.FinishAt) {
+		return fmt.Errorf("%s: should be before %s", fieldPath.Child("schedule", "startAt"), fieldPath.Child("schedule", "finishAt"))
+	}
+
+	static := s.Spec.Strategy.Static
+	if static == nil {
+		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("strategy", "static"))
+	}
+	if static.MinimumMinReplicas == nil && len(static.MinAllocatedResources) == 0 {
+		return fmt.Errorf("%s: either minimumMinReplicas or minAllocatedResources should be specified", fieldPath.Child("strategy", "static"))
+	}
+	if static.MinimumMinReplicas != nil && *static.MinimumMinReplicas <= 0 {
+		return fmt.Errorf("%s: should be greater than 0", fieldPath.Child("strategy", "static", "minimumMinReplicas"))
+	}
+	for i, r := range static.MinAllocatedResources {
+		if r.ContainerName == "" {
+			return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("strategy", "static", "minAllocatedResources").Index(i).Child("containerName"))
+		}
+		if len(r.Resources) == 0 {
+			return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("strategy", "static", "minAllocatedResources").Index(i).Child("resources"))
+		}
+	}
+
+	return nil
+}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *ScheduledScaling) ValidateCreate() (admission.Warnings, error) {
+	scheduledscalinglog.Info("validate create", "name", r.Name)
+	return nil, validateScheduledScaling(r)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *ScheduledScaling) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	scheduledscalinglog.Info("validate update", "name", r.Name)
+	if err := validateScheduledScaling(r); err != nil {
+		return nil, err
+	}
+
+	oldScheduledScaling, ok := old.(*ScheduledScaling)
+	if !ok {
+		return nil, errors.New("failed to parse old object to ScheduledScaling")
+	}
+
+	fieldPath := field.NewPath("spec")
+	if r.Spec.TargetRefs.TortoiseName != oldScheduledScaling.Spec.TargetRefs.TortoiseName {
+		return nil, fmt.Errorf("%s: immutable field get changed", fieldPath.Child("targetRefs", "tortoiseName"))
+	}
+
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *ScheduledScaling) ValidateDelete() (admission.Warnings, error) {
+	scheduledscalinglog.Info("validate delete", "name", r.Name)
+	return nil, nil
+}
@@ -0,0 +1,114 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TortoiseRecommendationHistorySpec defines the desired state of TortoiseRecommendationHistory
+type TortoiseRecommendationHistorySpec struct {
+	// TortoiseName is the name of the Tortoise which this history belongs to.
+	// The Tortoise must be in the same namespace as this TortoiseRecommendationHistory.
+	TortoiseName string `json:"tortoiseName" protobuf:"bytes,1,name=tortoiseName"`
+}
+
+// TortoiseRecommendationHistoryStatus defines the observed state of TortoiseRecommendationHistory
+type TortoiseRecommendationHistoryStatus struct {
+	// Entries are the changes applied by Tortoise, ordered from the oldest to the newest.
+	// Old entries are removed based on the retention configured by the cluster admin.
+	// +optional
+	Entries []RecommendationHistoryEntry `json:"entries,omitempty" protobuf:"bytes,1,opt,name=entries"`
+}
+
+type RecommendationHistoryEntry struct {
+	// Time is the time when the change is applied.
+	Time metav1.Time `json:"time" protobuf:"bytes,1,name=time"`
+	// Target is what is changed.
+	Target HistoryTarget `json:"target" protobuf:"bytes,2,name=target"`
+	// ContainerName is the name of the container whose resource request or HPA target utilization is changed.
+	// It's empty when Target is HPAMinReplicas or HPAMaxReplicas.
+	// +optional
+	ContainerName string `json:"containerName,omitempty" protobuf:"bytes,3,opt,name=containerName"`
+	// ResourceName is the name of the resource whose resource request or HPA target utilization is changed.
+	// It's empty when Target is HPAMinReplicas or HPAMaxReplicas.
+	// +optional
+	ResourceName v1.ResourceName `json:"resourceName,omitempty" protobuf:"bytes,4,opt,name=resourceName"`
+	// MetricName is the name of the metric whose HPA target is changed.
+	// It's set only when Target is HPAMetricTarget.
+	// +optional
+	MetricName string `json:"metricName,omitempty" protobuf:"bytes,8,opt,name=metricName"`
+	// OldValue is the value before the change.
+	// It's the quantity for ResourceRequest and HPAMetricTarget, and the number for the others.
+	// +optional
+	OldValue string `json:"oldValue,omitempty" protobuf:"bytes,5,opt,name=oldValue"`
+	// NewValue is the value after the change.
+	NewValue string `json:"newValue" protobuf:"bytes,6,name=newValue"`
+	// Reason is the reason why the change is applied.
+	// +optional
+	Reason string `json:"reason,omitempty" protobuf:"bytes,7,opt,name=reason"`
+}
+
+// +kubebuilder:validation:Enum=ResourceRequest;HPAMinReplicas;HPAMaxReplicas;HPATargetUtilization;HPAMetricTarget
+type HistoryTarget string
+
+const (
+	HistoryTargetResourceRequest      HistoryTarget = "ResourceRequest"
+	HistoryTargetHPAMinReplicas       HistoryTarget = "HPAMinReplicas"
+	HistoryTargetHPAMaxReplicas       HistoryTarget = "HPAMaxReplicas"
+	HistoryTargetHPATargetUtilization HistoryTarget = "HPATargetUtilization"
+	HistoryTargetHPAMetricTarget      HistoryTarget = "HPAMetricTarget"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="TORTOISE",type="string",JSONPath=".spec.tortoiseName"
+//+kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+
+// TortoiseRecommendationHistory is the Schema for the tortoiserecommendationhistories API.
+// It's created by the controller for each Tortoise with the same name, and is deleted together with the Tortoise.
+//
+// It doesn't have the status subresource so that the controller can create it and append entries with a single request.
+type TortoiseRecommendationHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TortoiseRecommendationHistorySpec   `json:"spec,omitempty"`
+	Status TortoiseRecommendationHistoryStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// TortoiseRecommendationHistoryList contains a list of TortoiseRecommendationHistory
+type TortoiseRecommendationHistoryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TortoiseRecommendationHistory `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TortoiseRecommendationHistory{}, &TortoiseRecommendationHistoryList{})
+}
@@ -6,6 +6,9 @@ import (
 
 	v1 "k8s.io/api/apps/v1"
 	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -30,6 +33,72 @@ func (c *service) GetDeploymentOnTortoise(ctx context.Context, tortoise *Tortois
 	return d, nil
 }
 
+func (c *service) GetStatefulSetOnTortoise(ctx context.Context, tortoise *Tortoise) (*v1.StatefulSet, error) {
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind != "StatefulSet" {
+		return nil, fmt.Errorf("target kind is not statefulset: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+
+	s := &v1.StatefulSet{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, s); err != nil {
+		return nil, fmt.Errorf("failed to get statefulset on tortoise: %w", err)
+	}
+	return s, nil
+}
+
+// GetRolloutOnTortoise returns the Argo Rollouts' Rollout targeted by the tortoise.
+// It's handled as unstructured.Unstructured so that tortoise doesn't depend on the Argo Rollouts module.
+func (c *service) GetRolloutOnTortoise(ctx context.Context, tortoise *Tortoise) (*unstructured.Unstructured, error) {
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind != "Rollout" {
+		return nil, fmt.Errorf("target kind is not rollout: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+
+	ro := &unstructured.Unstructured{}
+	ro.SetAPIVersion("argoproj.io/v1alpha1")
+	ro.SetKind("Rollout")
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, ro); err != nil {
+		return nil, fmt.Errorf("failed to get rollout on tortoise: %w", err)
+	}
+	return ro, nil
+}
+
+// GetPodTemplateOnTortoise returns the Pod template of the scale target of the tortoise.
+func (c *service) GetPodTemplateOnTortoise(ctx context.Context, tortoise *Tortoise) (*corev1.PodTemplateSpec, error) {
+	switch tortoise.Spec.TargetRefs.ScaleTargetRef.Kind {
+	case "Deployment":
+		d, err := c.GetDeploymentOnTortoise(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		return &d.Spec.Template, nil
+	case "StatefulSet":
+		s, err := c.GetStatefulSetOnTortoise(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		return &s.Spec.Template, nil
+	case "Rollout":
+		ro, err := c.GetRolloutOnTortoise(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		m, ok, err := unstructured.NestedMap(ro.Object, "spec", "template")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the pod template of rollout: %w", err)
+		}
+		if !ok {
+			// e.g., the rollout refers to the workload via .spec.workloadRef.
+			return nil, fmt.Errorf("rollout doesn't have the pod template in .spec.template")
+		}
+		template := &corev1.PodTemplateSpec{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, template); err != nil {
+			return nil, fmt.Errorf("failed to convert the pod template of rollout: %w", err)
+		}
+		return template, nil
+	default:
+		return nil, fmt.Errorf("unsupported target kind: %s", tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
+	}
+}
+
 func (c *service) GetHPAFromUser(ctx context.Context, tortoise *Tortoise) (*v2.HorizontalPodAutoscaler, error) {
 	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
 		// user doesn't specify HPA.
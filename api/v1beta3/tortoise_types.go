@@ -76,6 +76,8 @@ type TortoiseSpec struct {
 	//   Note that Tortoise supports only the ContainerResource metric type for HPAs; other metric types will be disregarded.
 	//   Additionally, if a ContainerResource metric is later added to an HPA associated with Tortoise,
 	//   Tortoise will automatically update relevant resources to utilize a "Horizontal" policy.
+	// - If .spec.TargetRefs.ScaledObjectName is specified, it works in the same way as HorizontalPodAutoscalerName;
+	//   resources governed by the cpu/memory triggers with containerName in the referenced ScaledObject will use a "Horizontal" policy.
 	// - if a container doesn't have the resource request, that container's autoscaling policy is always set to "Off"
 	//   because tortoise cannot generate any recommendation without the resource request.
 	//
@@ -96,8 +98,139 @@ type TortoiseSpec struct {
 	// If nil, Tortoise uses the cluster wide default value, which is currently hard-coded.
 	// +optional
 	HorizontalPodAutoscalerBehavior *v2.HorizontalPodAutoscalerBehavior `json:"horizontalPodAutoscalerBehavior,omitempty" protobuf:"bytes,7,opt,name=horizontalPodAutoscalerBehavior"`
+	// Recommenders are responsible for generating a part of the recommendation for this Tortoise.
+	// When a recommender is specified here, Tortoise controller doesn't update the recommendations that the recommender is responsible for.
+	// Usually, this field is managed by other controllers (e.g., ScheduledScaling controller), and users don't have to modify it.
+	// +optional
+	Recommenders []Recommender `json:"recommenders,omitempty" protobuf:"bytes,8,opt,name=recommenders"`
+	// RecommenderOverrides overrides the cluster-wide configurations of the recommendation for this Tortoise.
+	// Each field can only be set within the range that the cluster admin allows.
+	// If nil or a field is unset, Tortoise uses the cluster-wide configuration.
+	// +optional
+	RecommenderOverrides *RecommenderOverrides `json:"recommenderOverrides,omitempty" protobuf:"bytes,9,opt,name=recommenderOverrides"`
+	// ResourceUpdateStrategy is how tortoise applies the new resource requests to the running Pods.
+	// If "RolloutRestart", tortoise restarts the scale target so that the Pods are replaced with the new resource requests.
+	// If "InPlace", tortoise resizes the resources of the running Pods in place (the resize subresource of Pods),
+	// and falls back to "RolloutRestart" only when the resize is infeasible or deferred.
+	// "InPlace" requires the in-place Pod resource resize to be enabled in the cluster.
+	//
+	// "RolloutRestart" is the default value.
+	// +optional
+	ResourceUpdateStrategy ResourceUpdateStrategy `json:"resourceUpdateStrategy,omitempty" protobuf:"bytes,10,opt,name=resourceUpdateStrategy"`
+	// ApplyWindows are the maintenance windows in which tortoise can apply the resource requests lowered by the vertical recommendation.
+	// The lowered resource requests restart the Pods only to save the resources,
+	// so, tortoise queues them until the next window opens, and shows them in .status.conditions.pendingContainerResourceRequests.
+	// Meanwhile, the increased resource requests are applied immediately regardless of the windows.
+	//
+	// If empty, tortoise uses the cluster-wide default windows configured by the cluster admin.
+	// If neither is configured, tortoise applies the lowered resource requests at any time.
+	// +optional
+	ApplyWindows []ApplyWindow `json:"applyWindows,omitempty" protobuf:"bytes,11,rep,name=applyWindows"`
+	// ReplicasRecommendationSlots configures the time slots of the minReplicas/maxReplicas recommendation of this tortoise.
+	// The empty fields (or nil) fall back to the cluster-wide configurations.
+	//
+	// When it's changed, tortoise reshapes the existing recommendations into the new time slots
+	// with the maximum value of the overlapping old time slots, instead of gathering the data again.
+	// +optional
+	ReplicasRecommendationSlots *ReplicasRecommendationSlots `json:"replicasRecommendationSlots,omitempty" protobuf:"bytes,12,opt,name=replicasRecommendationSlots"`
+}
+
+// ReplicasRecommendationSlots is the per-Tortoise configuration of the time slots of the minReplicas/maxReplicas recommendation.
+type ReplicasRecommendationSlots struct {
+	// TimeZone is the time zone of the time slots. (e.g., "Asia/Tokyo")
+	// If empty, tortoise uses the time zone of the tortoise controller.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,1,opt,name=timeZone"`
+	// Hours is the length of each time slot in hours. It must be a divisor of 24.
+	// If both Hours and Minutes are nil, tortoise uses the slot length configured in the tortoise controller.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=24
+	// +optional
+	Hours *int32 `json:"hours,omitempty" protobuf:"varint,2,opt,name=hours"`
+	// Minutes is the length of each time slot in minutes. It must be a divisor of 1440 (24 hours).
+	// It takes precedence over Hours, and allows the time slots shorter than an hour, such as 15 minutes.
+	// If nil, tortoise uses Hours.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1440
+	// +optional
+	Minutes *int32 `json:"minutes,omitempty" protobuf:"varint,4,opt,name=minutes"`
+	// GatheringDataPeriod is the period in which the workload is expected to behave similarly.
+	// If "daily", all the days share the same time slots. If "weekly", each day of the week has its own time slots.
+	// If empty, tortoise uses GatheringDataPeriodType of the tortoise controller.
+	// +optional
+	GatheringDataPeriod GatheringDataPeriod `json:"gatheringDataPeriod,omitempty" protobuf:"bytes,3,opt,name=gatheringDataPeriod"`
+}
+
+// +kubebuilder:validation:Enum=daily;weekly
+type GatheringDataPeriod string
+
+const (
+	GatheringDataPeriodDaily  GatheringDataPeriod = "daily"
+	GatheringDataPeriodWeekly GatheringDataPeriod = "weekly"
+)
+
+// ApplyWindow is a weekly window, which opens at StartHour and closes at EndHour on each of Weekdays.
+type ApplyWindow struct {
+	// Weekdays are the days of the week when the window opens.
+	// If empty, the window opens every day.
+	// +optional
+	Weekdays []Weekday `json:"weekdays,omitempty" protobuf:"bytes,1,rep,name=weekdays"`
+	// StartHour is the hour (0-23) when the window opens.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=23
+	StartHour int32 `json:"startHour" protobuf:"varint,2,name=startHour"`
+	// EndHour is the hour (1-24) when the window closes.
+	// If it's less than StartHour, the window closes at EndHour on the next day. (e.g., 22-6 is from 22:00 to 6:00 on the next day.)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=24
+	EndHour int32 `json:"endHour" protobuf:"varint,3,name=endHour"`
+	// TimeZone is the time zone of StartHour and EndHour. (e.g., "Asia/Tokyo")
+	// If empty, tortoise uses the time zone of the tortoise controller.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty" protobuf:"bytes,4,opt,name=timeZone"`
+}
+
+// +kubebuilder:validation:Enum=Sunday;Monday;Tuesday;Wednesday;Thursday;Friday;Saturday
+type Weekday string
+
+// RecommenderOverrides is the per-Tortoise values of the cluster-wide recommender configurations.
+// See the same name fields in the configuration of the tortoise controller for the details of each field.
+type RecommenderOverrides struct {
+	// PreferredMaxReplicas is the replica number which the tortoise tries to keep the replica number less than.
+	// +optional
+	PreferredMaxReplicas *int32 `json:"preferredMaxReplicas,omitempty" protobuf:"varint,1,opt,name=preferredMaxReplicas"`
+	// MinimumMinReplicas is the minimum minReplicas that tortoise can give to the HPA.
+	// +optional
+	MinimumMinReplicas *int32 `json:"minimumMinReplicas,omitempty" protobuf:"varint,2,opt,name=minimumMinReplicas"`
+	// MaxReplicasRecommendationMultiplier is the factor to calculate the maxReplicas recommendation from the current replica number. (e.g., "2.5")
+	// +optional
+	MaxReplicasRecommendationMultiplier *resource.Quantity `json:"maxReplicasRecommendationMultiplier,omitempty" protobuf:"bytes,3,opt,name=maxReplicasRecommendationMultiplier"`
+	// BufferRatioOnVerticalResource is the buffer ratio on the resource request for vertical scaling. (e.g., "0.2")
+	// +optional
+	BufferRatioOnVerticalResource *resource.Quantity `json:"bufferRatioOnVerticalResource,omitempty" protobuf:"bytes,4,opt,name=bufferRatioOnVerticalResource"`
+	// MaxAllowedScalingDownRatio is the max allowed scaling down ratio of the resource request. (e.g., "0.8")
+	// +optional
+	MaxAllowedScalingDownRatio *resource.Quantity `json:"maxAllowedScalingDownRatio,omitempty" protobuf:"bytes,5,opt,name=maxAllowedScalingDownRatio"`
+	// MinimumTargetResourceUtilization is the min target utilization that tortoise can give to the HPA.
+	// +optional
+	MinimumTargetResourceUtilization *int32 `json:"minimumTargetResourceUtilization,omitempty" protobuf:"varint,6,opt,name=minimumTargetResourceUtilization"`
+}
+
+type Recommender struct {
+	// Name is the name of the recommender.
+	Name string `json:"name" protobuf:"bytes,1,name=name"`
+	// ResponsibleFor represents which kind of recommendation this recommender generates.
+	ResponsibleFor []ResponsibleFor `json:"responsibleFor" protobuf:"bytes,2,rep,name=responsibleFor"`
 }
 
+// +kubebuilder:validation:Enum=Constraints
+type ResponsibleFor string
+
+const (
+	// ResponsibleForConstraints means the recommender is responsible for generating .status.recommendations.constraints.
+	ResponsibleForConstraints ResponsibleFor = "Constraints"
+)
+
 type ContainerAutoscalingPolicy struct {
 	// ContainerName is the name of target container.
 	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
@@ -144,6 +277,14 @@ const (
 	UpdateModeAuto      UpdateMode = "Auto"
 )
 
+// +kubebuilder:validation:Enum=RolloutRestart;InPlace
+type ResourceUpdateStrategy string
+
+const (
+	ResourceUpdateStrategyRolloutRestart ResourceUpdateStrategy = "RolloutRestart"
+	ResourceUpdateStrategyInPlace        ResourceUpdateStrategy = "InPlace"
+)
+
 // +kubebuilder:validation:Enum=Off;Horizontal;Vertical
 type AutoscalingType string
 
@@ -177,6 +318,21 @@ type TargetRefs struct {
 	// This is an optional field, and if you don't specify this field, tortoise will create a new default HPA named `tortoise-hpa-{tortoise name}`.
 	// +optional
 	HorizontalPodAutoscalerName *string `json:"horizontalPodAutoscalerName,omitempty" protobuf:"bytes,2,opt,name=horizontalPodAutoscalerName"`
+	// ScaledObjectName is the name of the KEDA ScaledObject which scales the scale target.
+	// You can specify existing ScaledObject only, otherwise Tortoise errors out.
+	//
+	// When it's specified, Tortoise manages the ScaledObject instead of HPA;
+	// it updates minReplicaCount, maxReplicaCount, and the target value of the cpu/memory triggers in the ScaledObject,
+	// and leaves the HPA generated by KEDA alone (Tortoise only reads its status).
+	// The cpu/memory triggers should have the containerName in the metadata and use the Utilization metric type.
+	// The cpu/memory triggers without containerName are removed, like Resource type metrics in HPA,
+	// and the other triggers are kept as they are.
+	//
+	// It cannot be specified with HorizontalPodAutoscalerName at the same time.
+	//
+	// This is an optional field.
+	// +optional
+	ScaledObjectName *string `json:"scaledObjectName,omitempty" protobuf:"bytes,3,opt,name=scaledObjectName"`
 }
 
 // CrossVersionObjectReference contains enough information toet identify the referred resource.
@@ -270,6 +426,10 @@ type TargetsStatus struct {
 	HorizontalPodAutoscaler string                              `json:"horizontalPodAutoscaler" protobuf:"bytes,1,opt,name=horizontalPodAutoscaler"`
 	ScaleTargetRef          CrossVersionObjectReference         `json:"scaleTargetRef" protobuf:"bytes,2,name=scaleTargetRef"`
 	VerticalPodAutoscalers  []TargetStatusVerticalPodAutoscaler `json:"verticalPodAutoscalers" protobuf:"bytes,3,name=verticalPodAutoscalers"`
+	// ScaledObject is the name of the KEDA ScaledObject managed by Tortoise.
+	// It's set only when .spec.targetRefs.scaledObjectName is specified.
+	// +optional
+	ScaledObject string `json:"scaledObject,omitempty" protobuf:"bytes,4,opt,name=scaledObject"`
 }
 
 type TargetStatusVerticalPodAutoscaler struct {
@@ -289,12 +449,46 @@ type Recommendations struct {
 	Horizontal HorizontalRecommendations `json:"horizontal,omitempty" protobuf:"bytes,1,opt,name=horizontal"`
 	// +optional
 	Vertical VerticalRecommendations `json:"vertical,omitempty" protobuf:"bytes,2,opt,name=vertical"`
+	// Constraints shows the constraints that this Tortoise has to take into account when generating the recommendation.
+	// It's generated by the recommender which is responsible for "Constraints" in .spec.recommenders,
+	// and Tortoise controller removes it when no recommender is responsible for "Constraints".
+	// When this field is empty, only the global constraints that are configured through the admin configuration are used.
+	// +optional
+	Constraints *Constraints `json:"constraints,omitempty" protobuf:"bytes,3,opt,name=constraints"`
+}
+
+type Constraints struct {
+	// MinimumMinReplicas is the minimum replica number that Tortoise gives to HPA.
+	// Note that if it has a value lower than a global MinimumMinReplicas, it's just ignored; a global constraint is priority.
+	// +optional
+	MinimumMinReplicas *int32 `json:"minimumMinReplicas,omitempty" protobuf:"varint,1,opt,name=minimumMinReplicas"`
+	// MinAllocatedResources is the minimum resource request that Tortoise gives to the Pods.
+	// Only MinAllocatedResources in each ContainerResourcePolicy is taken into account.
+	// Note that if it has a value lower than .spec.resourcePolicy or a global minimum resource request, it's just ignored.
+	// +optional
+	MinAllocatedResources []ContainerResourcePolicy `json:"minAllocatedResources,omitempty" protobuf:"bytes,2,opt,name=minAllocatedResources"`
 }
 
 type VerticalRecommendations struct {
 	// ContainerResourceRecommendation has the recommendation of container resource request.
 	// +optional
 	ContainerResourceRecommendation []RecommendedContainerResources `json:"containerResourceRecommendation" protobuf:"bytes,1,opt,name=containerResourceRecommendation"`
+	// OOMBumps has the containers which were OOMKilled recently.
+	// Tortoise recommends at least BumpedMemory as the memory request of those containers,
+	// and doesn't scale down their memory request until ScaleDownStoppedUntil.
+	// +optional
+	OOMBumps []ContainerOOMBump `json:"oomBumps,omitempty" protobuf:"bytes,2,opt,name=oomBumps"`
+}
+
+type ContainerOOMBump struct {
+	// ContainerName is the name of target container.
+	ContainerName string `json:"containerName" protobuf:"bytes,1,name=containerName"`
+	// LastOOMKilledTime is the last time when the container was OOMKilled.
+	LastOOMKilledTime metav1.Time `json:"lastOOMKilledTime" protobuf:"bytes,2,name=lastOOMKilledTime"`
+	// BumpedMemory is the memory recommendation bumped up from the memory request at the OOMKill.
+	BumpedMemory resource.Quantity `json:"bumpedMemory" protobuf:"bytes,3,name=bumpedMemory"`
+	// ScaleDownStoppedUntil is the time until when Tortoise doesn't scale down the memory request of the container.
+	ScaleDownStoppedUntil metav1.Time `json:"scaleDownStoppedUntil" protobuf:"bytes,4,name=scaleDownStoppedUntil"`
 }
 
 type RecommendedContainerResources struct {
@@ -321,6 +515,24 @@ type HorizontalRecommendations struct {
 	// It contains the recommendations for each time slot.
 	// +optional
 	MinReplicas []ReplicasRecommendation `json:"minReplicas,omitempty" protobuf:"bytes,3,opt,name=minReplicas"`
+	// MetricTargets has the recommendation of the target average value of Pods and External metrics in the HPA.
+	// Only the metrics which the cluster admin configures the bounds of are tuned.
+	// +optional
+	MetricTargets []HPAMetricTargetRecommendation `json:"metricTargets,omitempty" protobuf:"bytes,4,opt,name=metricTargets"`
+}
+
+type HPAMetricTargetRecommendation struct {
+	// Type is the type of the metric, Pods or External.
+	Type v2.MetricSourceType `json:"type" protobuf:"bytes,1,name=type"`
+	// MetricName is the name of the metric.
+	MetricName string `json:"metricName" protobuf:"bytes,2,name=metricName"`
+	// Target is the recommendation of the target average value.
+	Target resource.Quantity `json:"target" protobuf:"bytes,3,name=target"`
+	// ObservedPeak is the highest average value of the metric per replica observed recently.
+	// It's decayed day by day so that the old peak is eventually forgotten.
+	ObservedPeak resource.Quantity `json:"observedPeak" protobuf:"bytes,4,name=observedPeak"`
+	// ObservedPeakUpdatedAt is the time when ObservedPeak is updated.
+	ObservedPeakUpdatedAt metav1.Time `json:"observedPeakUpdatedAt" protobuf:"bytes,5,name=observedPeakUpdatedAt"`
 }
 
 type ReplicasRecommendation struct {
@@ -338,6 +550,23 @@ type ReplicasRecommendation struct {
 	Value int32 `json:"value" protobuf:"variant,5,name=value"`
 	// +optional
 	UpdatedAt metav1.Time `json:"updatedAt,omitempty" protobuf:"bytes,6,opt,name=updatedAt"`
+	// Samples are the maximum replica numbers observed in this time slot in the past weeks (or days, if GatheringDataPeriodType is daily),
+	// ordered from the oldest to the latest.
+	// They're recorded only when the cluster admin configures the replica recommendation strategy which needs them (EWMA or Percentile).
+	// +optional
+	Samples []int32 `json:"samples,omitempty" protobuf:"varint,7,rep,name=samples"`
+	// FromMinute is the minute of the hour From when the time slot starts.
+	// The time slot starts at From:FromMinute. (e.g., From=12 and FromMinute=15 means 12:15.)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	FromMinute int `json:"fromMinute,omitempty" protobuf:"varint,8,opt,name=fromMinute"`
+	// ToMinute is the minute of the hour To when the time slot ends.
+	// The time slot ends at To:ToMinute. (e.g., To=12 and ToMinute=30 means 12:30.)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=59
+	// +optional
+	ToMinute int `json:"toMinute,omitempty" protobuf:"varint,9,opt,name=toMinute"`
 }
 
 type HPATargetUtilizationRecommendationPerContainer struct {
@@ -364,6 +593,22 @@ type Conditions struct {
 	// (Tortoise sometimes doesn't immediately apply the recommendation value to the resource request for the sake of safety.)
 	// +optional
 	ContainerResourceRequests []ContainerResourceRequests `json:"containerResourceRequests,omitempty" protobuf:"bytes,3,opt,name=containerResourceRequests"`
+	// PreviousContainerResourceRequests has ContainerResourceRequests before the last change.
+	// When the Pods crash (OOMKilled or CrashLoopBackOff) soon after the change lowered the resource requests,
+	// Tortoise rolls ContainerResourceRequests back to it.
+	// +optional
+	PreviousContainerResourceRequests []ContainerResourceRequests `json:"previousContainerResourceRequests,omitempty" protobuf:"bytes,4,opt,name=previousContainerResourceRequests"`
+	// ResourceRequestFloors has the minimum resource request for each container, which is raised by the rollback.
+	// Tortoise never sets the resource request lower than it.
+	// +optional
+	ResourceRequestFloors []ContainerResourceRequests `json:"resourceRequestFloors,omitempty" protobuf:"bytes,5,opt,name=resourceRequestFloors"`
+	// PendingContainerResourceRequests has the lowered resource request for each container,
+	// which is queued until the next apply window opens. See .spec.applyWindows.
+	// +optional
+	PendingContainerResourceRequests []ContainerResourceRequests `json:"pendingContainerResourceRequests,omitempty" protobuf:"bytes,6,opt,name=pendingContainerResourceRequests"`
+	// NextApplyWindowTime is the time when the next apply window opens and PendingContainerResourceRequests is applied.
+	// +optional
+	NextApplyWindowTime *metav1.Time `json:"nextApplyWindowTime,omitempty" protobuf:"bytes,7,opt,name=nextApplyWindowTime"`
 }
 
 type ContainerResourceRequests struct {
@@ -385,6 +630,14 @@ const (
 	// due to exclusion mechanisms (GlobalDisableMode, NamespaceExclusion, or ScaleOpsManaged).
 	// When Status=True, Tortoise operates in effective Off mode (read-only) regardless of spec.updateMode.
 	TortoiseConditionTypeEffectiveModeOverridden TortoiseConditionType = "EffectiveModeOverridden"
+	// TortoiseConditionTypeVerticalRecommendationRolledBack indicates that the resource requests were rolled back
+	// because the Pods crashed (OOMKilled or CrashLoopBackOff) after the resource requests were lowered.
+	TortoiseConditionTypeVerticalRecommendationRolledBack TortoiseConditionType = "VerticalRecommendationRolledBack"
+	// TortoiseConditionTypeResizingInPlace is True while the running Pods are being resized in place.
+	TortoiseConditionTypeResizingInPlace TortoiseConditionType = "ResizingInPlace"
+	// TortoiseConditionTypeRestartDeferred is True while the restart of the scale target is deferred
+	// because of the PodDisruptionBudget or the limit of the concurrent restarts.
+	TortoiseConditionTypeRestartDeferred TortoiseConditionType = "RestartDeferred"
 )
 
 type TortoiseCondition struct {
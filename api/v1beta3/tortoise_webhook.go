@@ -29,8 +29,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -38,13 +41,34 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
-	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
 )
 
 // log is for logging in this package.
 var tortoiselog = ctrl.Log.WithName("tortoise-resource")
 var ClientService *service
 
+// recommenderOverridesBounds is the range of .spec.recommenderOverrides that the cluster admin allows.
+var recommenderOverridesBounds config.RecommenderOverridesBounds
+
+// SetRecommenderOverridesBounds sets the range of .spec.recommenderOverrides that the webhook allows.
+// It has to be called before the webhook starts.
+func SetRecommenderOverridesBounds(bounds config.RecommenderOverridesBounds) {
+	recommenderOverridesBounds = bounds
+}
+
+// sidecarInjectors is the map from the name of the sidecar container injected by a mutating webhook to its configuration.
+// Only the istio sidecar proxy is known until SetSidecarInjectors is called.
+var sidecarInjectors = map[string]config.SidecarInjector{
+	config.IstioProxyContainerName: config.IstioSidecarInjector("", ""),
+}
+
+// SetSidecarInjectors sets the sidecar containers injected by mutating webhooks, which the webhook regards as the containers in the scale target.
+// It has to be called before the webhook starts.
+func SetSidecarInjectors(injectors map[string]config.SidecarInjector) {
+	sidecarInjectors = injectors
+}
+
 func (r *Tortoise) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	ClientService = newService(mgr.GetClient())
 	return ctrl.NewWebhookManagedBy(mgr).
@@ -69,52 +93,51 @@ func (r *Tortoise) defaultAutoscalingPolicy() {
 		return
 	}
 
-	// TODO: support other resources.
-	if r.Spec.TargetRefs.ScaleTargetRef.Kind == "Deployment" {
-		d, err := ClientService.GetDeploymentOnTortoise(ctx, r)
-		if err != nil {
-			tortoiselog.Error(err, "failed to get deployment")
-			return
-		}
+	if !isSupportedScaleTargetKind(r.Spec.TargetRefs.ScaleTargetRef.Kind) {
+		return
+	}
 
-		containers := d.Spec.Template.Spec.DeepCopy().Containers
-		if d.Spec.Template.Annotations != nil {
-			if v, ok := d.Spec.Template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
-				// If the deployment has the sidecar injection annotation, the Pods will have the sidecar container in addition.
-				containers = append(d.Spec.Template.Spec.Containers, v1.Container{
-					Name: "istio-proxy",
-				})
-			}
-		}
+	template, err := ClientService.GetPodTemplateOnTortoise(ctx, r)
+	if err != nil {
+		tortoiselog.Error(err, "failed to get the scale target")
+		return
+	}
 
-		if len(containers) != len(r.Spec.AutoscalingPolicy) {
-			for _, c := range containers {
-				policyExist := false
-				for _, p := range r.Spec.AutoscalingPolicy {
-					if c.Name == p.ContainerName {
-						policyExist = true
-						break
-					}
-				}
-				if !policyExist {
-					r.Spec.AutoscalingPolicy = append(r.Spec.AutoscalingPolicy, ContainerAutoscalingPolicy{
-						ContainerName: c.Name,
-						Policy:        map[v1.ResourceName]AutoscalingType{},
-					})
+	containers := scalableContainers(&template.Spec)
+	for _, name := range injectedSidecars(template) {
+		// If the scale target has the sidecar injection annotation, the Pods will have the sidecar container in addition.
+		containers = append(containers, v1.Container{
+			Name: name,
+		})
+	}
+
+	if len(containers) != len(r.Spec.AutoscalingPolicy) {
+		for _, c := range containers {
+			policyExist := false
+			for _, p := range r.Spec.AutoscalingPolicy {
+				if c.Name == p.ContainerName {
+					policyExist = true
+					break
 				}
 			}
+			if !policyExist {
+				r.Spec.AutoscalingPolicy = append(r.Spec.AutoscalingPolicy, ContainerAutoscalingPolicy{
+					ContainerName: c.Name,
+					Policy:        map[v1.ResourceName]AutoscalingType{},
+				})
+			}
 		}
+	}
 
-		// the default policy is Off
-		for i := range r.Spec.AutoscalingPolicy {
-			_, ok := r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU]
-			if !ok {
-				r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU] = AutoscalingTypeOff
-			}
-			_, ok = r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory]
-			if !ok {
-				r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory] = AutoscalingTypeOff
-			}
+	// the default policy is Off
+	for i := range r.Spec.AutoscalingPolicy {
+		_, ok := r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU]
+		if !ok {
+			r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceCPU] = AutoscalingTypeOff
+		}
+		_, ok = r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory]
+		if !ok {
+			r.Spec.AutoscalingPolicy[i].Policy[v1.ResourceMemory] = AutoscalingTypeOff
 		}
 	}
 }
@@ -148,20 +171,54 @@ func hasHorizontal(tortoise *Tortoise) bool {
 	return false
 }
 
+// isSupportedScaleTargetKind returns true if tortoise can handle the kind as the scale target.
+func isSupportedScaleTargetKind(kind string) bool {
+	return kind == "Deployment" || kind == "StatefulSet" || kind == "Rollout"
+}
+
 func validateTortoise(t *Tortoise) error {
 	fieldPath := field.NewPath("spec")
 	if t.Spec.TargetRefs.ScaleTargetRef.Kind == "" {
 		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
 	}
 
-	if t.Spec.TargetRefs.ScaleTargetRef.Kind != "Deployment" {
-		return fmt.Errorf("%s: only Deployment is supported now", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
+	if !isSupportedScaleTargetKind(t.Spec.TargetRefs.ScaleTargetRef.Kind) {
+		return fmt.Errorf("%s: only Deployment, StatefulSet and Rollout are supported now", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
 	}
 
 	if t.Spec.TargetRefs.ScaleTargetRef.Name == "" {
 		return fmt.Errorf("%s: shouldn't be empty", fieldPath.Child("targetRefs", "scaleTargetRef", "name"))
 	}
 
+	if t.Spec.TargetRefs.HorizontalPodAutoscalerName != nil && t.Spec.TargetRefs.ScaledObjectName != nil {
+		return fmt.Errorf("%s: cannot be specified with %s", fieldPath.Child("targetRefs", "scaledObjectName"), fieldPath.Child("targetRefs", "horizontalPodAutoscalerName"))
+	}
+
+	if err := validateRecommenderOverrides(t.Spec.RecommenderOverrides, fieldPath.Child("recommenderOverrides")); err != nil {
+		return err
+	}
+
+	for i, w := range t.Spec.ApplyWindows {
+		if w.StartHour == w.EndHour {
+			return fmt.Errorf("%s: should be different from startHour", fieldPath.Child("applyWindows").Index(i).Child("endHour"))
+		}
+		if _, err := time.LoadLocation(w.TimeZone); err != nil {
+			return fmt.Errorf("%s: invalid time zone: %w", fieldPath.Child("applyWindows").Index(i).Child("timeZone"), err)
+		}
+	}
+
+	if slots := t.Spec.ReplicasRecommendationSlots; slots != nil {
+		if _, err := time.LoadLocation(slots.TimeZone); err != nil {
+			return fmt.Errorf("%s: invalid time zone: %w", fieldPath.Child("replicasRecommendationSlots", "timeZone"), err)
+		}
+		if slots.Hours != nil && (*slots.Hours < 1 || 24%*slots.Hours != 0) {
+			return fmt.Errorf("%s: should be a divisor of 24", fieldPath.Child("replicasRecommendationSlots", "hours"))
+		}
+		if slots.Minutes != nil && (*slots.Minutes < 1 || 24*60%*slots.Minutes != 0) {
+			return fmt.Errorf("%s: should be a divisor of 1440", fieldPath.Child("replicasRecommendationSlots", "minutes"))
+		}
+	}
+
 	if t.Spec.UpdateMode == UpdateModeEmergency &&
 		t.Status.TortoisePhase != TortoisePhaseWorking && t.Status.TortoisePhase != TortoisePhaseEmergency && t.Status.TortoisePhase != TortoisePhaseBackToNormal {
 		return fmt.Errorf("%s: emergency mode is only available for tortoises with Running phase", fieldPath.Child("updateMode"))
@@ -170,43 +227,91 @@ func validateTortoise(t *Tortoise) error {
 	return nil
 }
 
+func validateRecommenderOverrides(o *RecommenderOverrides, fieldPath *field.Path) error {
+	if o == nil {
+		return nil
+	}
+
+	b := recommenderOverridesBounds
+	int32Fields := []struct {
+		name  string
+		value *int32
+		bound *config.Int32Range
+	}{
+		{"preferredMaxReplicas", o.PreferredMaxReplicas, b.PreferredMaxReplicas},
+		{"minimumMinReplicas", o.MinimumMinReplicas, b.MinimumMinReplicas},
+		{"minimumTargetResourceUtilization", o.MinimumTargetResourceUtilization, b.MinimumTargetResourceUtilization},
+	}
+	for _, f := range int32Fields {
+		if f.value == nil {
+			continue
+		}
+		if f.bound == nil {
+			return fmt.Errorf("%s: the cluster admin doesn't allow overriding this field", fieldPath.Child(f.name))
+		}
+		if !f.bound.Contains(*f.value) {
+			return fmt.Errorf("%s: should be between %d and %d", fieldPath.Child(f.name), f.bound.Min, f.bound.Max)
+		}
+	}
+
+	float64Fields := []struct {
+		name  string
+		value *resource.Quantity
+		bound *config.Float64Range
+	}{
+		{"maxReplicasRecommendationMultiplier", o.MaxReplicasRecommendationMultiplier, b.MaxReplicasRecommendationMultiplier},
+		{"bufferRatioOnVerticalResource", o.BufferRatioOnVerticalResource, b.BufferRatioOnVerticalResource},
+		{"maxAllowedScalingDownRatio", o.MaxAllowedScalingDownRatio, b.MaxAllowedScalingDownRatio},
+	}
+	for _, f := range float64Fields {
+		if f.value == nil {
+			continue
+		}
+		if f.bound == nil {
+			return fmt.Errorf("%s: the cluster admin doesn't allow overriding this field", fieldPath.Child(f.name))
+		}
+		if !f.bound.Contains(f.value.AsApproximateFloat64()) {
+			return fmt.Errorf("%s: should be between %v and %v", fieldPath.Child(f.name), f.bound.Min, f.bound.Max)
+		}
+	}
+
+	if o.MinimumMinReplicas != nil && o.PreferredMaxReplicas != nil && *o.MinimumMinReplicas >= *o.PreferredMaxReplicas {
+		return fmt.Errorf("%s: should be less than %s", fieldPath.Child("minimumMinReplicas"), fieldPath.Child("preferredMaxReplicas"))
+	}
+
+	return nil
+}
+
 // ValidateCreate implements webhook.Validator so a webhook will be registered for the type
 func (r *Tortoise) ValidateCreate() (admission.Warnings, error) {
 	ctx := context.Background()
 	tortoiselog.Info("validate create", "name", r.Name)
 	fieldPath := field.NewPath("spec")
-	if r.Spec.TargetRefs.ScaleTargetRef.Kind != "Deployment" {
-		return nil, fmt.Errorf("only deployment is supported in %s at the moment", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
+	if !isSupportedScaleTargetKind(r.Spec.TargetRefs.ScaleTargetRef.Kind) {
+		return nil, fmt.Errorf("only Deployment, StatefulSet and Rollout are supported in %s at the moment", fieldPath.Child("targetRefs", "scaleTargetRef", "kind"))
 	}
 
-	if r.Spec.TargetRefs.ScaleTargetRef.Kind == "Deployment" {
-		// TODO: do the same validation for other resources.
-		d, err := ClientService.GetDeploymentOnTortoise(ctx, r)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get the deployment defined in %s: %w", fieldPath.Child("targetRefs", "scaleTargetRef"), err)
-		}
+	template, err := ClientService.GetPodTemplateOnTortoise(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the %s defined in %s: %w", r.Spec.TargetRefs.ScaleTargetRef.Kind, fieldPath.Child("targetRefs", "scaleTargetRef"), err)
+	}
 
-		containersInDP := sets.New[string]()
-		for _, c := range d.Spec.Template.Spec.Containers {
-			containersInDP.Insert(c.Name)
-		}
+	containersInTarget := sets.New[string]()
+	for _, c := range scalableContainers(&template.Spec) {
+		containersInTarget.Insert(c.Name)
+	}
 
-		if d.Spec.Template.Annotations != nil {
-			if v, ok := d.Spec.Template.Annotations[annotation.IstioSidecarInjectionAnnotation]; ok && v == "true" {
-				// If the deployment has the sidecar injection annotation, the Pods will have the sidecar container in addition.
-				containersInDP.Insert("istio-proxy")
-			}
-		}
+	// If the scale target has the sidecar injection annotation, the Pods will have the sidecar container in addition.
+	containersInTarget.Insert(injectedSidecars(template)...)
 
-		containerWithPolicy := sets.New[string]()
-		for _, p := range r.Spec.AutoscalingPolicy {
-			containerWithPolicy.Insert(p.ContainerName)
-		}
+	containerWithPolicy := sets.New[string]()
+	for _, p := range r.Spec.AutoscalingPolicy {
+		containerWithPolicy.Insert(p.ContainerName)
+	}
 
-		uselessPolicies := containerWithPolicy.Difference(containersInDP)
-		if uselessPolicies.Len() != 0 {
-			return nil, fmt.Errorf("%s: tortoise should not have the policies for the container(s) which isn't defined in the deployment, but, it have the policy for the container(s) %v", fieldPath.Child("resourcePolicy"), uselessPolicies)
-		}
+	uselessPolicies := containerWithPolicy.Difference(containersInTarget)
+	if uselessPolicies.Len() != 0 {
+		return nil, fmt.Errorf("%s: tortoise should not have the policies for the container(s) which isn't defined in the %s, but, it have the policy for the container(s) %v", fieldPath.Child("resourcePolicy"), r.Spec.TargetRefs.ScaleTargetRef.Kind, uselessPolicies)
 	}
 
 	return nil, validateTortoise(r)
@@ -242,6 +347,17 @@ func (r *Tortoise) ValidateUpdate(old runtime.Object) (admission.Warnings, error
 
 		// removed is OK
 	}
+	if r.Spec.TargetRefs.ScaledObjectName != nil {
+		if oldTortoise.Spec.TargetRefs.ScaledObjectName == nil || *oldTortoise.Spec.TargetRefs.ScaledObjectName != *r.Spec.TargetRefs.ScaledObjectName {
+			if *r.Spec.TargetRefs.ScaledObjectName != r.Status.Targets.ScaledObject {
+				// newly specified or updated.
+				return nil, fmt.Errorf("%s: immutable field get changed", fieldPath.Child("targetRefs", "scaledObjectName"))
+			}
+			// updated but the same value as status, that is OK
+		}
+
+		// removed is OK
+	}
 
 	if hasHorizontal(oldTortoise) && !hasHorizontal(r) {
 		if r.Spec.DeletionPolicy == DeletionPolicyNoDelete {
@@ -252,6 +368,10 @@ func (r *Tortoise) ValidateUpdate(old runtime.Object) (admission.Warnings, error
 		if r.Spec.TargetRefs.HorizontalPodAutoscalerName != nil {
 			return nil, fmt.Errorf("%s: no horizontal policy exists. It will cause the deletion of HPA and you need to remove horizontalPodAutoscalerName to allow the deletion.", fieldPath.Child("targetRefs", "horizontalPodAutoscalerName"))
 		}
+
+		if r.Spec.TargetRefs.ScaledObjectName != nil {
+			return nil, fmt.Errorf("%s: no horizontal policy exists. It will cause the deletion of HPA and you need to remove scaledObjectName to allow the deletion.", fieldPath.Child("targetRefs", "scaledObjectName"))
+		}
 	}
 
 	return nil, nil
@@ -263,3 +383,27 @@ func (r *Tortoise) ValidateDelete() (admission.Warnings, error) {
 	tortoiselog.Info("validate delete", "name", r.Name)
 	return nil, nil
 }
+
+// scalableContainers returns the containers which Tortoise scales in the Pod spec:
+// all the containers and the native sidecars, which are the init containers with restartPolicy: Always.
+func scalableContainers(spec *v1.PodSpec) []v1.Container {
+	containers := append([]v1.Container{}, spec.Containers...)
+	for _, c := range spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+// injectedSidecars returns the names of the sidecar containers which will be injected into the Pods by the annotations on the Pod template.
+func injectedSidecars(template *v1.PodTemplateSpec) []string {
+	names := []string{}
+	for name, injector := range sidecarInjectors {
+		if injector.Injected(template.Annotations) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
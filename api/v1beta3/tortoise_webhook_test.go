@@ -36,6 +36,7 @@ import (
 	v1 "k8s.io/api/apps/v1"
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/yaml"
 
@@ -97,18 +98,19 @@ func mutateTest(before, after, deployment, hpa string) {
 	Expect(ret.Spec).Should(Equal(afterTortoise.Spec))
 }
 
-func validateCreationTest(tortoise, hpa, deployment string, valid bool) {
+func validateCreationTest(tortoise, hpa, workload string, valid bool) {
 	ctx := context.Background()
 
-	y, err := os.ReadFile(deployment)
+	// workload is either Deployment, StatefulSet or Rollout.
+	y, err := os.ReadFile(workload)
 	Expect(err).NotTo(HaveOccurred())
-	deploy := &v1.Deployment{}
-	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(deploy)
+	workloadObj := &unstructured.Unstructured{}
+	err = yaml.NewYAMLOrJSONDecoder(bytes.NewReader(y), 4096).Decode(workloadObj)
 	Expect(err).NotTo(HaveOccurred())
-	err = k8sClient.Create(ctx, deploy)
+	err = k8sClient.Create(ctx, workloadObj)
 	Expect(err).NotTo(HaveOccurred())
 	defer func() {
-		err = k8sClient.Delete(ctx, deploy)
+		err = k8sClient.Delete(ctx, workloadObj)
 		Expect(err).NotTo(HaveOccurred())
 	}()
 
@@ -228,6 +230,9 @@ var _ = Describe("Tortoise Webhook", func() {
 		It("should mutate a Tortoise with istio", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "with-istio", "before.yaml"), filepath.Join("testdata", "mutating", "with-istio", "after.yaml"), filepath.Join("testdata", "mutating", "with-istio", "deployment.yaml"), "")
 		})
+		It("should mutate a Tortoise with the policy for the native sidecar", func() {
+			mutateTest(filepath.Join("testdata", "mutating", "with-native-sidecar", "before.yaml"), filepath.Join("testdata", "mutating", "with-native-sidecar", "after.yaml"), filepath.Join("testdata", "mutating", "with-native-sidecar", "deployment.yaml"), "")
+		})
 		It("should mutate a Tortoise which some autoscalingPolicy is specified, but not all", func() {
 			mutateTest(filepath.Join("testdata", "mutating", "some-specified-others-not", "before.yaml"), filepath.Join("testdata", "mutating", "some-specified-others-not", "after.yaml"), filepath.Join("testdata", "mutating", "some-specified-others-not", "deployment.yaml"), "")
 		})
@@ -239,12 +244,36 @@ var _ = Describe("Tortoise Webhook", func() {
 		It("should create a valid Tortoise for the deployment with istio", func() {
 			validateCreationTest(filepath.Join("testdata", "validating", "success-with-istio", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-with-istio", "hpa.yaml"), filepath.Join("testdata", "validating", "success-with-istio", "deployment.yaml"), true)
 		})
+		It("should create a valid Tortoise with the policy for the native sidecar", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "success-native-sidecar", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-native-sidecar", "hpa.yaml"), filepath.Join("testdata", "validating", "success-native-sidecar", "deployment.yaml"), true)
+		})
+		It("should create a valid Tortoise for the statefulset", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "success-statefulset", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-statefulset", "hpa.yaml"), filepath.Join("testdata", "validating", "success-statefulset", "statefulset.yaml"), true)
+		})
+		It("should create a valid Tortoise targetting Rollout", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "success-rollout", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-rollout", "hpa.yaml"), filepath.Join("testdata", "validating", "success-rollout", "rollout.yaml"), true)
+		})
 		It("invalid: Tortoise is targetting the resource other than Deployment", func() {
 			validateCreationTest(filepath.Join("testdata", "validating", "not-targetting-deployment", "tortoise.yaml"), filepath.Join("testdata", "validating", "not-targetting-deployment", "hpa.yaml"), filepath.Join("testdata", "validating", "not-targetting-deployment", "deployment.yaml"), false)
 		})
 		It("invalid: Tortoise has resource policy for non-existing container", func() {
 			validateCreationTest(filepath.Join("testdata", "validating", "useless-policy", "tortoise.yaml"), filepath.Join("testdata", "validating", "useless-policy", "hpa.yaml"), filepath.Join("testdata", "validating", "useless-policy", "deployment.yaml"), false)
 		})
+		It("should create a valid Tortoise with recommenderOverrides within the bounds", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "success-recommender-overrides", "tortoise.yaml"), filepath.Join("testdata", "validating", "success-recommender-overrides", "hpa.yaml"), filepath.Join("testdata", "validating", "success-recommender-overrides", "deployment.yaml"), true)
+		})
+		It("invalid: Tortoise has recommenderOverrides out of the bounds", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "recommender-overrides-out-of-bounds", "tortoise.yaml"), filepath.Join("testdata", "validating", "recommender-overrides-out-of-bounds", "hpa.yaml"), filepath.Join("testdata", "validating", "recommender-overrides-out-of-bounds", "deployment.yaml"), false)
+		})
+		It("invalid: Tortoise has applyWindows with an invalid time zone", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "apply-windows-invalid-time-zone", "tortoise.yaml"), filepath.Join("testdata", "validating", "apply-windows-invalid-time-zone", "hpa.yaml"), filepath.Join("testdata", "validating", "apply-windows-invalid-time-zone", "deployment.yaml"), false)
+		})
+		It("invalid: Tortoise has replicasRecommendationSlots with hours which isn't a divisor of 24", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "replicas-recommendation-slots-invalid-hours", "tortoise.yaml"), filepath.Join("testdata", "validating", "replicas-recommendation-slots-invalid-hours", "hpa.yaml"), filepath.Join("testdata", "validating", "replicas-recommendation-slots-invalid-hours", "deployment.yaml"), false)
+		})
+		It("invalid: Tortoise has both horizontalPodAutoscalerName and scaledObjectName", func() {
+			validateCreationTest(filepath.Join("testdata", "validating", "hpa-and-scaledobject", "tortoise.yaml"), filepath.Join("testdata", "validating", "hpa-and-scaledobject", "hpa.yaml"), filepath.Join("testdata", "validating", "hpa-and-scaledobject", "deployment.yaml"), false)
+		})
 	})
 	Context("validating(updating)", func() {
 		It("should update a valid Tortoise", func() {
@@ -55,6 +55,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/mercari/tortoise/pkg/config"
 )
 
 // These tests use Ginkgo (BDD-style Go testing framework). Refer to
@@ -112,7 +114,7 @@ var _ = BeforeSuite(func() {
 	}
 
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases")},
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "bases"), filepath.Join("testdata", "crd")},
 		ErrorIfCRDPathMissing: false,
 		WebhookInstallOptions: envtest.WebhookInstallOptions{
 			Paths:              []string{filepath.Join("..", "..", "config", "webhook", "service.yaml")},
@@ -159,6 +161,10 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
+	SetRecommenderOverridesBounds(config.RecommenderOverridesBounds{
+		PreferredMaxReplicas:          &config.Int32Range{Min: 10, Max: 50},
+		BufferRatioOnVerticalResource: &config.Float64Range{Min: 0.1, Max: 0.3},
+	})
 	err = (&Tortoise{}).SetupWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -35,6 +35,26 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplyWindow) DeepCopyInto(out *ApplyWindow) {
+	*out = *in
+	if in.Weekdays != nil {
+		in, out := &in.Weekdays, &out.Weekdays
+		*out = make([]Weekday, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplyWindow.
+func (in *ApplyWindow) DeepCopy() *ApplyWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplyWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Conditions) DeepCopyInto(out *Conditions) {
 	*out = *in
@@ -59,6 +79,31 @@ func (in *Conditions) DeepCopyInto(out *Conditions) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.PreviousContainerResourceRequests != nil {
+		in, out := &in.PreviousContainerResourceRequests, &out.PreviousContainerResourceRequests
+		*out = make([]ContainerResourceRequests, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceRequestFloors != nil {
+		in, out := &in.ResourceRequestFloors, &out.ResourceRequestFloors
+		*out = make([]ContainerResourceRequests, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PendingContainerResourceRequests != nil {
+		in, out := &in.PendingContainerResourceRequests, &out.PendingContainerResourceRequests
+		*out = make([]ContainerResourceRequests, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NextApplyWindowTime != nil {
+		in, out := &in.NextApplyWindowTime, &out.NextApplyWindowTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Conditions.
@@ -71,6 +116,33 @@ func (in *Conditions) DeepCopy() *Conditions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Constraints) DeepCopyInto(out *Constraints) {
+	*out = *in
+	if in.MinimumMinReplicas != nil {
+		in, out := &in.MinimumMinReplicas, &out.MinimumMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAllocatedResources != nil {
+		in, out := &in.MinAllocatedResources, &out.MinAllocatedResources
+		*out = make([]ContainerResourcePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Constraints.
+func (in *Constraints) DeepCopy() *Constraints {
+	if in == nil {
+		return nil
+	}
+	out := new(Constraints)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerAutoscalingPolicy) DeepCopyInto(out *ContainerAutoscalingPolicy) {
 	*out = *in
@@ -93,6 +165,24 @@ func (in *ContainerAutoscalingPolicy) DeepCopy() *ContainerAutoscalingPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerOOMBump) DeepCopyInto(out *ContainerOOMBump) {
+	*out = *in
+	in.LastOOMKilledTime.DeepCopyInto(&out.LastOOMKilledTime)
+	out.BumpedMemory = in.BumpedMemory.DeepCopy()
+	in.ScaleDownStoppedUntil.DeepCopyInto(&out.ScaleDownStoppedUntil)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerOOMBump.
+func (in *ContainerOOMBump) DeepCopy() *ContainerOOMBump {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerOOMBump)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContainerRecommendationFromVPA) DeepCopyInto(out *ContainerRecommendationFromVPA) {
 	*out = *in
@@ -210,6 +300,24 @@ func (in *CrossVersionObjectReference) DeepCopy() *CrossVersionObjectReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HPAMetricTargetRecommendation) DeepCopyInto(out *HPAMetricTargetRecommendation) {
+	*out = *in
+	out.Target = in.Target.DeepCopy()
+	out.ObservedPeak = in.ObservedPeak.DeepCopy()
+	in.ObservedPeakUpdatedAt.DeepCopyInto(&out.ObservedPeakUpdatedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HPAMetricTargetRecommendation.
+func (in *HPAMetricTargetRecommendation) DeepCopy() *HPAMetricTargetRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(HPAMetricTargetRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HPATargetUtilizationRecommendationPerContainer) DeepCopyInto(out *HPATargetUtilizationRecommendationPerContainer) {
 	*out = *in
@@ -256,6 +364,13 @@ func (in *HorizontalRecommendations) DeepCopyInto(out *HorizontalRecommendations
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.MetricTargets != nil {
+		in, out := &in.MetricTargets, &out.MetricTargets
+		*out = make([]HPAMetricTargetRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HorizontalRecommendations.
@@ -273,6 +388,11 @@ func (in *Recommendations) DeepCopyInto(out *Recommendations) {
 	*out = *in
 	in.Horizontal.DeepCopyInto(&out.Horizontal)
 	in.Vertical.DeepCopyInto(&out.Vertical)
+	if in.Constraints != nil {
+		in, out := &in.Constraints, &out.Constraints
+		*out = new(Constraints)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommendations.
@@ -307,6 +427,71 @@ func (in *RecommendedContainerResources) DeepCopy() *RecommendedContainerResourc
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Recommender) DeepCopyInto(out *Recommender) {
+	*out = *in
+	if in.ResponsibleFor != nil {
+		in, out := &in.ResponsibleFor, &out.ResponsibleFor
+		*out = make([]ResponsibleFor, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Recommender.
+func (in *Recommender) DeepCopy() *Recommender {
+	if in == nil {
+		return nil
+	}
+	out := new(Recommender)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommenderOverrides) DeepCopyInto(out *RecommenderOverrides) {
+	*out = *in
+	if in.PreferredMaxReplicas != nil {
+		in, out := &in.PreferredMaxReplicas, &out.PreferredMaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinimumMinReplicas != nil {
+		in, out := &in.MinimumMinReplicas, &out.MinimumMinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicasRecommendationMultiplier != nil {
+		in, out := &in.MaxReplicasRecommendationMultiplier, &out.MaxReplicasRecommendationMultiplier
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.BufferRatioOnVerticalResource != nil {
+		in, out := &in.BufferRatioOnVerticalResource, &out.BufferRatioOnVerticalResource
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxAllowedScalingDownRatio != nil {
+		in, out := &in.MaxAllowedScalingDownRatio, &out.MaxAllowedScalingDownRatio
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MinimumTargetResourceUtilization != nil {
+		in, out := &in.MinimumTargetResourceUtilization, &out.MinimumTargetResourceUtilization
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommenderOverrides.
+func (in *RecommenderOverrides) DeepCopy() *RecommenderOverrides {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommenderOverrides)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicasRecommendation) DeepCopyInto(out *ReplicasRecommendation) {
 	*out = *in
@@ -316,6 +501,11 @@ func (in *ReplicasRecommendation) DeepCopyInto(out *ReplicasRecommendation) {
 		**out = **in
 	}
 	in.UpdatedAt.DeepCopyInto(&out.UpdatedAt)
+	if in.Samples != nil {
+		in, out := &in.Samples, &out.Samples
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicasRecommendation.
@@ -328,6 +518,31 @@ func (in *ReplicasRecommendation) DeepCopy() *ReplicasRecommendation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicasRecommendationSlots) DeepCopyInto(out *ReplicasRecommendationSlots) {
+	*out = *in
+	if in.Hours != nil {
+		in, out := &in.Hours, &out.Hours
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Minutes != nil {
+		in, out := &in.Minutes, &out.Minutes
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicasRecommendationSlots.
+func (in *ReplicasRecommendationSlots) DeepCopy() *ReplicasRecommendationSlots {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicasRecommendationSlots)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourcePhase) DeepCopyInto(out *ResourcePhase) {
 	*out = *in
@@ -370,6 +585,11 @@ func (in *TargetRefs) DeepCopyInto(out *TargetRefs) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ScaledObjectName != nil {
+		in, out := &in.ScaledObjectName, &out.ScaledObjectName
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetRefs.
@@ -522,6 +742,30 @@ func (in *TortoiseSpec) DeepCopyInto(out *TortoiseSpec) {
 		*out = new(v2.HorizontalPodAutoscalerBehavior)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Recommenders != nil {
+		in, out := &in.Recommenders, &out.Recommenders
+		*out = make([]Recommender, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RecommenderOverrides != nil {
+		in, out := &in.RecommenderOverrides, &out.RecommenderOverrides
+		*out = new(RecommenderOverrides)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApplyWindows != nil {
+		in, out := &in.ApplyWindows, &out.ApplyWindows
+		*out = make([]ApplyWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicasRecommendationSlots != nil {
+		in, out := &in.ReplicasRecommendationSlots, &out.ReplicasRecommendationSlots
+		*out = new(ReplicasRecommendationSlots)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TortoiseSpec.
@@ -576,6 +820,13 @@ func (in *VerticalRecommendations) DeepCopyInto(out *VerticalRecommendations) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.OOMBumps != nil {
+		in, out := &in.OOMBumps, &out.OOMBumps
+		*out = make([]ContainerOOMBump, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VerticalRecommendations.
@@ -43,21 +43,35 @@ import (
 	kube_client "k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 
 	autoscalingv2 "github.com/mercari/tortoise/api/autoscaling/v2"
 	v1 "github.com/mercari/tortoise/api/core/v1"
+	autoscalingv1alpha1 "github.com/mercari/tortoise/api/v1alpha1"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/internal/controller"
-	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/calendar"
+	tortoiseconfig "github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/history"
 	"github.com/mercari/tortoise/pkg/hpa"
 	"github.com/mercari/tortoise/pkg/metrics"
+	"github.com/mercari/tortoise/pkg/oom"
+	"github.com/mercari/tortoise/pkg/pacing"
 	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/recommendationsource"
 	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/resize"
+	"github.com/mercari/tortoise/pkg/rollback"
+	"github.com/mercari/tortoise/pkg/rollout"
 	"github.com/mercari/tortoise/pkg/scaleops"
+	"github.com/mercari/tortoise/pkg/scheduledscaling"
+	"github.com/mercari/tortoise/pkg/statefulset"
 	"github.com/mercari/tortoise/pkg/tortoise"
 	"github.com/mercari/tortoise/pkg/vpa"
+	"github.com/mercari/tortoise/pkg/workload"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -73,6 +87,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(autoscalingv1beta3.AddToScheme(scheme))
+	utilruntime.Must(autoscalingv1alpha1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -112,7 +127,7 @@ func main() {
 
 	ctrl.SetLogger(zapr.NewLogger(l))
 
-	config, err := config.ParseConfig(configPath)
+	config, err := tortoiseconfig.ParseConfig(configPath)
 	if err != nil {
 		setupLog.Error(err, "failed to load config")
 		os.Exit(1)
@@ -139,6 +154,13 @@ func main() {
 		// if you are doing or is intended to do any operation such as perform cleanups
 		// after the manager stops then its usage might be unsafe.
 		LeaderElectionReleaseOnCancel: true,
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				// All the Pods in the cluster are cached to watch OOMKilled,
+				// and only the fields which the watcher needs are kept to save the memory.
+				&corev1.Pod{}: {Transform: oom.StripPod},
+			},
+		},
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -149,29 +171,65 @@ func main() {
 	// Initialize ScaleOps service for detecting ScaleOps-managed workloads
 	scaleopsService := scaleops.New(mgr.GetClient())
 
-	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode, config.ExcludedNamespaces, scaleopsService)
+	// Records the changes applied by tortoise in TortoiseRecommendationHistory.
+	historyService := history.New(mgr.GetClient(), config.RecommendationHistoryMaxEntries, config.RecommendationHistoryRetentionPeriod)
+
+	tortoiseService, err := tortoise.New(mgr.GetClient(), eventRecorder, config.RangeOfMinMaxReplicasRecommendationHours, config.RangeOfMinMaxReplicasRecommendationMinutes, config.TimeZone, config.TortoiseUpdateInterval, config.GatheringDataPeriodType, config.GlobalDisableMode, config.ExcludedNamespaces, config.DefaultApplyWindows, scaleopsService, historyService)
 	if err != nil {
 		setupLog.Error(err, "unable to start tortoise service")
 		os.Exit(1)
 	}
 
-	vpaClient, err := vpa.New(mgr.GetConfig(), eventRecorder)
+	var recommendationSource recommendationsource.Source
+	switch config.RecommendationSource {
+	case tortoiseconfig.RecommendationSourcePrometheus:
+		recommendationSource, err = recommendationsource.NewPrometheusSource(config.PrometheusAddress, config.PrometheusRecommendationWindow)
+		if err != nil {
+			setupLog.Error(err, "unable to start prometheus client")
+			os.Exit(1)
+		}
+	default:
+		vpaClient, err := vpa.New(mgr.GetConfig(), eventRecorder)
+		if err != nil {
+			setupLog.Error(err, "unable to start vpa client")
+			os.Exit(1)
+		}
+		recommendationSource = recommendationsource.NewVPASource(vpaClient)
+	}
+
+	cal, err := calendar.New(config.Calendars, config.TimeZone)
 	if err != nil {
-		setupLog.Error(err, "unable to start vpa client")
+		setupLog.Error(err, "unable to create calendar")
 		os.Exit(1)
 	}
 
-	hpaService, err := hpa.New(mgr.GetClient(), eventRecorder, config.ReplicaReductionFactor, config.MaximumTargetResourceUtilization, config.HPATargetUtilizationMaxIncrease, config.HPATargetUtilizationUpdateInterval, config.DefaultHPABehavior, config.MaximumMinReplicas, config.MaximumMaxReplicas, int32(config.MinimumMinReplicas), config.HPAExternalMetricExclusionRegex, config.EmergencyModeGracePeriod, config.GlobalDisableMode, config.ExcludedNamespaces, scaleopsService)
+	hpaService, err := hpa.New(mgr.GetClient(), eventRecorder, config.ReplicaReductionFactor, config.MaximumTargetResourceUtilization, config.HPATargetUtilizationMaxIncrease, config.HPATargetUtilizationUpdateInterval, config.DefaultHPABehavior, config.MaximumMinReplicas, config.MaximumMaxReplicas, int32(config.MinimumMinReplicas), config.HPAExternalMetricExclusionRegex, config.EmergencyModeGracePeriod, config.MinReplicasLeadTime, cal, config.GlobalDisableMode, config.ExcludedNamespaces, scaleopsService, historyService)
 	if err != nil {
 		setupLog.Error(err, "unable to start hpa service")
 		os.Exit(1)
 	}
 
+	// Calculates the resources of the running Pods to resize them in place.
+	// The controller fetcher is needed only by the Pod webhook.
+	resizePodService, err := pod.New(config.ResourceLimitMultiplier, config.MinimumCPULimit, nil, config.AllSidecarInjectors(), config.FeatureFlags)
+	if err != nil {
+		setupLog.Error(err, "unable to create pod service")
+		os.Exit(1)
+	}
+
+	replicaRecommendationStrategy, err := recommender.NewReplicaRecommendationStrategy(config.ReplicaRecommendationStrategy, config.ReplicaRecommendationPeriods, config.ReplicaRecommendationEWMAAlpha, config.ReplicaRecommendationPercentile)
+	if err != nil {
+		setupLog.Error(err, "unable to create replica recommendation strategy")
+		os.Exit(1)
+	}
+
+	// Keeps track of the recent OOMKilled containers to bump up the memory recommendation.
+	oomService := oom.New(eventRecorder, config.OOMBumpUpRatio, config.OOMMinBumpUp, config.OOMScaleDownCooldown)
+
 	if err = (&controller.TortoiseReconciler{
-		Scheme:            mgr.GetScheme(),
-		HpaService:        hpaService,
-		VpaService:        vpaClient,
-		DeploymentService: deployment.New(mgr.GetClient(), config.IstioSidecarProxyDefaultCPU, config.IstioSidecarProxyDefaultMemory, eventRecorder),
+		Scheme:               mgr.GetScheme(),
+		HpaService:           hpaService,
+		RecommendationSource: recommendationSource,
 		RecommenderService: recommender.New(
 			config.MaxReplicasRecommendationMultiplier,
 			config.MinReplicasRecommendationMultiplier,
@@ -188,20 +246,58 @@ func main() {
 			config.MaximumMaxReplicas,
 			config.MaxAllowedScalingDownRatio,
 			config.BufferRatioOnVerticalResource,
+			config.HPAMetricTargetBounds,
+			replicaRecommendationStrategy,
+			cal,
 			config.FeatureFlags,
 			eventRecorder,
 		),
 		TortoiseService: tortoiseService,
 		Interval:        config.TortoiseUpdateInterval,
 		EventRecorder:   eventRecorder,
+		// The Pods are listed only during the rollback window, so we don't cache them.
+		RollbackService: rollback.New(mgr.GetAPIReader(), eventRecorder, config.VerticalRollbackWindow, historyService),
+		OOMService:      oomService,
+		ResizeService:   resize.New(mgr.GetClient(), mgr.GetAPIReader(), resizePodService, eventRecorder),
+		// The PodDisruptionBudgets and the scale targets are fetched only when restarting, so we don't cache them.
+		RestartPacingService: pacing.New(mgr.GetAPIReader(), eventRecorder, config.MaxConcurrentRestarts, config.MaxConcurrentRestartsPerNamespace),
+		WorkloadService: workload.New(mgr.GetClient(), map[string]workload.Adapter{
+			"Deployment":  deployment.New(mgr.GetClient(), config.AllSidecarInjectors(), eventRecorder),
+			"StatefulSet": statefulset.New(mgr.GetClient(), config.AllSidecarInjectors(), eventRecorder),
+			rollout.Kind:  rollout.New(mgr.GetClient(), config.AllSidecarInjectors(), eventRecorder),
+		}),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Tortoise")
 		os.Exit(1)
 	}
+	if oomService.Enabled() {
+		// The Pods are watched only when OOMKilled is taken into account.
+		if err = (&controller.PodReconciler{
+			Client:     mgr.GetClient(),
+			OOMService: oomService,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Pod")
+			os.Exit(1)
+		}
+	}
+	autoscalingv1beta3.SetRecommenderOverridesBounds(config.RecommenderOverridesBounds)
+	autoscalingv1beta3.SetSidecarInjectors(config.AllSidecarInjectors())
 	if err = (&autoscalingv1beta3.Tortoise{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "Tortoise")
 		os.Exit(1)
 	}
+	if err = (&controller.ScheduledScalingReconciler{
+		Scheme:                  mgr.GetScheme(),
+		Interval:                config.TortoiseUpdateInterval,
+		ScheduledScalingService: scheduledscaling.New(mgr.GetClient(), eventRecorder),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ScheduledScaling")
+		os.Exit(1)
+	}
+	if err = (&autoscalingv1alpha1.ScheduledScaling{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "ScheduledScaling")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	hpaWebhook := autoscalingv2.New(tortoiseService, hpaService)
@@ -223,7 +319,7 @@ func main() {
 	controllerFetcher.Start(ctx, 1*time.Second)
 	defer cancel()
 
-	podService, err := pod.New(config.ResourceLimitMultiplier, config.MinimumCPULimit, controllerFetcher, config.FeatureFlags)
+	podService, err := pod.New(config.ResourceLimitMultiplier, config.MinimumCPULimit, controllerFetcher, config.AllSidecarInjectors(), config.FeatureFlags)
 	if err != nil {
 		setupLog.Error(err, "unable to create pod service")
 		os.Exit(1)
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingv1alpha1 "github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/pkg/history"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history tortoise",
+	Short: "show the changes applied by tortoise",
+	Long: `history is the command to show the changes applied by tortoise, from the oldest to the newest.
+
+It shows the resource requests, the HPA minReplicas/maxReplicas, and the HPA target utilization changed by tortoise
+with the old and new values and the reason, which are recorded in TortoiseRecommendationHistory.
+
+How long and how many changes are kept depends on the controller configuration (RecommendationHistoryMaxEntries and RecommendationHistoryRetentionPeriod).
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if historyNamespace == "" {
+			return fmt.Errorf("namespace must be specified")
+		}
+		format := history.OutputFormat(historyOutput)
+		switch format {
+		case history.OutputFormatTable, history.OutputFormatJSON, history.OutputFormatYAML:
+		default:
+			return fmt.Errorf("output must be one of table, json or yaml")
+		}
+		if historySince < 0 {
+			return fmt.Errorf("since must be positive")
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		// The history service is only used to read the history here.
+		h, err := history.New(client, 0, 0).Get(cmd.Context(), types.NamespacedName{Namespace: historyNamespace, Name: args[0]})
+		if err != nil {
+			return fmt.Errorf("failed to get the history of tortoise: %v", err)
+		}
+
+		entries := h.Status.Entries
+		if historySince != 0 {
+			entries = history.Since(entries, time.Now().Add(-historySince))
+		}
+
+		return history.Write(os.Stdout, entries, format)
+	},
+}
+
+var (
+	// namespace of the tortoise
+	historyNamespace string
+	// only show the changes in this duration
+	historySince time.Duration
+	// output format: table, json or yaml
+	historyOutput string
+)
+
+func init() {
+	utilruntime.Must(autoscalingv1alpha1.AddToScheme(scheme))
+
+	rootCmd.AddCommand(historyCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		historyCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		historyCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	historyCmd.Flags().StringVarP(&historyNamespace, "namespace", "n", "", "namespace of the tortoise")
+	historyCmd.Flags().DurationVar(&historySince, "since", 0, "(optional) only show the changes in this duration (e.g., 24h). If it's not specified, all the recorded changes are shown")
+	historyCmd.Flags().StringVarP(&historyOutput, "output", "o", string(history.OutputFormatTable), "output format. One of: table, json, yaml")
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/pkg/stoper"
+)
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume tortoise1 tortoise2...",
+	Short: "resume tortoise(s) stopped by tortoisectl stop",
+	Long: `resume is the command to resume tortoise(s) stopped by "tortoisectl stop".
+
+"tortoisectl stop" records the update mode before stopping in the annotation on tortoise,
+and resume changes the tortoise updateMode back to the recorded one.
+Tortoises which aren't stopped by "tortoisectl stop" (e.g., turned off manually) are left as they are.
+
+Also, with the --revert-deployment-patch flag, it reverts the patch made by "tortoisectl stop --no-lowering-resources"
+so that the deployment gets back to the original resource requests, and tortoise can manage them again.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if resumeAll {
+			if len(args) != 0 {
+				return fmt.Errorf("tortoise name shouldn't be specified because of --all flag")
+			}
+		} else {
+			if resumeNamespace == "" {
+				return fmt.Errorf("namespace must be specified")
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("tortoise name must be specified")
+			}
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		// Resume doesn't need the deployment and pod services.
+		stoperService := stoper.New(client, nil, nil)
+
+		opts := []stoper.ResumeOption{}
+		if revertDeploymentPatch {
+			opts = append(opts, stoper.RevertDeploymentPatch)
+		}
+
+		err = stoperService.Resume(cmd.Context(), args, resumeNamespace, resumeAll, os.Stdout, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to resume tortoise(s): %v", err)
+		}
+
+		return nil
+	},
+}
+
+var (
+	// namespace to resume tortoise(s) in
+	resumeNamespace string
+	// resume all tortoises in the specified namespace, or in all namespaces if no namespace is specified.
+	resumeAll bool
+	// Revert the deployment patched by "tortoisectl stop --no-lowering-resources".
+	revertDeploymentPatch bool
+)
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		resumeCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		resumeCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	resumeCmd.Flags().StringVarP(&resumeNamespace, "namespace", "n", "", "namespace to resume tortoise(s) in")
+	resumeCmd.Flags().BoolVarP(&resumeAll, "all", "A", false, "resume all tortoises stopped by tortoisectl in the specified namespace, or in all namespaces if no namespace is specified.")
+	resumeCmd.Flags().BoolVar(&revertDeploymentPatch, "revert-deployment-patch", false, `Revert the deployment patched by "tortoisectl stop --no-lowering-resources" to the original resource requests.`)
+}
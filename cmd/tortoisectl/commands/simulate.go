@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	v2 "k8s.io/api/autoscaling/v2"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/simulation"
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate --tortoise tortoise.yaml --records records.csv",
+	Short: "simulate how tortoise would behave with the historical metrics",
+	Long: `simulate is the command to replay the historical metrics through the recommendation logic of tortoise, without any cluster.
+
+It takes the time series of the replica number, the VPA recommendation, and the resource requests from a CSV or JSON file,
+and drives the same recommendation logic as the controller with a fake clock.
+It outputs the timeline of the resulting resource requests and HPA (minReplicas, maxReplicas, and target utilization).
+
+It's intended to be used to check how changes in the controller configuration (--config) or in the recommendation logic
+would behave before rolling them out.
+
+The CSV file should have the following header, and each row is for one container at the time:
+  time,replicas,container,cpu_request,memory_request,cpu_target,memory_target,cpu_upper_bound,memory_upper_bound
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if simulateTortoisePath == "" {
+			return fmt.Errorf("--tortoise must be specified")
+		}
+		if simulateRecordsPath == "" {
+			return fmt.Errorf("--records must be specified")
+		}
+		inputFormat := simulation.InputFormat(simulateInputFormat)
+		if inputFormat == "" {
+			inputFormat = simulation.InputFormat(strings.TrimPrefix(filepath.Ext(simulateRecordsPath), "."))
+		}
+		switch inputFormat {
+		case simulation.InputFormatCSV, simulation.InputFormatJSON:
+		default:
+			return fmt.Errorf("input-format must be one of csv or json")
+		}
+		outputFormat := simulation.OutputFormat(simulateOutput)
+		switch outputFormat {
+		case simulation.OutputFormatTable, simulation.OutputFormatJSON, simulation.OutputFormatCSV:
+		default:
+			return fmt.Errorf("output must be one of table, json or csv")
+		}
+
+		cfg, err := config.ParseConfig(simulateConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse the config: %v", err)
+		}
+
+		tortoise := &autoscalingv1beta3.Tortoise{}
+		if err := readYAML(simulateTortoisePath, tortoise); err != nil {
+			return fmt.Errorf("failed to read tortoise: %v", err)
+		}
+		var hpa *v2.HorizontalPodAutoscaler
+		if simulateHPAPath != "" {
+			hpa = &v2.HorizontalPodAutoscaler{}
+			if err := readYAML(simulateHPAPath, hpa); err != nil {
+				return fmt.Errorf("failed to read HPA: %v", err)
+			}
+		}
+
+		f, err := os.Open(simulateRecordsPath)
+		if err != nil {
+			return fmt.Errorf("failed to open the records: %v", err)
+		}
+		defer f.Close()
+		records, err := simulation.ReadRecords(f, inputFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read the records: %v", err)
+		}
+
+		// Events are not needed in the simulation.
+		simulator, err := simulation.New(cfg, &record.FakeRecorder{})
+		if err != nil {
+			return fmt.Errorf("failed to create the simulator: %v", err)
+		}
+
+		steps, err := simulator.Run(cmd.Context(), tortoise, hpa, records)
+		if err != nil {
+			return fmt.Errorf("failed to simulate: %v", err)
+		}
+
+		return simulation.WriteTimeline(os.Stdout, steps, outputFormat)
+	},
+}
+
+var (
+	// Path to the Tortoise manifest to simulate.
+	simulateTortoisePath string
+	// Path to the HPA manifest. If it's not specified, the simulation starts from the HPA that tortoise would create.
+	simulateHPAPath string
+	// Path to the records file.
+	simulateRecordsPath string
+	// Format of the records file: csv or json. If it's not specified, it's decided by the file extension.
+	simulateInputFormat string
+	// Path to the controller configuration file. If it's not specified, the default configuration is used.
+	simulateConfigPath string
+	// output format: table, json or csv
+	simulateOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(simulateCmd)
+
+	simulateCmd.Flags().StringVar(&simulateTortoisePath, "tortoise", "", "path to the Tortoise manifest to simulate")
+	simulateCmd.Flags().StringVar(&simulateHPAPath, "hpa", "", "(optional) path to the HPA manifest. If it's not specified, the simulation starts from the HPA that tortoise would create")
+	simulateCmd.Flags().StringVar(&simulateRecordsPath, "records", "", "path to the records file (CSV or JSON)")
+	simulateCmd.Flags().StringVar(&simulateInputFormat, "input-format", "", "(optional) format of the records file. One of: csv, json. If it's not specified, it's decided by the file extension")
+	simulateCmd.Flags().StringVar(&simulateConfigPath, "config", "", "(optional) path to the controller configuration file. If it's not specified, the default configuration is used")
+	simulateCmd.Flags().StringVarP(&simulateOutput, "output", "o", string(simulation.OutputFormatTable), "output format. One of: table, json, csv")
+}
+
+func readYAML(path string, obj any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(b, obj)
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/homedir"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/pkg/reporter"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status tortoise1 tortoise2...",
+	Short: "show the status of tortoise(s) in a human-readable way",
+	Long: `status is the command to show the status of tortoise(s) in a human-readable way.
+
+It shows the phase of tortoise, the phase of each container's resource,
+the current and recommended resource requests, the HPA target utilization,
+the min/max replicas recommendation for the current time slot, and the active conditions (e.g., EffectiveModeOverridden).
+
+You can also get the same information in JSON or YAML with the --output flag.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// validation
+		if statusAll {
+			if len(args) != 0 {
+				return fmt.Errorf("tortoise name shouldn't be specified because of --all flag")
+			}
+		} else {
+			if statusNamespace == "" {
+				return fmt.Errorf("namespace must be specified")
+			}
+			if len(args) == 0 {
+				return fmt.Errorf("tortoise name must be specified")
+			}
+		}
+		format := reporter.OutputFormat(statusOutput)
+		switch format {
+		case reporter.OutputFormatTable, reporter.OutputFormatJSON, reporter.OutputFormatYAML:
+		default:
+			return fmt.Errorf("output must be one of table, json or yaml")
+		}
+
+		config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to build config: %v", err)
+		}
+
+		client, err := client.New(config, client.Options{
+			Scheme: scheme,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create client: %v", err)
+		}
+
+		reports, err := reporter.New(client).Report(cmd.Context(), args, statusNamespace, statusAll, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to get the status of tortoise(s): %v", err)
+		}
+
+		return reporter.Write(os.Stdout, reports, format)
+	},
+}
+
+var (
+	// namespace to show tortoise(s) in
+	statusNamespace string
+	// show all tortoises in the specified namespace, or in all namespaces if no namespace is specified.
+	statusAll bool
+	// output format: table, json or yaml
+	statusOutput string
+)
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	if home := homedir.HomeDir(); home != "" {
+		statusCmd.Flags().StringVar(&kubeconfig, "kubeconfig", filepath.Join(home, ".kube", "config"), "(optional) absolute path to the kubeconfig file")
+	} else {
+		statusCmd.Flags().StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
+	}
+
+	statusCmd.Flags().StringVarP(&statusNamespace, "namespace", "n", "", "namespace to show tortoise(s) in")
+	statusCmd.Flags().BoolVarP(&statusAll, "all", "A", false, "show all tortoises in the specified namespace, or in all namespaces if no namespace is specified.")
+	statusCmd.Flags().StringVarP(&statusOutput, "output", "o", string(reporter.OutputFormatTable), "output format. One of: table, json, yaml")
+}
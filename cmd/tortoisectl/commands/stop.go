@@ -16,6 +16,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	tortoiseconfig "github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/deployment"
 	"github.com/mercari/tortoise/pkg/pod"
 	"github.com/mercari/tortoise/pkg/stoper"
@@ -33,6 +34,9 @@ Also, with the --no-lowering-resources flag, it patches the deployment directly
 so that changing tortoise to Off won't result in lowering the resource request(s), damaging the service.
 e.g., if the Deployment declares 1 CPU request, and the current Pods' request is 2 CPU mutated by Tortoise,
 it'd patch the deployment to 2 CPU request to prevent a possible negative impact on the service. 
+The sidecars injected by mutating webhooks (e.g., istio-proxy) are handled through their resource annotations;
+pass the controller configuration with --config so that the sidecars configured in SidecarInjectors are handled as well.
+Otherwise, only istio-proxy is known as the injected sidecar.
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// validation
@@ -61,9 +65,15 @@ it'd patch the deployment to 2 CPU request to prevent a possible negative impact
 			return fmt.Errorf("failed to create client: %v", err)
 		}
 
+		// The default configuration only knows the istio sidecar proxy as the injected sidecar.
+		tortoiseConfig, err := tortoiseconfig.ParseConfig(stopConfigPath)
+		if err != nil {
+			return fmt.Errorf("failed to parse the config: %v", err)
+		}
+		sidecarInjectors := tortoiseConfig.AllSidecarInjectors()
 		recorder := record.NewBroadcaster().NewRecorder(scheme, corev1.EventSource{Component: "tortoisectl"})
-		deploymentService := deployment.New(client, "", "", recorder)
-		podService, err := pod.New(map[string]int64{}, "", nil, nil)
+		deploymentService := deployment.New(client, sidecarInjectors, recorder)
+		podService, err := pod.New(map[string]int64{}, "", nil, sidecarInjectors, nil)
 		if err != nil {
 			return fmt.Errorf("failed to create pod service: %v", err)
 		}
@@ -93,6 +103,9 @@ var (
 	// If this flag is specified and the current Deployment's resource request(s) is lower than the current Pods' request mutated by Tortoise,
 	// this CLI patches the deployment so that changing tortoise to Off won't result in lowering the resource request(s), damaging the service.
 	noLoweringResources bool
+	// Path to the controller configuration file, which the sidecar injectors are read from.
+	// If it's not specified, the default configuration is used.
+	stopConfigPath string
 
 	// Path to KUBECONFIG
 	kubeconfig string
@@ -117,4 +130,5 @@ func init() {
 	stopCmd.Flags().BoolVar(&noLoweringResources, "no-lowering-resources", false, `Stop tortoise without lowering resource requests. 
  If this flag is specified and the current Deployment's resource request(s) is lower than the current Pods' request mutated by Tortoise,
 this CLI patches the deployment so that changing tortoise to Off won't result in lowering the resource request(s), damaging the service.`)
+	stopCmd.Flags().StringVar(&stopConfigPath, "config", "", "(optional) path to the controller configuration file to read SidecarInjectors from. If it's not specified, only istio-proxy is known as the injected sidecar")
 }
@@ -25,6 +25,7 @@ require (
 
 require (
 	github.com/kyokomi/emoji/v2 v2.2.12
+	github.com/prometheus/common v0.55.0
 	github.com/spf13/cobra v1.8.1
 )
 
@@ -61,7 +62,6 @@ require (
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/prometheus/client_model v0.6.1 // indirect
-	github.com/prometheus/common v0.55.0 // indirect
 	github.com/prometheus/procfs v0.15.1 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
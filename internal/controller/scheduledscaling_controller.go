@@ -0,0 +1,127 @@
+/*
+MIT License
+
+Copyright (c) 2023 mercari
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/pkg/scheduledscaling"
+)
+
+// ScheduledScalingReconciler reconciles a ScheduledScaling object
+type ScheduledScalingReconciler struct {
+	Scheme *runtime.Scheme
+
+	// Interval is the interval to retry when the reconciliation failed.
+	Interval time.Duration
+
+	ScheduledScalingService *scheduledscaling.Service
+}
+
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=scheduledscalings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=scheduledscalings/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=scheduledscalings/finalizers,verbs=update
+
+func (r *ScheduledScalingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	now := time.Now()
+	if onlyTestNow != nil {
+		now = *onlyTestNow
+	}
+
+	ss, err := r.ScheduledScalingService.GetScheduledScaling(ctx, req.NamespacedName)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// The scheduled scaling is deleted.
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "failed to get scheduled scaling", "scheduledscaling", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if !ss.DeletionTimestamp.IsZero() {
+		// The scheduled scaling is being deleted, remove its constraints from the tortoise.
+		if _, err := r.ScheduledScalingService.SyncTortoise(ctx, ss.Namespace, ss.Spec.TargetRefs.TortoiseName, now); err != nil && !apierrors.IsNotFound(err) {
+			logger.Error(err, "failed to remove the scheduled scaling from tortoise", "scheduledscaling", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+		if err := r.ScheduledScalingService.RemoveFinalizer(ctx, ss); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
+
+	ss, err = r.ScheduledScalingService.AddFinalizer(ctx, ss)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	phase := scheduledscaling.DesiredPhase(ss, now)
+	if _, err := r.ScheduledScalingService.SyncTortoise(ctx, ss.Namespace, ss.Spec.TargetRefs.TortoiseName, now); err != nil {
+		if !apierrors.IsNotFound(err) || phase == v1alpha1.ScheduledScalingPhaseApplied {
+			// If the tortoise isn't found while the scheduled scaling isn't valid, there's nothing to apply or revert.
+			logger.Error(err, "failed to apply the scheduled scaling to tortoise", "scheduledscaling", req.NamespacedName)
+			if _, serr := r.ScheduledScalingService.UpdateScheduledScalingStatus(ctx, ss, v1alpha1.ScheduledScalingPhaseFailed, "FailedToUpdateTortoise", err.Error(), now); serr != nil {
+				logger.Error(serr, "failed to update scheduled scaling status", "scheduledscaling", req.NamespacedName)
+			}
+			return ctrl.Result{RequeueAfter: r.Interval}, nil
+		}
+	}
+
+	var reason, message string
+	var requeueAfter time.Duration
+	switch phase {
+	case v1alpha1.ScheduledScalingPhasePending:
+		reason, message = "Pending", "the schedule hasn't started yet"
+		requeueAfter = scheduledscaling.StartAt(ss).Sub(now)
+	case v1alpha1.ScheduledScalingPhaseApplied:
+		reason, message = "Applied", "the constraints are applied to the tortoise"
+		requeueAfter = ss.Spec.Schedule.FinishAt.Sub(now)
+	case v1alpha1.ScheduledScalingPhaseExpired:
+		reason, message = "Expired", "the schedule is finished and the constraints are removed from the tortoise"
+	}
+
+	if _, err := r.ScheduledScalingService.UpdateScheduledScalingStatus(ctx, ss, phase, reason, message, now); err != nil {
+		logger.Error(err, "failed to update scheduled scaling status", "scheduledscaling", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ScheduledScalingReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ScheduledScaling{}).
+		Complete(r)
+}
@@ -38,17 +38,23 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/mercari/tortoise/api/v1beta3"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
-	"github.com/mercari/tortoise/pkg/deployment"
 	"github.com/mercari/tortoise/pkg/hpa"
 	"github.com/mercari/tortoise/pkg/metrics"
+	"github.com/mercari/tortoise/pkg/oom"
+	"github.com/mercari/tortoise/pkg/pacing"
+	"github.com/mercari/tortoise/pkg/recommendationsource"
 	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/resize"
+	"github.com/mercari/tortoise/pkg/rollback"
 	tortoiseService "github.com/mercari/tortoise/pkg/tortoise"
 	"github.com/mercari/tortoise/pkg/utils"
 	"github.com/mercari/tortoise/pkg/vpa"
+	"github.com/mercari/tortoise/pkg/workload"
 )
 
 // TortoiseReconciler reconciles a Tortoise object
@@ -57,12 +63,26 @@ type TortoiseReconciler struct {
 
 	Interval time.Duration
 
-	HpaService         *hpa.Service
-	VpaService         *vpa.Service
-	DeploymentService  *deployment.Service
+	HpaService *hpa.Service
+	// RecommendationSource provides the resource recommendation for each container. (The monitor VPA by default.)
+	RecommendationSource recommendationsource.Source
+	// WorkloadService handles the scale target of each kind via its adapter.
+	WorkloadService    *workload.Service
 	TortoiseService    *tortoiseService.Service
 	RecommenderService *recommender.Service
 	EventRecorder      record.EventRecorder
+	// RollbackService rolls the resource requests back when the Pods crash after the resource requests are lowered.
+	// The rollback is disabled when it's nil.
+	RollbackService *rollback.Service
+	// OOMService bumps up the memory recommendation of the containers OOMKilled recently.
+	// OOMKilled isn't taken into account when it's nil.
+	OOMService *oom.Service
+	// ResizeService resizes the Pods in place for the tortoises with the InPlace resource update strategy.
+	// The Pods are always restarted when it's nil.
+	ResizeService *resize.Service
+	// RestartPacingService defers the restarts of the scale targets based on the PodDisruptionBudgets and the concurrent restarts.
+	// The scale targets are always restarted immediately when it's nil.
+	RestartPacingService *pacing.Service
 }
 
 var (
@@ -73,22 +93,31 @@ var (
 //+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=tortoises,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=tortoises/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=tortoises/finalizers,verbs=update
+//+kubebuilder:rbac:groups=autoscaling.mercari.com,resources=tortoiserecommendationhistories,verbs=get;list;watch;create;update;patch;delete
 
 //+kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=autoscaling.k8s.io,resources=verticalpodautoscalers/status,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps,resources=deployments/scale,verbs=get
 //+kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups=core,resources=events,verbs=create;update;patch
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups=core,resources=pods/resize,verbs=patch
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list
 
-// Tortoise only supports the deployment at the moment though, will support them too in the future.
+// Tortoise only supports the deployment, statefulset and rollout at the moment though, will support them too in the future.
 // At the moment, we only need a read permission for the below resources to run the controller fetcher.
 
 //+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
-//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=apps,resources=statefulsets/scale,verbs=get
 //+kubebuilder:rbac:groups=core,resources=replicationcontrollers,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=argoproj.io,resources=rollouts,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=argoproj.io,resources=rollouts/scale,verbs=get
 
 // ScaleOps CRD permissions for detecting ScaleOps-managed workloads
 //+kubebuilder:rbac:groups=analysis.scaleops.sh,resources=automatednamespaces,verbs=get;list;watch
@@ -177,33 +206,34 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		)
 	}
 
-	// TODO: stop depending on deployment.
-	// https://github.com/mercari/tortoise/issues/129
-	//
-	// Currently, we don't depend on the deployment on almost all cases,
-	// but we need to get the number of replicas from it + we need to take resource requests of each container when initializing tortoises.
-	// We should be able to eventually remove this dependency by using the number of replicas from scale subresource.
-	dm, err := r.DeploymentService.GetDeploymentOnTortoise(ctx, tortoise)
+	// The scale target is handled via the adapter for its kind, and the number of replicas and the Pod selector are read from the scale subresource.
+	// We still need the scale target itself to take resource requests of each container and to restart the Pods.
+	adapter, err := r.WorkloadService.Adapter(tortoise.Spec.TargetRefs.ScaleTargetRef.Kind)
 	if err != nil {
-		logger.Error(err, "failed to get deployment", "tortoise", req.NamespacedName)
+		logger.Error(err, "failed to get the adapter of the scale target", "tortoise", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
-	if dm.Spec.Replicas == nil {
-		logger.Error(nil, "the deployment doesn't have the number of replicas and tortoise cannot calculate the recommendation", "tortoise", req.NamespacedName, "deployment", klog.KObj(dm))
-		return ctrl.Result{}, nil
-
+	workload, err := adapter.Get(ctx, tortoise)
+	if err != nil {
+		logger.Error(err, "failed to get the scale target", "tortoise", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+	scale, err := r.WorkloadService.GetScale(ctx, workload)
+	if err != nil {
+		logger.Error(err, "failed to get the scale subresource of the scale target", "tortoise", req.NamespacedName, "scaleTarget", klog.KObj(workload))
+		return ctrl.Result{}, err
 	}
 
-	currentDesiredReplicaNum := *dm.Spec.Replicas // Use the desired replica number.
+	currentDesiredReplicaNum := scale.Replicas // Use the desired replica number.
 
 	if tortoise.Spec.UpdateMode == autoscalingv1beta3.UpdateModeOff /* When Off, ContainerResourceRequests should be reset */ ||
 		tortoise.Status.Conditions.ContainerResourceRequests == nil /* The first reconciliation */ {
-		// If the update mode is off, we have to update ContainerResourceRequests from the deployment directly
+		// If the update mode is off, we have to update ContainerResourceRequests from the scale target directly
 		// so that pods will get an original resource request.
 		// If it's not off, ContainerResourceRequests should be updated in UpdateVPAFromTortoiseRecommendation in the last reconciliation.
-		acr, err := r.DeploymentService.GetResourceRequests(dm)
+		acr, err := adapter.ResourceRequests(workload)
 		if err != nil {
-			logger.Error(err, "failed to get resource requests in deployment", "tortoise", req.NamespacedName, "deployment", klog.KObj(dm))
+			logger.Error(err, "failed to get resource requests in the scale target", "tortoise", req.NamespacedName, "scaleTarget", klog.KObj(workload))
 			return ctrl.Result{}, err
 		}
 		tortoise.Status.Conditions.ContainerResourceRequests = acr
@@ -239,13 +269,13 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, err
 	}
 
-	monitorvpa, ready, err := r.VpaService.GetTortoiseMonitorVPA(ctx, tortoise)
+	containerRecommendations, ready, err := r.RecommendationSource.GetRecommendation(ctx, tortoise, now)
 	if err != nil {
-		logger.Error(err, "failed to get tortoise VPA", "tortoise", req.NamespacedName)
+		logger.Error(err, "failed to get the recommendation from the recommendation source", "tortoise", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
 	if !ready {
-		logger.Info("VPA created by tortoise isn't ready yet", "tortoise", req.NamespacedName)
+		logger.Info("the recommendation source isn't ready yet", "tortoise", req.NamespacedName)
 		_, err = r.TortoiseService.UpdateTortoiseStatus(ctx, tortoise, now, true)
 		if err != nil {
 			logger.Error(err, "update Tortoise status", "tortoise", req.NamespacedName)
@@ -254,16 +284,10 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{RequeueAfter: r.Interval}, nil
 	}
 
-	_, err = r.VpaService.UpdateVPAContainerResourcePolicy(ctx, tortoise, monitorvpa)
-	if err != nil {
-		logger.Error(err, "update VPA Container Resource Policy", "tortoise", req.NamespacedName)
-		return ctrl.Result{}, err
-	}
-
-	// VPA is ready, we mark all Vertical scaling resources as Running.
+	// The recommendation source is ready, we mark all Vertical scaling resources as Running.
 	tortoise = vpa.SetAllVerticalContainerResourcePhaseWorking(tortoise, now)
 
-	logger.Info("VPA created by tortoise is ready, proceeding to generate the recommendation", "tortoise", req.NamespacedName)
+	logger.Info("the recommendation source is ready, proceeding to generate the recommendation", "tortoise", req.NamespacedName)
 	hpa, isReady, err := r.HpaService.GetHPAOnTortoise(ctx, tortoise)
 	if err != nil {
 		logger.Error(err, "failed to get HPA", "tortoise", req.NamespacedName)
@@ -283,7 +307,15 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, err
 	}
 
-	tortoise = r.TortoiseService.UpdateContainerRecommendationFromVPA(tortoise, monitorvpa, now)
+	tortoise = r.TortoiseService.UpdateContainerRecommendationFromVPA(tortoise, containerRecommendations, now)
+
+	if r.OOMService != nil {
+		tortoise, err = r.OOMService.UpdateOOMBumps(ctx, tortoise, scale.Selector, now)
+		if err != nil {
+			logger.Error(err, "failed to update the OOM bumps in tortoise", "tortoise", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+	}
 
 	tortoise, err = r.RecommenderService.UpdateRecommendations(ctx, tortoise, hpa, currentDesiredReplicaNum, now)
 	if err != nil {
@@ -308,11 +340,23 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 		return ctrl.Result{}, err
 	}
 
+	if r.RollbackService != nil && tortoise.Spec.UpdateMode != v1beta3.UpdateModeOff && !disabled {
+		tortoise, err = r.RollbackService.RollbackIfPodsCrash(ctx, tortoise, scale.Selector, now)
+		if err != nil {
+			logger.Error(err, "failed to check the pods for the rollback", "tortoise", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+	}
+
+	beforeUpdateResourceRequest := tortoise.DeepCopy()
 	tortoise, err = r.TortoiseService.UpdateResourceRequest(ctx, tortoise, currentDesiredReplicaNum, now)
 	if err != nil {
 		logger.Error(err, "update VPA based on the recommendation in tortoise", "tortoise", req.NamespacedName)
 		return ctrl.Result{}, err
 	}
+	if r.RollbackService != nil {
+		tortoise = r.RollbackService.RecordPreviousRequests(beforeUpdateResourceRequest, tortoise, now)
+	}
 
 	tortoise, err = r.TortoiseService.UpdateTortoiseStatus(ctx, tortoise, now, true)
 	if err != nil {
@@ -323,11 +367,43 @@ func (r *TortoiseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (_
 	// Reuse disabled and reason from earlier check (no need to call IsChangeApplicationDisabled again)
 	if tortoise.Spec.UpdateMode != v1beta3.UpdateModeOff && !disabled && !reflect.DeepEqual(oldTortoise.Status.Conditions.ContainerResourceRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
 		// The container resource requests are updated, so we need to update the Pods.
-		err = r.DeploymentService.RolloutRestart(ctx, dm, tortoise, now)
+		restart := true
+		if r.ResizeService != nil && resize.Enabled(tortoise) {
+			tortoise, restart, err = r.ResizeService.ResizePods(ctx, tortoise, scale.Selector, now)
+			if err != nil {
+				logger.Error(err, "failed to resize the Pods in place", "tortoise", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+		if restart {
+			tortoise, err = r.pacedRolloutRestart(ctx, adapter, workload, tortoise, now)
+			if err != nil {
+				logger.Error(err, "failed to rollout restart", "tortoise", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
+	} else if r.RestartPacingService != nil && pacing.Deferred(tortoise) && tortoise.Spec.UpdateMode != v1beta3.UpdateModeOff && !disabled {
+		// The restart was deferred, retry it.
+		tortoise, err = r.pacedRolloutRestart(ctx, adapter, workload, tortoise, now)
 		if err != nil {
 			logger.Error(err, "failed to rollout restart", "tortoise", req.NamespacedName)
 			return ctrl.Result{}, err
 		}
+	} else if r.ResizeService != nil && resize.Enabled(tortoise) && tortoise.Spec.UpdateMode != v1beta3.UpdateModeOff && !disabled {
+		// Watch the Pods being resized in place, and restart them if the resize isn't possible.
+		var restart bool
+		tortoise, restart, err = r.ResizeService.CheckResize(ctx, tortoise, scale.Selector, now)
+		if err != nil {
+			logger.Error(err, "failed to check the in-place resize of the Pods", "tortoise", req.NamespacedName)
+			return ctrl.Result{}, err
+		}
+		if restart {
+			tortoise, err = r.pacedRolloutRestart(ctx, adapter, workload, tortoise, now)
+			if err != nil {
+				logger.Error(err, "failed to rollout restart", "tortoise", req.NamespacedName)
+				return ctrl.Result{}, err
+			}
+		}
 	} else if disabled && !reflect.DeepEqual(oldTortoise.Status.Conditions.ContainerResourceRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
 		logger.Info("Skipping rollout restart", "tortoise", req.NamespacedName, "reason", reason)
 	}
@@ -368,9 +444,9 @@ func (r *TortoiseReconciler) deleteVPAAndHPA(ctx context.Context, tortoise *auto
 		}
 	}
 
-	err = r.VpaService.DeleteTortoiseMonitorVPA(ctx, tortoise)
+	err = r.RecommendationSource.Delete(ctx, tortoise)
 	if err != nil {
-		return fmt.Errorf("delete monitor VPA created by tortoise: %w", err)
+		return fmt.Errorf("delete the resources for the recommendation source: %w", err)
 	}
 	return nil
 }
@@ -382,9 +458,9 @@ func (r *TortoiseReconciler) initializeVPAAndHPA(ctx context.Context, tortoise *
 		return err
 	}
 
-	_, tortoise, err = r.VpaService.CreateTortoiseMonitorVPA(ctx, tortoise)
+	tortoise, err = r.RecommendationSource.Initialize(ctx, tortoise)
 	if err != nil {
-		return fmt.Errorf("create tortoise monitor VPA: %w", err)
+		return fmt.Errorf("initialize the recommendation source: %w", err)
 	}
 	_, err = r.TortoiseService.UpdateTortoiseStatus(ctx, tortoise, now, true)
 	if err != nil {
@@ -393,6 +469,22 @@ func (r *TortoiseReconciler) initializeVPAAndHPA(ctx context.Context, tortoise *
 	return nil
 }
 
+// pacedRolloutRestart restarts the scale target unless RestartPacingService defers the restart.
+func (r *TortoiseReconciler) pacedRolloutRestart(ctx context.Context, adapter workload.Adapter, w client.Object, tortoise *autoscalingv1beta3.Tortoise, now time.Time) (*autoscalingv1beta3.Tortoise, error) {
+	if r.RestartPacingService != nil {
+		var start bool
+		var err error
+		tortoise, start, err = r.RestartPacingService.TryStart(ctx, tortoise, adapter, w, now)
+		if err != nil {
+			return tortoise, fmt.Errorf("check whether the scale target can be restarted now: %w", err)
+		}
+		if !start {
+			return tortoise, nil
+		}
+	}
+	return tortoise, adapter.Restart(ctx, w, tortoise, now)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *TortoiseReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
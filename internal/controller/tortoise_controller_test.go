@@ -13,7 +13,9 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	v1 "k8s.io/api/apps/v1"
 	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	autoscalingv1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
 	"k8s.io/client-go/tools/record"
@@ -24,12 +26,18 @@ import (
 	"sigs.k8s.io/yaml"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	tortoiseconfig "github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/deployment"
 	"github.com/mercari/tortoise/pkg/features"
 	"github.com/mercari/tortoise/pkg/hpa"
+	"github.com/mercari/tortoise/pkg/recommendationsource"
 	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/rollback"
+	"github.com/mercari/tortoise/pkg/rollout"
+	"github.com/mercari/tortoise/pkg/statefulset"
 	"github.com/mercari/tortoise/pkg/tortoise"
 	"github.com/mercari/tortoise/pkg/vpa"
+	"github.com/mercari/tortoise/pkg/workload"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -244,6 +252,10 @@ func removeUnnecessaryFields(rawdata []byte) ([]byte, error) {
 }
 
 func startController(ctx context.Context) func() {
+	return startControllerWithRollbackWindow(ctx, 0)
+}
+
+func startControllerWithRollbackWindow(ctx context.Context, rollbackWindow time.Duration) func() {
 	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:         scheme,
 		LeaderElection: false,
@@ -255,20 +267,26 @@ func startController(ctx context.Context) func() {
 
 	// We only reconcile once.
 	recorder := mgr.GetEventRecorderFor("tortoise-controller")
-	tortoiseService, err := tortoise.New(mgr.GetClient(), recorder, 24, "Asia/Tokyo", 1000*time.Minute, "daily", false, nil, nil)
+	sidecarInjectors := map[string]tortoiseconfig.SidecarInjector{tortoiseconfig.IstioProxyContainerName: tortoiseconfig.IstioSidecarInjector("100m", "100Mi")}
+	tortoiseService, err := tortoise.New(mgr.GetClient(), recorder, 24, 0, "Asia/Tokyo", 1000*time.Minute, "daily", false, nil, nil, nil, nil)
 	Expect(err).ShouldNot(HaveOccurred())
 	cli, err := vpa.New(mgr.GetConfig(), recorder)
 	Expect(err).ShouldNot(HaveOccurred())
-	hpaS, err := hpa.New(mgr.GetClient(), recorder, 0.95, 90, 25, time.Hour, nil, 1000, 10000, 3, ".*-exclude-metric", 5*time.Minute, false, nil, nil)
+	hpaS, err := hpa.New(mgr.GetClient(), recorder, 0.95, 90, 25, time.Hour, nil, 1000, 10000, 3, ".*-exclude-metric", 5*time.Minute, 0, nil, false, nil, nil, nil)
 	Expect(err).ShouldNot(HaveOccurred())
 	reconciler := &TortoiseReconciler{
-		Scheme:             scheme,
-		HpaService:         hpaS,
-		EventRecorder:      record.NewFakeRecorder(10),
-		VpaService:         cli,
-		DeploymentService:  deployment.New(mgr.GetClient(), "100m", "100Mi", recorder),
-		TortoiseService:    tortoiseService,
-		RecommenderService: recommender.New(2.0, 0.5, 90, 40, 3, 30, "10m", "10Mi", map[string]string{"istio-proxy": "11m"}, map[string]string{"istio-proxy": "11Mi"}, "10", "10Gi", 10000, 0, 0, []features.FeatureFlag{features.VerticalScalingBasedOnPreferredMaxReplicas}, recorder),
+		Scheme:               scheme,
+		HpaService:           hpaS,
+		EventRecorder:        record.NewFakeRecorder(10),
+		RecommendationSource: recommendationsource.NewVPASource(cli),
+		TortoiseService:      tortoiseService,
+		RecommenderService:   recommender.New(2.0, 0.5, 90, 40, 3, 30, "10m", "10Mi", map[string]string{"istio-proxy": "11m"}, map[string]string{"istio-proxy": "11Mi"}, "10", "10Gi", 10000, 0, 0, nil, nil, nil, []features.FeatureFlag{features.VerticalScalingBasedOnPreferredMaxReplicas}, recorder),
+		RollbackService:      rollback.New(mgr.GetAPIReader(), recorder, rollbackWindow, nil),
+		WorkloadService: workload.New(mgr.GetClient(), map[string]workload.Adapter{
+			"Deployment":  deployment.New(mgr.GetClient(), sidecarInjectors, recorder),
+			"StatefulSet": statefulset.New(mgr.GetClient(), sidecarInjectors, recorder),
+			rollout.Kind:  rollout.New(mgr.GetClient(), sidecarInjectors, recorder),
+		}),
 	}
 	err = reconciler.SetupWithManager(mgr)
 	Expect(err).ShouldNot(HaveOccurred())
@@ -510,6 +528,60 @@ var _ = Describe("Test TortoiseController", func() {
 			runTest(filepath.Join("testdata", "reconcile-automatic-emergency-mode-mixed-policies-healthy"))
 		})
 	})
+	Context("rollback when the Pods crash after the resource requests are lowered", func() {
+		It("roll back the resource requests and raise the floor when the restarted Pod is OOMKilled", func() {
+			initializeResourcesFromFiles(ctx, k8sClient, filepath.Join("testdata", "reconcile-rollback-oomkilled", "before"))
+
+			// The Pod restarted with the lowered memory request is OOMKilled.
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "mercari-app-oomkilled", Namespace: "default", Labels: map[string]string{"app": "mercari"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "app", Image: "awesome-mercari-app-image"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			pod.Status = corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name: "app",
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+			defer func() {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, pod, client.GracePeriodSeconds(0)))).To(Succeed())
+			}()
+
+			stopFunc = startControllerWithRollbackWindow(ctx, 15*time.Minute)
+
+			Eventually(func(g Gomega) {
+				got := &v1beta3.Tortoise{}
+				g.Expect(k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "mercari"}, got)).To(Succeed())
+
+				// The memory request is rolled back to the previous one.
+				g.Expect(got.Status.Conditions.ContainerResourceRequests).To(HaveLen(1))
+				g.Expect(got.Status.Conditions.ContainerResourceRequests[0].Resource.Memory().Cmp(resource.MustParse("4Gi"))).To(Equal(0))
+				g.Expect(got.Status.Conditions.PreviousContainerResourceRequests).To(BeEmpty())
+
+				// The floor is raised so that the memory request won't be lowered again.
+				g.Expect(got.Status.Conditions.ResourceRequestFloors).To(HaveLen(1))
+				g.Expect(got.Status.Conditions.ResourceRequestFloors[0].ContainerName).To(Equal("app"))
+				g.Expect(got.Status.Conditions.ResourceRequestFloors[0].Resource.Memory().Cmp(resource.MustParse("4Gi"))).To(Equal(0))
+
+				var rolledBack *v1beta3.TortoiseCondition
+				for i, c := range got.Status.Conditions.TortoiseConditions {
+					if c.Type == v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack {
+						rolledBack = &got.Status.Conditions.TortoiseConditions[i]
+					}
+				}
+				g.Expect(rolledBack).NotTo(BeNil())
+				g.Expect(rolledBack.Status).To(Equal(corev1.ConditionTrue))
+			}).Should(Succeed())
+		})
+	})
 	Context("DeletionPolicy is handled correctly", func() {
 		It("[DeletionPolicy = DeleteAll] delete HPA and VPA when Tortoise is deleted", func() {
 			resource := initializeResourcesFromFiles(ctx, k8sClient, "testdata/deletion-policy-all/before")
@@ -19,4 +19,9 @@ const (
 	// But, DryRun Tortoise is not allowed to modify HPAs, and if users manually add/remove metrics in HPAs,
 	// it could result in being inconsistent with the autoscaling policy in DryRun Tortoise.
 	ModifyDryRunTortoiseWhenHPAIsChangedAnnotation = "tortoise.autoscaling.mercari.com/modify-dryrun-tortoise-when-hpa-is-changed"
+
+	// StoppedStateAnnotation is set by "tortoisectl stop" to record the state before stopping the tortoise,
+	// so that "tortoisectl resume" can restore it.
+	// The value is JSON, which has the previous update mode and the original resources of the patched Deployment (if any).
+	StoppedStateAnnotation = "tortoise.autoscaling.mercari.com/stopped-state"
 )
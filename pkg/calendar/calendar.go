@@ -0,0 +1,70 @@
+package calendar
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+var weekdays = map[string]time.Weekday{
+	time.Sunday.String():    time.Sunday,
+	time.Monday.String():    time.Monday,
+	time.Tuesday.String():   time.Tuesday,
+	time.Wednesday.String(): time.Wednesday,
+	time.Thursday.String():  time.Thursday,
+	time.Friday.String():    time.Friday,
+	time.Saturday.String():  time.Saturday,
+}
+
+// Calendar knows the special days, such as public holidays, in each time zone,
+// and which day of the week's recommendation each of them uses.
+// A nil Calendar doesn't have any special day.
+type Calendar struct {
+	// specialDays is the map from the time zone name to the map from the date (YYYY-MM-DD) to the day of the week.
+	specialDays map[string]map[string]time.Weekday
+}
+
+// New creates the Calendar from the calendars in the config.
+// It returns nil if there is no special day.
+func New(calendars []config.Calendar, defaultTimeZone string) (*Calendar, error) {
+	if len(calendars) == 0 {
+		return nil, nil
+	}
+
+	c := &Calendar{specialDays: map[string]map[string]time.Weekday{}}
+	for _, cal := range calendars {
+		tz := cal.TimeZone
+		if tz == "" {
+			tz = defaultTimeZone
+		}
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("load the time zone of the calendar: %w", err)
+		}
+
+		days := map[string]time.Weekday{}
+		for _, d := range cal.SpecialDays {
+			weekday, ok := weekdays[d.Weekday]
+			if !ok {
+				return nil, fmt.Errorf("invalid day of the week of the special day %s: %s", d.Date, d.Weekday)
+			}
+			days[d.Date] = weekday
+		}
+		c.specialDays[loc.String()] = days
+	}
+	return c, nil
+}
+
+// Weekday returns the day of the week whose recommendation is used at t.
+// t is expected to be in the time zone of the recommendation;
+// the special days in the calendar of the time zone are applied.
+func (c *Calendar) Weekday(t time.Time) time.Weekday {
+	if c == nil {
+		return t.Weekday()
+	}
+	if w, ok := c.specialDays[t.Location().String()][t.Format(time.DateOnly)]; ok {
+		return w
+	}
+	return t.Weekday()
+}
@@ -0,0 +1,72 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+func TestCalendar_Weekday(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New([]config.Calendar{
+		// 2024-01-08 (Monday) is a public holiday in Japan.
+		{SpecialDays: []config.SpecialDay{{Date: "2024-01-08", Weekday: "Sunday"}}},
+		// 2024-01-15 (Monday) is a public holiday in the US.
+		{TimeZone: "America/New_York", SpecialDays: []config.SpecialDay{{Date: "2024-01-15", Weekday: "Saturday"}}},
+	}, "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		t        time.Time
+		want     time.Weekday
+	}{
+		{
+			name:     "the special day uses the specified day of the week",
+			calendar: c,
+			t:        time.Date(2024, 1, 8, 10, 0, 0, 0, tokyo),
+			want:     time.Sunday,
+		},
+		{
+			name:     "the normal day",
+			calendar: c,
+			t:        time.Date(2024, 1, 15, 10, 0, 0, 0, tokyo),
+			want:     time.Monday,
+		},
+		{
+			name:     "the special day in another time zone",
+			calendar: c,
+			t:        time.Date(2024, 1, 15, 10, 0, 0, 0, newYork),
+			want:     time.Saturday,
+		},
+		{
+			name:     "the special day is checked in the time zone of the calendar",
+			calendar: c,
+			t:        time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC),
+			want:     time.Monday,
+		},
+		{
+			name: "nil calendar",
+			t:    time.Date(2024, 1, 8, 10, 0, 0, 0, tokyo),
+			want: time.Monday,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calendar.Weekday(tt.t); got != tt.want {
+				t.Errorf("Weekday() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -8,10 +8,24 @@ import (
 
 	"gopkg.in/yaml.v3"
 	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/mercari/tortoise/pkg/annotation"
 	"github.com/mercari/tortoise/pkg/features"
 )
 
+const (
+	RecommendationSourceVPA        = "VPA"
+	RecommendationSourcePrometheus = "Prometheus"
+)
+
+const (
+	ReplicaRecommendationStrategyMaxDecay   = "MaxDecay"
+	ReplicaRecommendationStrategyEWMA       = "EWMA"
+	ReplicaRecommendationStrategyPercentile = "Percentile"
+)
+
 type Config struct {
 	// RangeOfMinMaxReplicasRecommendationHours is the time (hours) range of minReplicas and maxReplicas recommendation (default: 1)
 	//
@@ -36,6 +50,36 @@ type Config struct {
 	//           updatedAt: 2023-01-01T00:00:00Z
 	// ```
 	RangeOfMinMaxReplicasRecommendationHours int `yaml:"RangeOfMinMaxReplicasRecommendationHours"`
+	// RangeOfMinMaxReplicasRecommendationMinutes is the time (minutes) range of minReplicas and maxReplicas recommendation (default: 0)
+	// It must be a divisor of 1440 (24 hours), and allows the time slots shorter than an hour, such as 15 minutes for sharp peaks.
+	// If it's set, it takes precedence over RangeOfMinMaxReplicasRecommendationHours.
+	// If it's 0, tortoise uses RangeOfMinMaxReplicasRecommendationHours.
+	//
+	//```yaml
+	// kind: Tortoise
+	// #...
+	// status:
+	//   recommendations:
+	//     horizontal:
+	//       minReplicas:
+	//         - from: 12
+	//           fromMinute: 0
+	//           to: 12
+	//           toMinute: 15
+	//           weekday: Sunday
+	//           timezone: Asia/Tokyo
+	//           value: 3
+	//           updatedAt: 2023-01-01T00:00:00Z
+	//         - from: 12
+	//           fromMinute: 15
+	//           to: 12
+	//           toMinute: 30
+	//           weekday: Sunday
+	//           timezone: Asia/Tokyo
+	//           value: 6
+	//           updatedAt: 2023-01-01T00:00:00Z
+	// ```
+	RangeOfMinMaxReplicasRecommendationMinutes int `yaml:"RangeOfMinMaxReplicasRecommendationMinutes"`
 	// GatheringDataPeriodType means how long do we gather data for minReplica/maxReplica or data from VPA. "daily" and "weekly" are only valid value. (default: weekly)
 	// If "daily", tortoise will consider all workload behaves very similarly every day.
 	// If your workload may behave differently on, for example, weekdays and weekends, set this to "weekly".
@@ -237,6 +281,45 @@ type Config struct {
 	// all the external metric which name matches `datadogmetric.*` regex are removed by Tortoise once Tortoise is in Auto mode.
 	HPAExternalMetricExclusionRegex string `yaml:"HPAExternalMetricExclusionRegex"`
 
+	// HPAMetricTargetBounds is the list of Pods and External metrics in HPA whose target average value is tuned by Tortoise. (default: empty = not tuned)
+	// Tortoise observes the average value of the metric per replica from the HPA status,
+	// and recommends the target so that the peak per replica stays within MaxTarget,
+	// in the same way as the target utilization of the container resource metrics.
+	// So, MaxTarget should be the value that each replica can handle at most.
+	// The target is increased by HPATargetUtilizationMaxIncrease percent at most at once, and updated every HPATargetUtilizationUpdateInterval.
+	// Only the metrics with the AverageValue target are tuned.
+	//
+	// ```yaml
+	// HPAMetricTargetBounds:
+	//   - Type: External
+	//     Name: queue_messages_ready
+	//     MinTarget: "10"
+	//     MaxTarget: "100"
+	// ```
+	HPAMetricTargetBounds []MetricTargetBounds `yaml:"HPAMetricTargetBounds"`
+
+	// ReplicaRecommendationStrategy is how Tortoise calculates the minReplicas/maxReplicas recommendation for each time slot. (default: MaxDecay)
+	// - MaxDecay: the maximum replica number observed in the time slot. It's decayed by 5% when the time slot comes again (e.g., next week).
+	// - EWMA: the exponentially weighted moving average of the maximum replica numbers observed in the time slot in the past ReplicaRecommendationPeriods weeks (or days).
+	// - Percentile: the ReplicaRecommendationPercentile-th percentile of the maximum replica numbers observed in the time slot in the past ReplicaRecommendationPeriods weeks (or days).
+	// With MaxDecay, one spike keeps the recommendation high for a long time,
+	// while EWMA and Percentile forget it in ReplicaRecommendationPeriods weeks (or days) at most.
+	ReplicaRecommendationStrategy string `yaml:"ReplicaRecommendationStrategy"`
+	// ReplicaRecommendationPeriods is the number of the past weeks (or days, if GatheringDataPeriodType is daily) kept for each time slot,
+	// which is used when ReplicaRecommendationStrategy is EWMA or Percentile. (default: 4)
+	ReplicaRecommendationPeriods int `yaml:"ReplicaRecommendationPeriods"`
+	// ReplicaRecommendationEWMAAlpha is the weight of the latest week (or day), which is used when ReplicaRecommendationStrategy is EWMA. (default: 0.5)
+	ReplicaRecommendationEWMAAlpha float64 `yaml:"ReplicaRecommendationEWMAAlpha"`
+	// ReplicaRecommendationPercentile is the percentile, which is used when ReplicaRecommendationStrategy is Percentile. (default: 90)
+	ReplicaRecommendationPercentile float64 `yaml:"ReplicaRecommendationPercentile"`
+
+	// MinReplicasLeadTime is how far ahead Tortoise looks at the minReplicas recommendation. (default: 0 = disabled)
+	// When it's set, Tortoise sets minReplicas of HPA to the maximum of the recommendations
+	// for the current time slot and the time slots starting within MinReplicasLeadTime,
+	// so that the replicas are raised before the traffic ramps up, based on the history of the last days/weeks.
+	// It only raises minReplicas ahead of time; minReplicas is lowered at the end of the time slot as usual.
+	MinReplicasLeadTime time.Duration `yaml:"MinReplicasLeadTime"`
+
 	// EmergencyModeGracePeriod is the grace period before triggering emergency mode when HPA metrics are temporarily unavailable (default: 5m)
 	// This prevents false emergency mode triggers during temporary HPA metric unavailability during HPA updates, deployments, scheduled scaling, etc.
 	// During this grace period, the system will continue normal operation even if HPA metrics are temporarily unavailable.
@@ -284,6 +367,98 @@ type Config struct {
 	// The default value is nil; Tortoise doesn't change the resource limit itself.
 	ResourceLimitMultiplier map[string]int64 `yaml:"ResourceLimitMultiplier"`
 
+	// RecommendationSource is the source of the resource recommendation for each container (default: VPA)
+	// Tortoise uses the recommendation to calculate the resource requests for vertical scaling and the HPA target utilization for horizontal scaling.
+	//
+	// - "VPA": Tortoise creates a monitor VPA for each tortoise and uses its recommendation. The VPA recommender has to be running in the cluster.
+	// - "Prometheus": Tortoise queries the container usage metrics (cAdvisor metrics) from Prometheus specified by PrometheusAddress.
+	// The target recommendation is the 90th percentile of the usage during PrometheusRecommendationWindow,
+	// and the upper bound is the 99th percentile of the CPU usage and the maximum memory usage during PrometheusRecommendationWindow.
+	RecommendationSource string `yaml:"RecommendationSource"`
+	// PrometheusAddress is the address of Prometheus, which is used when RecommendationSource is "Prometheus". (default: "")
+	// e.g., http://prometheus.monitoring.svc:9090
+	PrometheusAddress string `yaml:"PrometheusAddress"`
+	// PrometheusRecommendationWindow is the time range of the container usage to calculate the recommendation,
+	// which is used when RecommendationSource is "Prometheus". (default: 168h)
+	PrometheusRecommendationWindow time.Duration `yaml:"PrometheusRecommendationWindow"`
+
+	// RecommenderOverridesBounds is the range of values that users can set in .spec.recommenderOverrides of each tortoise. (default: empty = no override is allowed)
+	// If the range of a field isn't configured, users cannot override the field.
+	//
+	// ```yaml
+	// RecommenderOverridesBounds:
+	//   PreferredMaxReplicas:
+	//     Min: 10
+	//     Max: 50
+	//   BufferRatioOnVerticalResource:
+	//     Min: 0.1
+	//     Max: 0.3
+	// ```
+	RecommenderOverridesBounds RecommenderOverridesBounds `yaml:"RecommenderOverridesBounds"`
+
+	// RecommendationHistoryMaxEntries is the maximum number of entries kept in TortoiseRecommendationHistory of each tortoise. (default: 500)
+	// Tortoise records the changes applied to the resource requests and HPA in TortoiseRecommendationHistory,
+	// and removes the oldest entries when the number of entries exceeds this value.
+	// If it's 0, Tortoise doesn't record the history.
+	RecommendationHistoryMaxEntries int `yaml:"RecommendationHistoryMaxEntries"`
+	// RecommendationHistoryRetentionPeriod is the period to keep each entry in TortoiseRecommendationHistory. (default: 720h)
+	// The entries older than this period are removed.
+	RecommendationHistoryRetentionPeriod time.Duration `yaml:"RecommendationHistoryRetentionPeriod"`
+
+	// VerticalRollbackWindow is the period after Tortoise changes the resource requests, during which Tortoise watches the restarted Pods. (default: 15m)
+	// If the restarted Pods get OOMKilled or CrashLoopBackOff during this period after the resource requests were lowered,
+	// Tortoise rolls the resource requests back to the previous ones,
+	// and raises the floor of the resource request of the crashed container so that it won't be lowered again.
+	// If it's 0, Tortoise doesn't roll back the resource requests.
+	VerticalRollbackWindow time.Duration `yaml:"VerticalRollbackWindow"`
+
+	// OOMBumpUpRatio is the ratio to bump up the memory recommendation when the container is OOMKilled. (default: 1.2)
+	// When the container is OOMKilled, Tortoise recommends max(memory request * OOMBumpUpRatio, memory request + OOMMinBumpUp) at least.
+	OOMBumpUpRatio float64 `yaml:"OOMBumpUpRatio"`
+	// OOMMinBumpUp is the minimum amount of memory to bump up the memory recommendation when the container is OOMKilled. (default: 100Mi)
+	OOMMinBumpUp string `yaml:"OOMMinBumpUp"`
+	// OOMScaleDownCooldown is the period after the container is OOMKilled, during which Tortoise keeps the bumped memory recommendation
+	// and doesn't scale down the memory request of the container. (default: 1h)
+	// If it's 0, Tortoise doesn't take OOMKilled into account in the memory recommendation.
+	OOMScaleDownCooldown time.Duration `yaml:"OOMScaleDownCooldown"`
+
+	// DefaultApplyWindows are the maintenance windows used by the tortoises which don't have .spec.applyWindows. (default: empty = no window)
+	// Tortoise queues the lowered resource requests until the next window opens,
+	// while it applies the increased resource requests immediately.
+	// If empty, tortoise applies the lowered resource requests at any time.
+	//
+	// ```yaml
+	// DefaultApplyWindows:
+	//   - Weekdays: ["Saturday", "Sunday"]
+	//     StartHour: 2
+	//     EndHour: 6
+	//     TimeZone: Asia/Tokyo
+	// ```
+	DefaultApplyWindows []ApplyWindow `yaml:"DefaultApplyWindows"`
+
+	// Calendars are the special days, such as public holidays, in each time zone. (default: empty)
+	// On the special day, the weekly minReplicas/maxReplicas recommendation of the specified day of the week is used and updated
+	// instead of the one of the actual day of the week.
+	// For example, a public holiday on Monday can use the recommendation of Sunday,
+	// and it doesn't pollute the recommendation of the normal Mondays.
+	// Each calendar is applied to the recommendations in the same time zone.
+	//
+	// ```yaml
+	// Calendars:
+	//   - TimeZone: Asia/Tokyo
+	//     SpecialDays:
+	//       - Date: "2024-01-08"
+	//         Weekday: Sunday
+	// ```
+	Calendars []Calendar `yaml:"Calendars"`
+
+	// MaxConcurrentRestarts is the maximum number of the rollouts triggered by Tortoise that can run at the same time in the cluster. (default: 0 = unlimited)
+	// The restarts beyond the limit are deferred until the running rollouts are completed,
+	// and the tortoises waiting for the restart have the RestartDeferred condition.
+	MaxConcurrentRestarts int `yaml:"MaxConcurrentRestarts"`
+	// MaxConcurrentRestartsPerNamespace is the maximum number of the rollouts triggered by Tortoise that can run at the same time in each namespace. (default: 0 = unlimited)
+	MaxConcurrentRestartsPerNamespace int `yaml:"MaxConcurrentRestartsPerNamespace"`
+
 	// TODO: the following fields should be removed after we stop depending on deployment.
 	// So, we don't put them in the documentation.
 	// IstioSidecarProxyDefaultCPU is the default CPU resource request of the istio sidecar proxy (default: 100m)
@@ -291,6 +466,25 @@ type Config struct {
 	// IstioSidecarProxyDefaultMemory is the default Memory resource request of the istio sidecar proxy (default: 200Mi)
 	IstioSidecarProxyDefaultMemory string `yaml:"IstioSidecarProxyDefaultMemory"`
 
+	// SidecarInjectors is the map from the name of the sidecar container injected by a mutating webhook (e.g., Linkerd, Datadog)
+	// to the annotations on the Pod template which configure the resources of the sidecar. (default: empty)
+	// The istio sidecar proxy (istio-proxy) is always supported with the sidecar.istio.io/* annotations,
+	// IstioSidecarProxyDefaultCPU and IstioSidecarProxyDefaultMemory, unless it's overridden here.
+	//
+	// ```yaml
+	// SidecarInjectors:
+	//   linkerd-proxy:
+	//     InjectionAnnotation: linkerd.io/inject
+	//     InjectionAnnotationValue: enabled
+	//     CPURequestAnnotation: config.linkerd.io/proxy-cpu-request
+	//     CPULimitAnnotation: config.linkerd.io/proxy-cpu-limit
+	//     MemoryRequestAnnotation: config.linkerd.io/proxy-memory-request
+	//     MemoryLimitAnnotation: config.linkerd.io/proxy-memory-limit
+	//     DefaultCPURequest: 100m
+	//     DefaultMemoryRequest: 20Mi
+	// ```
+	SidecarInjectors map[string]SidecarInjector `yaml:"SidecarInjectors"`
+
 	// FeatureFlags is the list of feature flags (default: empty = all alpha features are disabled)
 	// See the list of feature flags in features.go
 	FeatureFlags []features.FeatureFlag `yaml:"FeatureFlags"`
@@ -310,6 +504,162 @@ type Config struct {
 	ExcludedNamespaces []string `yaml:"ExcludedNamespaces"`
 }
 
+// RecommenderOverridesBounds is the range of each field in .spec.recommenderOverrides.
+type RecommenderOverridesBounds struct {
+	PreferredMaxReplicas                *Int32Range   `yaml:"PreferredMaxReplicas"`
+	MinimumMinReplicas                  *Int32Range   `yaml:"MinimumMinReplicas"`
+	MaxReplicasRecommendationMultiplier *Float64Range `yaml:"MaxReplicasRecommendationMultiplier"`
+	BufferRatioOnVerticalResource       *Float64Range `yaml:"BufferRatioOnVerticalResource"`
+	MaxAllowedScalingDownRatio          *Float64Range `yaml:"MaxAllowedScalingDownRatio"`
+	MinimumTargetResourceUtilization    *Int32Range   `yaml:"MinimumTargetResourceUtilization"`
+}
+
+// MetricTargetBounds is the range of the target average value of the metric in HPA, which Tortoise tunes.
+type MetricTargetBounds struct {
+	// Type is the type of the metric, "Pods" or "External".
+	Type string `yaml:"Type"`
+	// Name is the name of the metric.
+	Name string `yaml:"Name"`
+	// MinTarget is the minimum target average value. (e.g., "10")
+	MinTarget string `yaml:"MinTarget"`
+	// MaxTarget is the maximum target average value, which each replica can handle at most. (e.g., "100")
+	MaxTarget string `yaml:"MaxTarget"`
+}
+
+// ApplyWindow is the window in which tortoise can apply the lowered resource requests.
+// See .spec.applyWindows in Tortoise for the details of each field.
+type ApplyWindow struct {
+	// Weekdays are the days of the week when the window opens. (e.g., "Monday") If empty, the window opens every day.
+	Weekdays []string `yaml:"Weekdays"`
+	// StartHour is the hour (0-23) when the window opens.
+	StartHour int32 `yaml:"StartHour"`
+	// EndHour is the hour (1-24) when the window closes. If it's less than StartHour, the window closes on the next day.
+	EndHour int32 `yaml:"EndHour"`
+	// TimeZone is the time zone of StartHour and EndHour. If empty, TimeZone in Config is used.
+	TimeZone string `yaml:"TimeZone"`
+}
+
+// Calendar is the list of the special days in a time zone.
+type Calendar struct {
+	// TimeZone is the time zone of the special days. If empty, TimeZone in Config is used.
+	TimeZone string `yaml:"TimeZone"`
+	// SpecialDays are the days which use the recommendation of another day of the week.
+	SpecialDays []SpecialDay `yaml:"SpecialDays"`
+}
+
+// SpecialDay is the day which uses the recommendation of another day of the week.
+type SpecialDay struct {
+	// Date is the date in the YYYY-MM-DD format.
+	Date string `yaml:"Date"`
+	// Weekday is the day of the week whose recommendation is used on the date. (e.g., "Sunday")
+	Weekday string `yaml:"Weekday"`
+}
+
+// IstioProxyContainerName is the name of the istio sidecar proxy container.
+const IstioProxyContainerName = "istio-proxy"
+
+// SidecarInjector is the sidecar container injected into the Pods by a mutating webhook,
+// whose resources are configured by the annotations on the Pod template instead of the container spec in the workload.
+type SidecarInjector struct {
+	// InjectionAnnotation is the annotation on the Pod template which enables the injection.
+	InjectionAnnotation string `yaml:"InjectionAnnotation"`
+	// InjectionAnnotationValue is the value of InjectionAnnotation which enables the injection. (default: "true")
+	InjectionAnnotationValue string `yaml:"InjectionAnnotationValue"`
+	// CPURequestAnnotation is the annotation for the CPU request of the sidecar.
+	CPURequestAnnotation string `yaml:"CPURequestAnnotation"`
+	// CPULimitAnnotation is the annotation for the CPU limit of the sidecar.
+	CPULimitAnnotation string `yaml:"CPULimitAnnotation"`
+	// MemoryRequestAnnotation is the annotation for the memory request of the sidecar.
+	MemoryRequestAnnotation string `yaml:"MemoryRequestAnnotation"`
+	// MemoryLimitAnnotation is the annotation for the memory limit of the sidecar.
+	MemoryLimitAnnotation string `yaml:"MemoryLimitAnnotation"`
+	// DefaultCPURequest is the CPU request of the sidecar when the Pod template doesn't have CPURequestAnnotation.
+	// If empty, the CPU request of the sidecar is unknown without the annotation.
+	DefaultCPURequest string `yaml:"DefaultCPURequest"`
+	// DefaultMemoryRequest is the memory request of the sidecar when the Pod template doesn't have MemoryRequestAnnotation.
+	// If empty, the memory request of the sidecar is unknown without the annotation.
+	DefaultMemoryRequest string `yaml:"DefaultMemoryRequest"`
+}
+
+// IstioSidecarInjector returns the SidecarInjector of the istio sidecar proxy.
+func IstioSidecarInjector(defaultCPU, defaultMemory string) SidecarInjector {
+	return SidecarInjector{
+		InjectionAnnotation:     annotation.IstioSidecarInjectionAnnotation,
+		CPURequestAnnotation:    annotation.IstioSidecarProxyCPUAnnotation,
+		CPULimitAnnotation:      annotation.IstioSidecarProxyCPULimitAnnotation,
+		MemoryRequestAnnotation: annotation.IstioSidecarProxyMemoryAnnotation,
+		MemoryLimitAnnotation:   annotation.IstioSidecarProxyMemoryLimitAnnotation,
+		DefaultCPURequest:       defaultCPU,
+		DefaultMemoryRequest:    defaultMemory,
+	}
+}
+
+// Injected returns true if the injection of the sidecar is enabled by the annotations on the Pod template.
+func (s SidecarInjector) Injected(annotations map[string]string) bool {
+	want := s.InjectionAnnotationValue
+	if want == "" {
+		want = "true"
+	}
+	v, ok := annotations[s.InjectionAnnotation]
+	return ok && v == want
+}
+
+// ResourceAnnotations returns the annotations for the request and the limit of the resource.
+// They're empty if the resource isn't configured by the annotations.
+func (s SidecarInjector) ResourceAnnotations(name corev1.ResourceName) (request, limit string) {
+	switch name {
+	case corev1.ResourceCPU:
+		return s.CPURequestAnnotation, s.CPULimitAnnotation
+	case corev1.ResourceMemory:
+		return s.MemoryRequestAnnotation, s.MemoryLimitAnnotation
+	}
+	return "", ""
+}
+
+// DefaultRequest returns the request of the resource used when the Pod template doesn't have the annotation.
+func (s SidecarInjector) DefaultRequest(name corev1.ResourceName) string {
+	switch name {
+	case corev1.ResourceCPU:
+		return s.DefaultCPURequest
+	case corev1.ResourceMemory:
+		return s.DefaultMemoryRequest
+	}
+	return ""
+}
+
+// AllSidecarInjectors returns SidecarInjectors with the istio sidecar proxy unless it's overridden in SidecarInjectors.
+func (c *Config) AllSidecarInjectors() map[string]SidecarInjector {
+	injectors := map[string]SidecarInjector{
+		IstioProxyContainerName: IstioSidecarInjector(c.IstioSidecarProxyDefaultCPU, c.IstioSidecarProxyDefaultMemory),
+	}
+	for name, s := range c.SidecarInjectors {
+		injectors[name] = s
+	}
+	return injectors
+}
+
+// Int32Range is the range between Min and Max (both inclusive).
+type Int32Range struct {
+	Min int32 `yaml:"Min"`
+	Max int32 `yaml:"Max"`
+}
+
+// Contains returns true if v is within the range. A nil range doesn't contain any value.
+func (r *Int32Range) Contains(v int32) bool {
+	return r != nil && r.Min <= v && v <= r.Max
+}
+
+// Float64Range is the range between Min and Max (both inclusive).
+type Float64Range struct {
+	Min float64 `yaml:"Min"`
+	Max float64 `yaml:"Max"`
+}
+
+// Contains returns true if v is within the range. A nil range doesn't contain any value.
+func (r *Float64Range) Contains(v float64) bool {
+	return r != nil && r.Min <= v && v <= r.Max
+}
+
 func defaultConfig() *Config {
 	return &Config{
 		RangeOfMinMaxReplicasRecommendationHours: 1,
@@ -341,6 +691,18 @@ func defaultConfig() *Config {
 		BufferRatioOnVerticalResource:            0.1,
 		EmergencyModeGracePeriod:                 5 * time.Minute,
 		GlobalDisableMode:                        false,
+		RecommendationSource:                     RecommendationSourceVPA,
+		PrometheusRecommendationWindow:           7 * 24 * time.Hour,
+		RecommendationHistoryMaxEntries:          500,
+		RecommendationHistoryRetentionPeriod:     30 * 24 * time.Hour,
+		VerticalRollbackWindow:                   15 * time.Minute,
+		OOMBumpUpRatio:                           1.2,
+		OOMMinBumpUp:                             "100Mi",
+		OOMScaleDownCooldown:                     time.Hour,
+		ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyMaxDecay,
+		ReplicaRecommendationPeriods:             4,
+		ReplicaRecommendationEWMAAlpha:           0.5,
+		ReplicaRecommendationPercentile:          90,
 	}
 }
 
@@ -441,6 +803,11 @@ func validate(config *Config) error {
 		return fmt.Errorf("RangeOfMinMaxReplicasRecommendationHours should be between 1 and 24")
 	}
 
+	if config.RangeOfMinMaxReplicasRecommendationMinutes != 0 &&
+		(config.RangeOfMinMaxReplicasRecommendationMinutes < 0 || 24*60%config.RangeOfMinMaxReplicasRecommendationMinutes != 0) {
+		return fmt.Errorf("RangeOfMinMaxReplicasRecommendationMinutes should be a divisor of 1440")
+	}
+
 	if config.GatheringDataPeriodType != "daily" && config.GatheringDataPeriodType != "weekly" {
 		return fmt.Errorf("GatheringDataPeriodType should be either \"daily\" or \"weekly\"")
 	}
@@ -475,6 +842,98 @@ func validate(config *Config) error {
 		}
 	}
 
+	switch config.RecommendationSource {
+	case "", RecommendationSourceVPA:
+	case RecommendationSourcePrometheus:
+		if config.PrometheusAddress == "" {
+			return fmt.Errorf("PrometheusAddress should be specified when RecommendationSource is %q", RecommendationSourcePrometheus)
+		}
+		if config.PrometheusRecommendationWindow <= 0 {
+			return fmt.Errorf("PrometheusRecommendationWindow should be greater than 0")
+		}
+	default:
+		return fmt.Errorf("RecommendationSource should be either %q or %q", RecommendationSourceVPA, RecommendationSourcePrometheus)
+	}
+
+	if err := validateRecommenderOverridesBounds(config.RecommenderOverridesBounds); err != nil {
+		return err
+	}
+
+	if config.RecommendationHistoryMaxEntries < 0 {
+		return fmt.Errorf("RecommendationHistoryMaxEntries should be greater than or equal to 0")
+	}
+	if config.RecommendationHistoryMaxEntries > 0 && config.RecommendationHistoryRetentionPeriod <= 0 {
+		return fmt.Errorf("RecommendationHistoryRetentionPeriod should be greater than 0")
+	}
+
+	if config.VerticalRollbackWindow < 0 {
+		return fmt.Errorf("VerticalRollbackWindow should be greater than or equal to 0")
+	}
+
+	if config.OOMScaleDownCooldown < 0 {
+		return fmt.Errorf("OOMScaleDownCooldown should be greater than or equal to 0")
+	}
+	if config.OOMScaleDownCooldown > 0 {
+		if config.OOMBumpUpRatio < 1 {
+			return fmt.Errorf("OOMBumpUpRatio should be greater than or equal to 1")
+		}
+		if _, err := resource.ParseQuantity(config.OOMMinBumpUp); err != nil {
+			return fmt.Errorf("OOMMinBumpUp should be a valid quantity: %w", err)
+		}
+	}
+
+	if err := validateHPAMetricTargetBounds(config.HPAMetricTargetBounds); err != nil {
+		return err
+	}
+
+	switch config.ReplicaRecommendationStrategy {
+	case "", ReplicaRecommendationStrategyMaxDecay:
+	case ReplicaRecommendationStrategyEWMA:
+		if config.ReplicaRecommendationPeriods < 1 {
+			return fmt.Errorf("ReplicaRecommendationPeriods should be greater than 0")
+		}
+		if config.ReplicaRecommendationEWMAAlpha <= 0 || config.ReplicaRecommendationEWMAAlpha > 1 {
+			return fmt.Errorf("ReplicaRecommendationEWMAAlpha should be greater than 0 and less than or equal to 1")
+		}
+	case ReplicaRecommendationStrategyPercentile:
+		if config.ReplicaRecommendationPeriods < 1 {
+			return fmt.Errorf("ReplicaRecommendationPeriods should be greater than 0")
+		}
+		if config.ReplicaRecommendationPercentile <= 0 || config.ReplicaRecommendationPercentile > 100 {
+			return fmt.Errorf("ReplicaRecommendationPercentile should be greater than 0 and less than or equal to 100")
+		}
+	default:
+		return fmt.Errorf("ReplicaRecommendationStrategy should be one of %q, %q or %q", ReplicaRecommendationStrategyMaxDecay, ReplicaRecommendationStrategyEWMA, ReplicaRecommendationStrategyPercentile)
+	}
+
+	if config.MinReplicasLeadTime < 0 {
+		return fmt.Errorf("MinReplicasLeadTime should be greater than or equal to 0")
+	}
+	if config.MinReplicasLeadTime >= 24*time.Hour {
+		return fmt.Errorf("MinReplicasLeadTime should be less than 24h")
+	}
+
+	if config.MaxConcurrentRestarts < 0 {
+		return fmt.Errorf("MaxConcurrentRestarts should be greater than or equal to 0")
+	}
+	if config.MaxConcurrentRestartsPerNamespace < 0 {
+		return fmt.Errorf("MaxConcurrentRestartsPerNamespace should be greater than or equal to 0")
+	}
+
+	for i, w := range config.DefaultApplyWindows {
+		if err := validateApplyWindow(w); err != nil {
+			return fmt.Errorf("DefaultApplyWindows[%d]: %w", i, err)
+		}
+	}
+
+	if err := validateCalendars(config.Calendars, config.TimeZone); err != nil {
+		return err
+	}
+
+	if err := validateSidecarInjectors(config.SidecarInjectors); err != nil {
+		return err
+	}
+
 	// Validate HPA behavior if specified
 	if err := validateDefaultHPA(config.DefaultHPABehavior); err != nil {
 		return err
@@ -482,3 +941,145 @@ func validate(config *Config) error {
 
 	return nil
 }
+
+func validateRecommenderOverridesBounds(b RecommenderOverridesBounds) error {
+	int32Ranges := map[string]*Int32Range{
+		"PreferredMaxReplicas":             b.PreferredMaxReplicas,
+		"MinimumMinReplicas":               b.MinimumMinReplicas,
+		"MinimumTargetResourceUtilization": b.MinimumTargetResourceUtilization,
+	}
+	for name, r := range int32Ranges {
+		if r != nil && (r.Min > r.Max || r.Min < 1) {
+			return fmt.Errorf("RecommenderOverridesBounds.%s should have Min between 1 and Max", name)
+		}
+	}
+	if r := b.MinimumTargetResourceUtilization; r != nil && r.Max > 100 {
+		return fmt.Errorf("RecommenderOverridesBounds.MinimumTargetResourceUtilization.Max should be less than or equal to 100")
+	}
+
+	float64Ranges := map[string]*Float64Range{
+		"MaxReplicasRecommendationMultiplier": b.MaxReplicasRecommendationMultiplier,
+		"BufferRatioOnVerticalResource":       b.BufferRatioOnVerticalResource,
+		"MaxAllowedScalingDownRatio":          b.MaxAllowedScalingDownRatio,
+	}
+	for name, r := range float64Ranges {
+		if r != nil && (r.Min > r.Max || r.Min < 0) {
+			return fmt.Errorf("RecommenderOverridesBounds.%s should have Min between 0 and Max", name)
+		}
+	}
+	if r := b.MaxAllowedScalingDownRatio; r != nil && r.Max > 1 {
+		return fmt.Errorf("RecommenderOverridesBounds.MaxAllowedScalingDownRatio.Max should be less than or equal to 1")
+	}
+
+	return nil
+}
+
+var weekdays = map[string]bool{
+	time.Sunday.String():    true,
+	time.Monday.String():    true,
+	time.Tuesday.String():   true,
+	time.Wednesday.String(): true,
+	time.Thursday.String():  true,
+	time.Friday.String():    true,
+	time.Saturday.String():  true,
+}
+
+func validateApplyWindow(w ApplyWindow) error {
+	for _, d := range w.Weekdays {
+		if !weekdays[d] {
+			return fmt.Errorf("Weekdays should be the name of the day of the week (e.g., \"Monday\"), but got %q", d)
+		}
+	}
+	if w.StartHour < 0 || w.StartHour > 23 {
+		return fmt.Errorf("StartHour should be between 0 and 23")
+	}
+	if w.EndHour < 1 || w.EndHour > 24 {
+		return fmt.Errorf("EndHour should be between 1 and 24")
+	}
+	if w.StartHour == w.EndHour {
+		return fmt.Errorf("EndHour should be different from StartHour")
+	}
+	if _, err := time.LoadLocation(w.TimeZone); err != nil {
+		return fmt.Errorf("TimeZone should be a valid time zone: %w", err)
+	}
+	return nil
+}
+
+func validateCalendars(calendars []Calendar, defaultTimeZone string) error {
+	timeZones := map[string]bool{}
+	for i, c := range calendars {
+		tz := c.TimeZone
+		if tz == "" {
+			tz = defaultTimeZone
+		}
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("Calendars[%d].TimeZone should be a valid time zone: %w", i, err)
+		}
+		if timeZones[tz] {
+			return fmt.Errorf("Calendars[%d]: the calendar for the time zone %q is duplicated", i, tz)
+		}
+		timeZones[tz] = true
+
+		dates := map[string]bool{}
+		for j, d := range c.SpecialDays {
+			if _, err := time.Parse(time.DateOnly, d.Date); err != nil {
+				return fmt.Errorf("Calendars[%d].SpecialDays[%d].Date should be in the YYYY-MM-DD format: %w", i, j, err)
+			}
+			if !weekdays[d.Weekday] {
+				return fmt.Errorf("Calendars[%d].SpecialDays[%d].Weekday should be the name of the day of the week (e.g., \"Sunday\"), but got %q", i, j, d.Weekday)
+			}
+			if dates[d.Date] {
+				return fmt.Errorf("Calendars[%d].SpecialDays[%d]: the date %s is duplicated", i, j, d.Date)
+			}
+			dates[d.Date] = true
+		}
+	}
+	return nil
+}
+
+func validateSidecarInjectors(injectors map[string]SidecarInjector) error {
+	for name, s := range injectors {
+		if s.InjectionAnnotation == "" {
+			return fmt.Errorf("SidecarInjectors[%s].InjectionAnnotation should be specified", name)
+		}
+		for field, q := range map[string]string{"DefaultCPURequest": s.DefaultCPURequest, "DefaultMemoryRequest": s.DefaultMemoryRequest} {
+			if q == "" {
+				continue
+			}
+			if _, err := resource.ParseQuantity(q); err != nil {
+				return fmt.Errorf("SidecarInjectors[%s].%s should be a valid quantity: %w", name, field, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateHPAMetricTargetBounds(bounds []MetricTargetBounds) error {
+	seen := map[MetricTargetBounds]bool{}
+	for i, b := range bounds {
+		if b.Type != string(v2.PodsMetricSourceType) && b.Type != string(v2.ExternalMetricSourceType) {
+			return fmt.Errorf("HPAMetricTargetBounds[%d].Type should be either %q or %q", i, v2.PodsMetricSourceType, v2.ExternalMetricSourceType)
+		}
+		if b.Name == "" {
+			return fmt.Errorf("HPAMetricTargetBounds[%d].Name shouldn't be empty", i)
+		}
+		key := MetricTargetBounds{Type: b.Type, Name: b.Name}
+		if seen[key] {
+			return fmt.Errorf("HPAMetricTargetBounds[%d]: the %s metric %s is duplicated", i, b.Type, b.Name)
+		}
+		seen[key] = true
+
+		min, err := resource.ParseQuantity(b.MinTarget)
+		if err != nil {
+			return fmt.Errorf("HPAMetricTargetBounds[%d].MinTarget should be a valid quantity: %w", i, err)
+		}
+		max, err := resource.ParseQuantity(b.MaxTarget)
+		if err != nil {
+			return fmt.Errorf("HPAMetricTargetBounds[%d].MaxTarget should be a valid quantity: %w", i, err)
+		}
+		if min.Sign() <= 0 || min.Cmp(max) > 0 {
+			return fmt.Errorf("HPAMetricTargetBounds[%d] should have MinTarget between 0 (exclusive) and MaxTarget", i)
+		}
+	}
+	return nil
+}
@@ -47,6 +47,18 @@ func TestParseConfig(t *testing.T) {
 				HPATargetUtilizationUpdateInterval:       3 * time.Hour,
 				IstioSidecarProxyDefaultCPU:              "100m",
 				IstioSidecarProxyDefaultMemory:           "200Mi",
+				RecommendationSource:                     RecommendationSourceVPA,
+				PrometheusRecommendationWindow:           7 * 24 * time.Hour,
+				RecommendationHistoryMaxEntries:          500,
+				RecommendationHistoryRetentionPeriod:     30 * 24 * time.Hour,
+				VerticalRollbackWindow:                   15 * time.Minute,
+				OOMBumpUpRatio:                           1.2,
+				OOMMinBumpUp:                             "100Mi",
+				OOMScaleDownCooldown:                     time.Hour,
+				ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyMaxDecay,
+				ReplicaRecommendationPeriods:             4,
+				ReplicaRecommendationEWMAAlpha:           0.5,
+				ReplicaRecommendationPercentile:          90,
 				MaxAllowedScalingDownRatio:               0.5,
 				MinimumCPURequestPerContainer: map[string]string{
 					"istio-proxy": "100m",
@@ -92,6 +104,18 @@ func TestParseConfig(t *testing.T) {
 				HPATargetUtilizationUpdateInterval:       24 * time.Hour,
 				IstioSidecarProxyDefaultCPU:              "100m",
 				IstioSidecarProxyDefaultMemory:           "200Mi",
+				RecommendationSource:                     RecommendationSourceVPA,
+				PrometheusRecommendationWindow:           7 * 24 * time.Hour,
+				RecommendationHistoryMaxEntries:          500,
+				RecommendationHistoryRetentionPeriod:     30 * 24 * time.Hour,
+				VerticalRollbackWindow:                   15 * time.Minute,
+				OOMBumpUpRatio:                           1.2,
+				OOMMinBumpUp:                             "100Mi",
+				OOMScaleDownCooldown:                     time.Hour,
+				ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyMaxDecay,
+				ReplicaRecommendationPeriods:             4,
+				ReplicaRecommendationEWMAAlpha:           0.5,
+				ReplicaRecommendationPercentile:          90,
 				MaxAllowedScalingDownRatio:               0.8,
 				MinimumCPURequestPerContainer:            map[string]string{},
 				MinimumMemoryRequestPerContainer:         map[string]string{},
@@ -135,6 +159,18 @@ func TestParseConfig(t *testing.T) {
 				HPATargetUtilizationUpdateInterval:       24 * time.Hour,
 				IstioSidecarProxyDefaultCPU:              "100m",
 				IstioSidecarProxyDefaultMemory:           "200Mi",
+				RecommendationSource:                     RecommendationSourceVPA,
+				PrometheusRecommendationWindow:           7 * 24 * time.Hour,
+				RecommendationHistoryMaxEntries:          500,
+				RecommendationHistoryRetentionPeriod:     30 * 24 * time.Hour,
+				VerticalRollbackWindow:                   15 * time.Minute,
+				OOMBumpUpRatio:                           1.2,
+				OOMMinBumpUp:                             "100Mi",
+				OOMScaleDownCooldown:                     time.Hour,
+				ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyMaxDecay,
+				ReplicaRecommendationPeriods:             4,
+				ReplicaRecommendationEWMAAlpha:           0.5,
+				ReplicaRecommendationPercentile:          90,
 				MaxAllowedScalingDownRatio:               0.8,
 				MinimumCPURequestPerContainer:            map[string]string{},
 				MinimumMemoryRequestPerContainer:         map[string]string{},
@@ -592,6 +628,714 @@ func Test_validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid config with Prometheus recommendation source",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommendationSource:                     RecommendationSourcePrometheus,
+				PrometheusAddress:                        "http://prometheus:9090",
+				PrometheusRecommendationWindow:           24 * time.Hour,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid Prometheus recommendation source without PrometheusAddress",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommendationSource:                     RecommendationSourcePrometheus,
+				PrometheusRecommendationWindow:           24 * time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid negative RecommendationHistoryMaxEntries",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommendationHistoryMaxEntries:          -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid RecommendationHistoryRetentionPeriod when the history is enabled",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommendationHistoryMaxEntries:          100,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid negative VerticalRollbackWindow",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				VerticalRollbackWindow:                   -time.Minute,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid OOMBumpUpRatio",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				OOMBumpUpRatio:                           0.9,
+				OOMMinBumpUp:                             "100Mi",
+				OOMScaleDownCooldown:                     time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid OOMMinBumpUp",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				OOMBumpUpRatio:                           1.2,
+				OOMMinBumpUp:                             "hoge",
+				OOMScaleDownCooldown:                     time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid RecommenderOverridesBounds",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommenderOverridesBounds: RecommenderOverridesBounds{
+					PreferredMaxReplicas:          &Int32Range{Min: 10, Max: 50},
+					BufferRatioOnVerticalResource: &Float64Range{Min: 0.1, Max: 0.3},
+					MaxAllowedScalingDownRatio:    &Float64Range{Min: 0.5, Max: 1},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid RecommenderOverridesBounds: Min is bigger than Max",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommenderOverridesBounds: RecommenderOverridesBounds{
+					PreferredMaxReplicas: &Int32Range{Min: 50, Max: 10},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid RecommenderOverridesBounds: MaxAllowedScalingDownRatio is bigger than 1",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommenderOverridesBounds: RecommenderOverridesBounds{
+					MaxAllowedScalingDownRatio: &Float64Range{Min: 0.5, Max: 1.5},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid RecommendationSource",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				RecommendationSource:                     "invalid",
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid DefaultApplyWindows",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				DefaultApplyWindows: []ApplyWindow{
+					{Weekdays: []string{"Saturday", "Sunday"}, StartHour: 2, EndHour: 6, TimeZone: "Asia/Tokyo"},
+					{StartHour: 22, EndHour: 6},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid DefaultApplyWindows: unknown weekday",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				DefaultApplyWindows: []ApplyWindow{
+					{Weekdays: []string{"Sat"}, StartHour: 2, EndHour: 6},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid DefaultApplyWindows: EndHour is out of range",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				DefaultApplyWindows: []ApplyWindow{
+					{StartHour: 2, EndHour: 25},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid DefaultApplyWindows: EndHour is the same as StartHour",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				DefaultApplyWindows: []ApplyWindow{
+					{StartHour: 2, EndHour: 2},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid DefaultApplyWindows: unknown time zone",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				DefaultApplyWindows: []ApplyWindow{
+					{StartHour: 2, EndHour: 6, TimeZone: "Mars/Olympus"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid negative MaxConcurrentRestarts",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				MaxConcurrentRestarts:                    -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid SidecarInjectors",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				SidecarInjectors: map[string]SidecarInjector{
+					"linkerd-proxy": {InjectionAnnotation: "linkerd.io/inject", InjectionAnnotationValue: "enabled", CPURequestAnnotation: "config.linkerd.io/proxy-cpu-request", DefaultCPURequest: "100m"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid SidecarInjectors without InjectionAnnotation",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				SidecarInjectors: map[string]SidecarInjector{
+					"linkerd-proxy": {CPURequestAnnotation: "config.linkerd.io/proxy-cpu-request"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid SidecarInjectors with invalid DefaultMemoryRequest",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				SidecarInjectors: map[string]SidecarInjector{
+					"linkerd-proxy": {InjectionAnnotation: "linkerd.io/inject", DefaultMemoryRequest: "20MB?"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid Percentile ReplicaRecommendationStrategy",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyPercentile,
+				ReplicaRecommendationPeriods:             4,
+				ReplicaRecommendationPercentile:          90,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid ReplicaRecommendationStrategy",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				ReplicaRecommendationStrategy:            "Average",
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ReplicaRecommendationEWMAAlpha",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyEWMA,
+				ReplicaRecommendationPeriods:             4,
+				ReplicaRecommendationEWMAAlpha:           1.5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ReplicaRecommendationPeriods",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				ReplicaRecommendationStrategy:            ReplicaRecommendationStrategyPercentile,
+				ReplicaRecommendationPeriods:             0,
+				ReplicaRecommendationPercentile:          90,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid Calendars",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				TimeZone:                                 "Asia/Tokyo",
+				Calendars: []Calendar{
+					{SpecialDays: []SpecialDay{{Date: "2024-01-08", Weekday: "Sunday"}}},
+					{TimeZone: "America/New_York", SpecialDays: []SpecialDay{{Date: "2024-01-15", Weekday: "Sunday"}}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid Calendars: invalid date",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				TimeZone:                                 "Asia/Tokyo",
+				Calendars: []Calendar{
+					{SpecialDays: []SpecialDay{{Date: "2024/01/08", Weekday: "Sunday"}}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid Calendars: invalid weekday",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				TimeZone:                                 "Asia/Tokyo",
+				Calendars: []Calendar{
+					{SpecialDays: []SpecialDay{{Date: "2024-01-08", Weekday: "Holiday"}}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid Calendars: duplicated time zone",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				TimeZone:                                 "Asia/Tokyo",
+				Calendars: []Calendar{
+					{SpecialDays: []SpecialDay{{Date: "2024-01-08", Weekday: "Sunday"}}},
+					{TimeZone: "Asia/Tokyo", SpecialDays: []SpecialDay{{Date: "2024-02-12", Weekday: "Sunday"}}},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid RangeOfMinMaxReplicasRecommendationMinutes",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours:   1,
+				GatheringDataPeriodType:                    "weekly",
+				HPATargetUtilizationMaxIncrease:            5,
+				MinimumTargetResourceUtilization:           65,
+				MaximumTargetResourceUtilization:           90,
+				MinimumMinReplicas:                         3,
+				MaximumMinReplicas:                         10,
+				MaximumMaxReplicas:                         100,
+				PreferredMaxReplicas:                       30,
+				MaxAllowedScalingDownRatio:                 0.8,
+				RangeOfMinMaxReplicasRecommendationMinutes: 15,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid RangeOfMinMaxReplicasRecommendationMinutes which isn't a divisor of 1440",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours:   1,
+				GatheringDataPeriodType:                    "weekly",
+				HPATargetUtilizationMaxIncrease:            5,
+				MinimumTargetResourceUtilization:           65,
+				MaximumTargetResourceUtilization:           90,
+				MinimumMinReplicas:                         3,
+				MaximumMinReplicas:                         10,
+				MaximumMaxReplicas:                         100,
+				PreferredMaxReplicas:                       30,
+				MaxAllowedScalingDownRatio:                 0.8,
+				RangeOfMinMaxReplicasRecommendationMinutes: 7,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid negative RangeOfMinMaxReplicasRecommendationMinutes",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours:   1,
+				GatheringDataPeriodType:                    "weekly",
+				HPATargetUtilizationMaxIncrease:            5,
+				MinimumTargetResourceUtilization:           65,
+				MaximumTargetResourceUtilization:           90,
+				MinimumMinReplicas:                         3,
+				MaximumMinReplicas:                         10,
+				MaximumMaxReplicas:                         100,
+				PreferredMaxReplicas:                       30,
+				MaxAllowedScalingDownRatio:                 0.8,
+				RangeOfMinMaxReplicasRecommendationMinutes: -15,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid MinReplicasLeadTime",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				MinReplicasLeadTime:                      30 * time.Minute,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid negative MinReplicasLeadTime",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				MinReplicasLeadTime:                      -time.Minute,
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid MinReplicasLeadTime longer than a day",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				MinReplicasLeadTime:                      24 * time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid HPAMetricTargetBounds",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				HPAMetricTargetBounds: []MetricTargetBounds{
+					{Type: "External", Name: "queue_messages_ready", MinTarget: "10", MaxTarget: "100"},
+					{Type: "Pods", Name: "requests_per_second", MinTarget: "500m", MaxTarget: "20"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid HPAMetricTargetBounds: unsupported type",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				HPAMetricTargetBounds: []MetricTargetBounds{
+					{Type: "Object", Name: "requests_per_second", MinTarget: "10", MaxTarget: "100"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid HPAMetricTargetBounds: duplicated metric",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				HPAMetricTargetBounds: []MetricTargetBounds{
+					{Type: "External", Name: "queue_messages_ready", MinTarget: "10", MaxTarget: "100"},
+					{Type: "External", Name: "queue_messages_ready", MinTarget: "20", MaxTarget: "200"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid HPAMetricTargetBounds: MinTarget is bigger than MaxTarget",
+			config: &Config{
+				RangeOfMinMaxReplicasRecommendationHours: 1,
+				GatheringDataPeriodType:                  "weekly",
+				HPATargetUtilizationMaxIncrease:          5,
+				MinimumTargetResourceUtilization:         65,
+				MaximumTargetResourceUtilization:         90,
+				MinimumMinReplicas:                       3,
+				MaximumMinReplicas:                       10,
+				MaximumMaxReplicas:                       100,
+				PreferredMaxReplicas:                     30,
+				MaxAllowedScalingDownRatio:               0.8,
+				HPAMetricTargetBounds: []MetricTargetBounds{
+					{Type: "External", Name: "queue_messages_ready", MinTarget: "100", MaxTarget: "10"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -601,3 +1345,33 @@ func Test_validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_AllSidecarInjectors(t *testing.T) {
+	linkerd := SidecarInjector{InjectionAnnotation: "linkerd.io/inject", InjectionAnnotationValue: "enabled"}
+	tests := []struct {
+		name   string
+		config *Config
+		want   map[string]SidecarInjector
+	}{
+		{
+			name:   "istio-proxy is always included",
+			config: &Config{IstioSidecarProxyDefaultCPU: "100m", IstioSidecarProxyDefaultMemory: "200Mi", SidecarInjectors: map[string]SidecarInjector{"linkerd-proxy": linkerd}},
+			want: map[string]SidecarInjector{
+				IstioProxyContainerName: IstioSidecarInjector("100m", "200Mi"),
+				"linkerd-proxy":         linkerd,
+			},
+		},
+		{
+			name:   "istio-proxy can be overridden",
+			config: &Config{IstioSidecarProxyDefaultCPU: "100m", IstioSidecarProxyDefaultMemory: "200Mi", SidecarInjectors: map[string]SidecarInjector{IstioProxyContainerName: linkerd}},
+			want:   map[string]SidecarInjector{IstioProxyContainerName: linkerd},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.AllSidecarInjectors(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllSidecarInjectors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package deployment
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var _ workload.Adapter = &Service{}
+
+// Get implements workload.Adapter.
+func (c *Service) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (client.Object, error) {
+	dm, err := c.GetDeploymentOnTortoise(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	return dm, nil
+}
+
+// PodTemplate implements workload.Adapter.
+func (c *Service) PodTemplate(w client.Object) (*corev1.PodTemplateSpec, error) {
+	dm, err := workload.As[*v1.Deployment](w)
+	if err != nil {
+		return nil, err
+	}
+	return &dm.Spec.Template, nil
+}
+
+// ResourceRequests implements workload.Adapter.
+func (c *Service) ResourceRequests(w client.Object) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
+	dm, err := workload.As[*v1.Deployment](w)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetResourceRequests(dm)
+}
+
+// Restart implements workload.Adapter.
+func (c *Service) Restart(ctx context.Context, w client.Object, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	dm, err := workload.As[*v1.Deployment](w)
+	if err != nil {
+		return err
+	}
+	return c.RolloutRestart(ctx, dm, tortoise, now)
+}
+
+// RolloutCompleted returns true if all the Pods of the deployment are updated and available,
+// which is the same condition as `kubectl rollout status`.
+func (c *Service) RolloutCompleted(w client.Object) (bool, error) {
+	dm, err := workload.As[*v1.Deployment](w)
+	if err != nil {
+		return false, err
+	}
+	replicas := int32(1)
+	if dm.Spec.Replicas != nil {
+		replicas = *dm.Spec.Replicas
+	}
+	return dm.Status.ObservedGeneration >= dm.Generation &&
+		dm.Status.UpdatedReplicas >= replicas &&
+		dm.Status.Replicas == dm.Status.UpdatedReplicas &&
+		dm.Status.AvailableReplicas == dm.Status.UpdatedReplicas, nil
+}
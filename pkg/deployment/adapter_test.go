@@ -0,0 +1,35 @@
+package deployment
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func testDeployment(rolledOut bool) *appsv1.Deployment {
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](3)},
+		Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+	}
+	if !rolledOut {
+		d.Status.ObservedGeneration = 1
+		d.Status.UpdatedReplicas = 1
+	}
+	return d
+}
+
+func TestService_RolloutCompleted(t *testing.T) {
+	s := New(nil, nil, nil)
+	if got, _ := s.RolloutCompleted(testDeployment(true)); !got {
+		t.Errorf("RolloutCompleted() = false, want true")
+	}
+	if got, _ := s.RolloutCompleted(testDeployment(false)); got {
+		t.Errorf("RolloutCompleted() = true, want false")
+	}
+	if _, err := s.RolloutCompleted(&appsv1.StatefulSet{}); err == nil {
+		t.Errorf("RolloutCompleted() should return an error for StatefulSet")
+	}
+}
@@ -3,14 +3,17 @@ package event
 const (
 	VPACreated = "VPACreated"
 
-	VerticalRecommendationUpdated = "VerticalRecommendationUpdated"
+	VerticalRecommendationUpdated    = "VerticalRecommendationUpdated"
+	VerticalRecommendationRolledBack = "VerticalRecommendationRolledBack"
+	MemoryBumpedUpOnOOMKilled        = "MemoryBumpedUpOnOOMKilled"
 
 	HPACreated  = "HPACreated"
 	HPAUpdated  = "HPAUpdated"
 	HPADeleted  = "HPADeleted"
 	HPADisabled = "HPADisabled"
 
-	RecommendationUpdated = "RecommendationUpdated"
+	RecommendationUpdated          = "RecommendationUpdated"
+	ReplicasRecommendationReshaped = "ReplicasRecommendationReshaped"
 
 	Initialized          = "Initialized"
 	Working              = "Working"
@@ -18,6 +21,16 @@ const (
 	EmergencyModeEnabled = "EmergencyModeEnabled"
 	EmergencyModeFailed  = "EmergencyModeFailed"
 	RestartDeployment    = "RestartDeployment"
+	RestartStatefulSet   = "RestartStatefulSet"
+	RestartRollout       = "RestartRollout"
+	RestartDeferred      = "RestartDeferred"
+	PodsResizedInPlace   = "PodsResizedInPlace"
+	InPlaceResizeFailed  = "InPlaceResizeFailed"
+
+	ScheduledScalingUp      = "ScheduledScalingUp"
+	ScheduledScalingExpired = "ScheduledScalingExpired"
+	ScheduledScalingFailed  = "ScheduledScalingFailed"
 
 	WarningHittingHardMaxReplicaLimit = "HitHardMaxReplicaLimit"
+	WarningMetricTargetNotApplied     = "MetricTargetNotApplied"
 )
@@ -12,6 +12,15 @@ const (
 	// Stage: alpha (default: disabled)
 	// Description: Enable the feature to modify GOMEMLIMIT based on the memory request in the Pod mutating webhook.
 	GoMemLimitModificationEnabled FeatureFlag = "GoMemLimitModificationEnabled"
+
+	// Stage: alpha (default: disabled)
+	// Description: Enable the feature to modify -Xmx, -Xms and -XX:ActiveProcessorCount in JAVA_TOOL_OPTIONS and JAVA_OPTS
+	// based on the resource requests in the Pod mutating webhook.
+	JVMOptionsModificationEnabled FeatureFlag = "JVMOptionsModificationEnabled"
+
+	// Stage: alpha (default: disabled)
+	// Description: Enable the feature to modify --max-old-space-size in NODE_OPTIONS based on the memory request in the Pod mutating webhook.
+	NodeOptionsModificationEnabled FeatureFlag = "NodeOptionsModificationEnabled"
 )
 
 func Contains(flags []FeatureFlag, flag FeatureFlag) bool {
@@ -0,0 +1,60 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+)
+
+// OutputFormat is the format of the history.
+type OutputFormat string
+
+const (
+	OutputFormatTable OutputFormat = "table"
+	OutputFormatJSON  OutputFormat = "json"
+	OutputFormatYAML  OutputFormat = "yaml"
+)
+
+// Write writes the entries in the format.
+func Write(w io.Writer, entries []v1alpha1.RecommendationHistoryEntry, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		b, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal the history to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case OutputFormatYAML:
+		b, err := yaml.Marshal(entries)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the history to YAML: %w", err)
+		}
+		_, err = w.Write(b)
+		return err
+	case OutputFormatTable, "":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "TIME\tTARGET\tCONTAINER\tRESOURCE\tOLD\tNEW\tREASON")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				e.Time.Time.Format(time.RFC3339), e.Target, orDash(e.ContainerName), orDash(string(e.ResourceName)),
+				orDash(e.OldValue), orDash(e.NewValue), orDash(e.Reason))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
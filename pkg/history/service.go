@@ -0,0 +1,110 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+// Service records the changes applied by tortoise in TortoiseRecommendationHistory.
+type Service struct {
+	c client.Client
+
+	// maxEntries is the maximum number of entries kept per Tortoise.
+	// 0 means the history is disabled.
+	maxEntries int
+	// retentionPeriod is how long the entries are kept.
+	retentionPeriod time.Duration
+}
+
+func New(c client.Client, maxEntries int, retentionPeriod time.Duration) *Service {
+	return &Service{c: c, maxEntries: maxEntries, retentionPeriod: retentionPeriod}
+}
+
+// Append appends the entries to the TortoiseRecommendationHistory of the tortoise.
+// The TortoiseRecommendationHistory is created with the same name as the tortoise if it doesn't exist yet,
+// and the old entries are removed based on the retention.
+func (s *Service) Append(ctx context.Context, tortoise *v1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) error {
+	if s.maxEntries == 0 || len(entries) == 0 {
+		return nil
+	}
+
+	updateFn := func() error {
+		h := &v1alpha1.TortoiseRecommendationHistory{}
+		err := s.c.Get(ctx, client.ObjectKeyFromObject(tortoise), h)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get tortoise recommendation history: %w", err)
+		}
+		if apierrors.IsNotFound(err) {
+			h = &v1alpha1.TortoiseRecommendationHistory{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      tortoise.Name,
+					Namespace: tortoise.Namespace,
+				},
+				Spec: v1alpha1.TortoiseRecommendationHistorySpec{
+					TortoiseName: tortoise.Name,
+				},
+			}
+			// The history is garbage-collected together with the tortoise.
+			if err := controllerutil.SetControllerReference(tortoise, h, s.c.Scheme()); err != nil {
+				return fmt.Errorf("failed to set the owner reference: %w", err)
+			}
+			h.Status.Entries = Prune(entries, s.maxEntries, s.retentionPeriod, now)
+			return s.c.Create(ctx, h)
+		}
+
+		h.Status.Entries = Prune(append(h.Status.Entries, entries...), s.maxEntries, s.retentionPeriod, now)
+		return s.c.Update(ctx, h)
+	}
+
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to append entries to tortoise recommendation history: %w", err)
+	}
+	return nil
+}
+
+// Get returns the TortoiseRecommendationHistory of the tortoise.
+func (s *Service) Get(ctx context.Context, nn types.NamespacedName) (*v1alpha1.TortoiseRecommendationHistory, error) {
+	h := &v1alpha1.TortoiseRecommendationHistory{}
+	if err := s.c.Get(ctx, nn, h); err != nil {
+		return nil, fmt.Errorf("failed to get tortoise recommendation history: %w", err)
+	}
+	return h, nil
+}
+
+// Prune removes the entries older than the retention period, and then the oldest entries beyond maxEntries.
+// The entries must be ordered from the oldest to the newest.
+func Prune(entries []v1alpha1.RecommendationHistoryEntry, maxEntries int, retentionPeriod time.Duration, now time.Time) []v1alpha1.RecommendationHistoryEntry {
+	start := 0
+	for start < len(entries) && entries[start].Time.Time.Before(now.Add(-retentionPeriod)) {
+		start++
+	}
+	if len(entries)-start > maxEntries {
+		start = len(entries) - maxEntries
+	}
+	if start == 0 {
+		return entries
+	}
+	return append([]v1alpha1.RecommendationHistoryEntry{}, entries[start:]...)
+}
+
+// Since returns the entries recorded at or after the given time.
+func Since(entries []v1alpha1.RecommendationHistoryEntry, since time.Time) []v1alpha1.RecommendationHistoryEntry {
+	var result []v1alpha1.RecommendationHistoryEntry
+	for _, e := range entries {
+		if !e.Time.Time.Before(since) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+var now = time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC)
+
+func entry(t time.Time, newValue string) v1alpha1.RecommendationHistoryEntry {
+	return v1alpha1.RecommendationHistoryEntry{
+		Time:     metav1.NewTime(t),
+		Target:   v1alpha1.HistoryTargetHPAMinReplicas,
+		OldValue: "1",
+		NewValue: newValue,
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name            string
+		entries         []v1alpha1.RecommendationHistoryEntry
+		maxEntries      int
+		retentionPeriod time.Duration
+		want            []v1alpha1.RecommendationHistoryEntry
+	}{
+		{
+			name:            "nothing to prune",
+			entries:         []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-2*time.Hour), "2"), entry(now.Add(-time.Hour), "3")},
+			maxEntries:      10,
+			retentionPeriod: 24 * time.Hour,
+			want:            []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-2*time.Hour), "2"), entry(now.Add(-time.Hour), "3")},
+		},
+		{
+			name:            "entries older than the retention period are removed",
+			entries:         []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-48*time.Hour), "2"), entry(now.Add(-time.Hour), "3")},
+			maxEntries:      10,
+			retentionPeriod: 24 * time.Hour,
+			want:            []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-time.Hour), "3")},
+		},
+		{
+			name:            "the oldest entries beyond maxEntries are removed",
+			entries:         []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-3*time.Hour), "2"), entry(now.Add(-2*time.Hour), "3"), entry(now.Add(-time.Hour), "4")},
+			maxEntries:      2,
+			retentionPeriod: 24 * time.Hour,
+			want:            []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-2*time.Hour), "3"), entry(now.Add(-time.Hour), "4")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Prune(tt.entries, tt.maxEntries, tt.retentionPeriod, now)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("Prune() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestService_Append(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1beta3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tortoise := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tortoise).Build()
+	s := New(c, 2, 24*time.Hour)
+
+	// The first append creates the history.
+	if err := s.Append(context.Background(), tortoise, []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-2*time.Hour), "2")}, now); err != nil {
+		t.Fatalf("Append() unexpected error = %v", err)
+	}
+	// The second append exceeds maxEntries, and the oldest one should be removed.
+	if err := s.Append(context.Background(), tortoise, []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-time.Hour), "3"), entry(now, "4")}, now); err != nil {
+		t.Fatalf("Append() unexpected error = %v", err)
+	}
+
+	got, err := s.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "tortoise"})
+	if err != nil {
+		t.Fatalf("Get() unexpected error = %v", err)
+	}
+	if got.Spec.TortoiseName != "tortoise" {
+		t.Errorf("Spec.TortoiseName = %s, want tortoise", got.Spec.TortoiseName)
+	}
+	if len(got.OwnerReferences) != 1 || got.OwnerReferences[0].Kind != "Tortoise" || got.OwnerReferences[0].Name != "tortoise" {
+		t.Errorf("OwnerReferences = %v, want the reference to the tortoise", got.OwnerReferences)
+	}
+	want := []v1alpha1.RecommendationHistoryEntry{entry(now.Add(-time.Hour), "3"), entry(now, "4")}
+	if d := cmp.Diff(want, got.Status.Entries); d != "" {
+		t.Errorf("Status.Entries diff = %s", d)
+	}
+}
+
+func TestService_Append_Disabled(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1beta3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tortoise := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tortoise).Build()
+	s := New(c, 0, 24*time.Hour)
+
+	if err := s.Append(context.Background(), tortoise, []v1alpha1.RecommendationHistoryEntry{entry(now, "2")}, now); err != nil {
+		t.Fatalf("Append() unexpected error = %v", err)
+	}
+	if _, err := s.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "tortoise"}); err == nil {
+		t.Errorf("Get() should fail because the history shouldn't be created when it's disabled")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := []v1alpha1.RecommendationHistoryEntry{
+		{
+			Time:          metav1.NewTime(now),
+			Target:        v1alpha1.HistoryTargetResourceRequest,
+			ContainerName: "app",
+			ResourceName:  corev1.ResourceCPU,
+			OldValue:      "100m",
+			NewValue:      "200m",
+			Reason:        "increased based on the recommendation",
+		},
+		entry(now, "2"),
+	}
+	buf := &bytes.Buffer{}
+	if err := Write(buf, entries, OutputFormatTable); err != nil {
+		t.Fatalf("Write() unexpected error = %v", err)
+	}
+	want := `TIME                  TARGET           CONTAINER  RESOURCE  OLD   NEW   REASON
+2023-01-10T12:00:00Z  ResourceRequest  app        cpu       100m  200m  increased based on the recommendation
+2023-01-10T12:00:00Z  HPAMinReplicas   -          -         1     2     -
+`
+	if d := cmp.Diff(want, buf.String()); d != "" {
+		t.Errorf("Write() diff = %s", d)
+	}
+}
@@ -0,0 +1,94 @@
+package hpa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+type fakeHistoryService struct {
+	entries []v1alpha1.RecommendationHistoryEntry
+}
+
+func (f *fakeHistoryService) Append(_ context.Context, _ *v1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, _ time.Time) error {
+	f.entries = append(f.entries, entries...)
+	return nil
+}
+
+func TestService_ChangeHPAFromTortoiseRecommendation_History(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		updateMode    v1beta3.UpdateMode
+		recordMetrics bool
+		want          []v1alpha1.RecommendationHistoryEntry
+	}{
+		{
+			name:          "applied changes are recorded",
+			updateMode:    v1beta3.UpdateModeAuto,
+			recordMetrics: true,
+			want: []v1alpha1.RecommendationHistoryEntry{
+				{
+					Time:          metav1.NewTime(now),
+					Target:        v1alpha1.HistoryTargetHPATargetUtilization,
+					ContainerName: "app",
+					ResourceName:  v1.ResourceCPU,
+					OldValue:      "60",
+					NewValue:      "80",
+					Reason:        "the target utilization recommendation is 80",
+				},
+				{
+					Time:     metav1.NewTime(now),
+					Target:   v1alpha1.HistoryTargetHPAMinReplicas,
+					OldValue: "2",
+					NewValue: "3",
+					Reason:   "the minReplicas recommendation for the current time slot",
+				},
+				{
+					Time:     metav1.NewTime(now),
+					Target:   v1alpha1.HistoryTargetHPAMaxReplicas,
+					OldValue: "20",
+					NewValue: "6",
+					Reason:   "the maxReplicas recommendation for the current time slot",
+				},
+			},
+		},
+		{
+			name:          "nothing is recorded in Off mode",
+			updateMode:    v1beta3.UpdateModeOff,
+			recordMetrics: true,
+		},
+		{
+			name:          "nothing is recorded when it's not the actual reconciliation (e.g., from the webhook)",
+			updateMode:    v1beta3.UpdateModeAuto,
+			recordMetrics: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &fakeHistoryService{}
+			s, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 50, time.Hour, nil, 1000, 10000, 3, "", defaultEmergencyModeGracePeriod, 0, nil, false, nil, nil, h)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal})
+			tortoise.Spec.UpdateMode = tt.updateMode
+
+			if _, _, err := s.ChangeHPAFromTortoiseRecommendation(context.Background(), tortoise, testKEDAHPA(), now, tt.recordMetrics); err != nil {
+				t.Fatalf("ChangeHPAFromTortoiseRecommendation() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, h.entries); d != "" {
+				t.Errorf("recorded history diff = %s", d)
+			}
+		})
+	}
+}
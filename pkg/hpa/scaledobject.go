@@ -0,0 +1,309 @@
+package hpa
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+)
+
+const (
+	// KEDA API group and version
+	KEDAAPIGroup   = "keda.sh"
+	KEDAAPIVersion = "v1alpha1"
+
+	// kedaHPANamePrefix is the prefix of the HPA name generated by KEDA, which is used when the ScaledObject doesn't specify the HPA name.
+	kedaHPANamePrefix = "keda-hpa-"
+	// kedaDefaultMaxReplicaCount is the default value of maxReplicaCount in KEDA.
+	kedaDefaultMaxReplicaCount = 100
+)
+
+// ScaledObjectGVK is the GroupVersionKind of KEDA ScaledObject.
+var ScaledObjectGVK = schema.GroupVersionKind{
+	Group:   KEDAAPIGroup,
+	Version: KEDAAPIVersion,
+	Kind:    "ScaledObject",
+}
+
+// usesScaledObject returns true if the tortoise manages the KEDA ScaledObject instead of HPA.
+func usesScaledObject(tortoise *autoscalingv1beta3.Tortoise) bool {
+	return tortoise.Spec.TargetRefs.ScaledObjectName != nil
+}
+
+func (c *Service) getScaledObject(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*unstructured.Unstructured, error) {
+	so := &unstructured.Unstructured{}
+	so.SetGroupVersionKind(ScaledObjectGVK)
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: *tortoise.Spec.TargetRefs.ScaledObjectName}, so); err != nil {
+		return nil, fmt.Errorf("failed to get scaledobject on tortoise: %w", err)
+	}
+	return so, nil
+}
+
+// getHPA returns the HPA managed by the tortoise.
+//
+// When the tortoise manages the ScaledObject, it returns the HPA converted from the ScaledObject
+// so that the rest of this service can handle it in the same way as HPA.
+// Its status is copied from the HPA generated by KEDA.
+func (c *Service) getHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v2.HorizontalPodAutoscaler, error) {
+	if !usesScaledObject(tortoise) {
+		hpa := &v2.HorizontalPodAutoscaler{}
+		if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Status.Targets.HorizontalPodAutoscaler}, hpa); err != nil {
+			return nil, err
+		}
+		return hpa, nil
+	}
+
+	so, err := c.getScaledObject(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	hpa, err := hpaFromScaledObject(so)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := &v2.HorizontalPodAutoscaler{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: hpa.Name}, generated); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get hpa generated by KEDA: %w", err)
+		}
+		// KEDA hasn't created HPA yet. The status is kept empty, and it's regarded as unready.
+		return hpa, nil
+	}
+	hpa.Status = generated.Status
+
+	return hpa, nil
+}
+
+// updateHPA updates the HPA managed by the tortoise.
+//
+// When the tortoise manages the ScaledObject, it writes the HPA (returned from getHPA) back to the ScaledObject,
+// and the HPA generated by KEDA isn't touched.
+func (c *Service) updateHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler) error {
+	if !usesScaledObject(tortoise) {
+		return c.c.Update(ctx, hpa)
+	}
+
+	so, err := c.getScaledObject(ctx, tortoise)
+	if err != nil {
+		return err
+	}
+	// Use the resourceVersion at the time of getHPA so that the conflict is detected.
+	so.SetResourceVersion(hpa.ResourceVersion)
+	if err := applyHPAToScaledObject(hpa, so); err != nil {
+		return err
+	}
+
+	return c.c.Update(ctx, so)
+}
+
+// scaledObjectHPAName returns the name of the HPA generated by KEDA for the ScaledObject.
+func scaledObjectHPAName(so *unstructured.Unstructured) string {
+	if name, _, _ := unstructured.NestedString(so.Object, "status", "hpaName"); name != "" {
+		return name
+	}
+	if name, _, _ := unstructured.NestedString(so.Object, "spec", "advanced", "horizontalPodAutoscalerConfig", "name"); name != "" {
+		return name
+	}
+	return kedaHPANamePrefix + so.GetName()
+}
+
+// isResourceUtilizationTrigger returns true if the trigger is the cpu/memory trigger with the Utilization metric type.
+// Such triggers are managed by tortoise.
+func isResourceUtilizationTrigger(trigger map[string]interface{}) bool {
+	t, _, _ := unstructured.NestedString(trigger, "type")
+	if t != string(corev1.ResourceCPU) && t != string(corev1.ResourceMemory) {
+		return false
+	}
+
+	metricType, _, _ := unstructured.NestedString(trigger, "metricType")
+	if metricType == "" {
+		// Deprecated way to specify the metric type.
+		metricType, _, _ = unstructured.NestedString(trigger, "metadata", "type")
+	}
+	return metricType == "" || metricType == string(v2.UtilizationMetricType)
+}
+
+// hpaFromScaledObject converts the ScaledObject to HPA.
+// Only the cpu/memory triggers with the Utilization metric type are converted to the metrics;
+// ContainerResource metrics for the triggers with containerName, and Resource metrics for the ones without containerName.
+func hpaFromScaledObject(so *unstructured.Unstructured) (*v2.HorizontalPodAutoscaler, error) {
+	hpa := &v2.HorizontalPodAutoscaler{}
+	hpa.Name = scaledObjectHPAName(so)
+	hpa.Namespace = so.GetNamespace()
+	hpa.ResourceVersion = so.GetResourceVersion()
+
+	minReplicas, found, err := unstructured.NestedInt64(so.Object, "spec", "minReplicaCount")
+	if err != nil {
+		return nil, fmt.Errorf("invalid minReplicaCount in scaledobject %s/%s: %w", so.GetNamespace(), so.GetName(), err)
+	}
+	if !found || minReplicas < 1 {
+		// KEDA sets 1 to minReplicas in HPA when minReplicaCount is 0 or not specified.
+		minReplicas = 1
+	}
+	hpa.Spec.MinReplicas = ptr.To(int32(minReplicas))
+
+	maxReplicas, found, err := unstructured.NestedInt64(so.Object, "spec", "maxReplicaCount")
+	if err != nil {
+		return nil, fmt.Errorf("invalid maxReplicaCount in scaledobject %s/%s: %w", so.GetNamespace(), so.GetName(), err)
+	}
+	if !found {
+		maxReplicas = kedaDefaultMaxReplicaCount
+	}
+	hpa.Spec.MaxReplicas = int32(maxReplicas)
+
+	triggers, _, err := unstructured.NestedSlice(so.Object, "spec", "triggers")
+	if err != nil {
+		return nil, fmt.Errorf("invalid triggers in scaledobject %s/%s: %w", so.GetNamespace(), so.GetName(), err)
+	}
+	for _, tr := range triggers {
+		trigger, ok := tr.(map[string]interface{})
+		if !ok || !isResourceUtilizationTrigger(trigger) {
+			continue
+		}
+
+		t, _, _ := unstructured.NestedString(trigger, "type")
+		value, _, _ := unstructured.NestedString(trigger, "metadata", "value")
+		utilization, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in the %s trigger in scaledobject %s/%s: %w", t, so.GetNamespace(), so.GetName(), err)
+		}
+		target := v2.MetricTarget{
+			Type:               v2.UtilizationMetricType,
+			AverageUtilization: ptr.To(int32(utilization)),
+		}
+
+		containerName, _, _ := unstructured.NestedString(trigger, "metadata", "containerName")
+		if containerName == "" {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, v2.MetricSpec{
+				Type: v2.ResourceMetricSourceType,
+				Resource: &v2.ResourceMetricSource{
+					Name:   corev1.ResourceName(t),
+					Target: target,
+				},
+			})
+			continue
+		}
+		hpa.Spec.Metrics = append(hpa.Spec.Metrics, v2.MetricSpec{
+			Type: v2.ContainerResourceMetricSourceType,
+			ContainerResource: &v2.ContainerResourceMetricSource{
+				Name:      corev1.ResourceName(t),
+				Container: containerName,
+				Target:    target,
+			},
+		})
+	}
+
+	behavior, found, err := unstructured.NestedMap(so.Object, "spec", "advanced", "horizontalPodAutoscalerConfig", "behavior")
+	if err != nil {
+		return nil, fmt.Errorf("invalid behavior in scaledobject %s/%s: %w", so.GetNamespace(), so.GetName(), err)
+	}
+	if found {
+		hpa.Spec.Behavior = &v2.HorizontalPodAutoscalerBehavior{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(behavior, hpa.Spec.Behavior); err != nil {
+			return nil, fmt.Errorf("invalid behavior in scaledobject %s/%s: %w", so.GetNamespace(), so.GetName(), err)
+		}
+	}
+
+	return hpa, nil
+}
+
+// applyHPAToScaledObject writes minReplicas, maxReplicas, behavior, and ContainerResource metrics in the HPA to the ScaledObject.
+// The cpu/memory triggers which don't have the corresponding ContainerResource metric in the HPA are removed,
+// and the other triggers are kept as they are.
+func applyHPAToScaledObject(hpa *v2.HorizontalPodAutoscaler, so *unstructured.Unstructured) error {
+	current, err := hpaFromScaledObject(so)
+	if err != nil {
+		return err
+	}
+
+	// Only the changed fields are written so that, for example, minReplicaCount: 0 isn't replaced with 1 when only metrics are changed.
+	if hpa.Spec.MinReplicas != nil && *hpa.Spec.MinReplicas != *current.Spec.MinReplicas {
+		if err := unstructured.SetNestedField(so.Object, int64(*hpa.Spec.MinReplicas), "spec", "minReplicaCount"); err != nil {
+			return fmt.Errorf("set minReplicaCount: %w", err)
+		}
+	}
+	if hpa.Spec.MaxReplicas != current.Spec.MaxReplicas {
+		if err := unstructured.SetNestedField(so.Object, int64(hpa.Spec.MaxReplicas), "spec", "maxReplicaCount"); err != nil {
+			return fmt.Errorf("set maxReplicaCount: %w", err)
+		}
+	}
+
+	if hpa.Spec.Behavior != nil && !reflect.DeepEqual(hpa.Spec.Behavior, current.Spec.Behavior) {
+		behavior, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hpa.Spec.Behavior)
+		if err != nil {
+			return fmt.Errorf("convert behavior: %w", err)
+		}
+		if err := unstructured.SetNestedMap(so.Object, behavior, "spec", "advanced", "horizontalPodAutoscalerConfig", "behavior"); err != nil {
+			return fmt.Errorf("set behavior: %w", err)
+		}
+	}
+
+	targets := map[resourceNameAndContainerName]int32{}
+	var order []resourceNameAndContainerName
+	for _, m := range hpa.Spec.Metrics {
+		if m.Type != v2.ContainerResourceMetricSourceType || m.ContainerResource == nil || m.ContainerResource.Target.AverageUtilization == nil {
+			continue
+		}
+		k := resourceNameAndContainerName{m.ContainerResource.Name, m.ContainerResource.Container}
+		targets[k] = *m.ContainerResource.Target.AverageUtilization
+		order = append(order, k)
+	}
+
+	triggers, _, err := unstructured.NestedSlice(so.Object, "spec", "triggers")
+	if err != nil {
+		return fmt.Errorf("invalid triggers in scaledobject %s/%s: %w", so.GetNamespace(), so.GetName(), err)
+	}
+	newTriggers := []interface{}{}
+	for _, tr := range triggers {
+		trigger, ok := tr.(map[string]interface{})
+		if !ok || !isResourceUtilizationTrigger(trigger) {
+			// not managed by tortoise.
+			newTriggers = append(newTriggers, tr)
+			continue
+		}
+
+		t, _, _ := unstructured.NestedString(trigger, "type")
+		containerName, _, _ := unstructured.NestedString(trigger, "metadata", "containerName")
+		k := resourceNameAndContainerName{corev1.ResourceName(t), containerName}
+		target, ok := targets[k]
+		if !ok {
+			// remove the trigger
+			continue
+		}
+		if err := unstructured.SetNestedField(trigger, strconv.Itoa(int(target)), "metadata", "value"); err != nil {
+			return fmt.Errorf("set the value in the %s trigger: %w", t, err)
+		}
+		newTriggers = append(newTriggers, trigger)
+		delete(targets, k)
+	}
+
+	// add triggers for the metrics which don't exist in the ScaledObject yet.
+	for _, k := range order {
+		target, ok := targets[k]
+		if !ok {
+			continue
+		}
+		newTriggers = append(newTriggers, map[string]interface{}{
+			"type":       string(k.rn),
+			"metricType": string(v2.UtilizationMetricType),
+			"metadata": map[string]interface{}{
+				"value":         strconv.Itoa(int(target)),
+				"containerName": k.containerName,
+			},
+		})
+	}
+
+	return unstructured.SetNestedSlice(so.Object, newTriggers, "spec", "triggers")
+}
@@ -0,0 +1,375 @@
+package hpa
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+)
+
+func testScaledObject() *unstructured.Unstructured {
+	so := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      "so",
+			"namespace": "default",
+		},
+		"spec": map[string]interface{}{
+			"scaleTargetRef":  map[string]interface{}{"name": "app"},
+			"minReplicaCount": int64(2),
+			"maxReplicaCount": int64(20),
+			"triggers": []interface{}{
+				map[string]interface{}{
+					"type":       "cpu",
+					"metricType": "Utilization",
+					"metadata":   map[string]interface{}{"value": "60", "containerName": "app"},
+				},
+				// cpu/memory trigger without containerName is removed like Resource metrics in HPA.
+				map[string]interface{}{
+					"type":       "memory",
+					"metricType": "Utilization",
+					"metadata":   map[string]interface{}{"value": "70"},
+				},
+				// The other triggers are kept.
+				map[string]interface{}{
+					"type":     "prometheus",
+					"metadata": map[string]interface{}{"serverAddress": "http://prometheus:9090", "query": "sum(rate(http_requests_total[1m]))", "threshold": "100"},
+				},
+			},
+		},
+	}}
+	so.SetGroupVersionKind(ScaledObjectGVK)
+	return so
+}
+
+// testKEDAHPA is the HPA generated by KEDA for testScaledObject.
+func testKEDAHPA() *v2.HorizontalPodAutoscaler {
+	return &v2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "keda-hpa-so", Namespace: "default"},
+		Spec: v2.HorizontalPodAutoscalerSpec{
+			MinReplicas: ptr.To[int32](2),
+			MaxReplicas: 20,
+			Metrics: []v2.MetricSpec{
+				{
+					Type: v2.ContainerResourceMetricSourceType,
+					ContainerResource: &v2.ContainerResourceMetricSource{
+						Name:      v1.ResourceCPU,
+						Container: "app",
+						Target:    v2.MetricTarget{Type: v2.UtilizationMetricType, AverageUtilization: ptr.To[int32](60)},
+					},
+				},
+			},
+		},
+		Status: v2.HorizontalPodAutoscalerStatus{
+			Conditions: []v2.HorizontalPodAutoscalerCondition{
+				{Type: "ScalingActive", Status: "True"},
+			},
+			CurrentMetrics: []v2.MetricStatus{
+				{
+					Type: v2.ContainerResourceMetricSourceType,
+					ContainerResource: &v2.ContainerResourceMetricStatus{
+						Name:      v1.ResourceCPU,
+						Container: "app",
+						Current:   v2.MetricValueStatus{AverageUtilization: ptr.To[int32](50)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testScaledObjectTortoise(now time.Time, policy map[v1.ResourceName]v1beta3.AutoscalingType) *v1beta3.Tortoise {
+	phases := map[v1.ResourceName]v1beta3.ResourcePhase{}
+	for rn := range policy {
+		phases[rn] = v1beta3.ResourcePhase{Phase: v1beta3.ContainerResourcePhaseWorking}
+	}
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode: v1beta3.UpdateModeAuto,
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef:   v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"},
+				ScaledObjectName: ptr.To("so"),
+			},
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: v1beta3.TortoisePhaseWorking,
+			AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{ContainerName: "app", Policy: policy},
+			},
+			ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+				{ContainerName: "app", ResourcePhases: phases},
+			},
+			Targets: v1beta3.TargetsStatus{
+				HorizontalPodAutoscaler: "keda-hpa-so",
+				ScaledObject:            "so",
+			},
+			Recommendations: v1beta3.Recommendations{
+				Horizontal: v1beta3.HorizontalRecommendations{
+					TargetUtilizations: []v1beta3.HPATargetUtilizationRecommendationPerContainer{
+						{ContainerName: "app", TargetUtilization: map[v1.ResourceName]int32{v1.ResourceCPU: 80}},
+					},
+					MaxReplicas: []v1beta3.ReplicasRecommendation{
+						{From: 0, To: 24, Value: 6, WeekDay: ptr.To(now.Weekday().String())},
+					},
+					MinReplicas: []v1beta3.ReplicasRecommendation{
+						{From: 0, To: 24, Value: 3, WeekDay: ptr.To(now.Weekday().String())},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newScaledObjectTestService(t *testing.T) (*Service, client.Client) {
+	t.Helper()
+	c := fake.NewClientBuilder().WithObjects(testScaledObject(), testKEDAHPA()).Build()
+	s, err := New(c, record.NewFakeRecorder(10), 0.95, 90, 50, time.Hour, nil, 1000, 10000, 3, "", defaultEmergencyModeGracePeriod, 0, nil, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s, c
+}
+
+func getTestScaledObject(t *testing.T, c client.Client) *unstructured.Unstructured {
+	t.Helper()
+	so := &unstructured.Unstructured{}
+	so.SetGroupVersionKind(ScaledObjectGVK)
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "so"}, so); err != nil {
+		t.Fatalf("failed to get scaledobject: %v", err)
+	}
+	return so
+}
+
+func TestService_InitializeHPA_ScaledObject(t *testing.T) {
+	s, c := newScaledObjectTestService(t)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal})
+	tortoise.Status.Targets = v1beta3.TargetsStatus{}
+
+	got, err := s.InitializeHPA(context.Background(), tortoise, 4, now)
+	if err != nil {
+		t.Fatalf("InitializeHPA() error = %v", err)
+	}
+	if d := cmp.Diff(v1beta3.TargetsStatus{HorizontalPodAutoscaler: "keda-hpa-so", ScaledObject: "so"}, got.Status.Targets); d != "" {
+		t.Errorf("InitializeHPA() targets diff = %s", d)
+	}
+
+	hpas := &v2.HorizontalPodAutoscalerList{}
+	if err := c.List(context.Background(), hpas); err != nil {
+		t.Fatal(err)
+	}
+	if len(hpas.Items) != 1 {
+		t.Errorf("InitializeHPA() shouldn't create HPA, got %d HPAs", len(hpas.Items))
+	}
+}
+
+func TestService_UpdateHPAFromTortoiseRecommendation_ScaledObject(t *testing.T) {
+	s, c := newScaledObjectTestService(t)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal})
+
+	if _, _, err := s.UpdateHPAFromTortoiseRecommendation(context.Background(), tortoise, now); err != nil {
+		t.Fatalf("UpdateHPAFromTortoiseRecommendation() error = %v", err)
+	}
+
+	so := getTestScaledObject(t, c)
+	spec := so.Object["spec"].(map[string]interface{})
+	if d := cmp.Diff(int64(3), spec["minReplicaCount"]); d != "" {
+		t.Errorf("minReplicaCount diff = %s", d)
+	}
+	if d := cmp.Diff(int64(6), spec["maxReplicaCount"]); d != "" {
+		t.Errorf("maxReplicaCount diff = %s", d)
+	}
+	wantTriggers := []interface{}{
+		map[string]interface{}{
+			"type":       "cpu",
+			"metricType": "Utilization",
+			"metadata":   map[string]interface{}{"value": "80", "containerName": "app"},
+		},
+		map[string]interface{}{
+			"type":     "prometheus",
+			"metadata": map[string]interface{}{"serverAddress": "http://prometheus:9090", "query": "sum(rate(http_requests_total[1m]))", "threshold": "100"},
+		},
+	}
+	if d := cmp.Diff(wantTriggers, spec["triggers"]); d != "" {
+		t.Errorf("triggers diff = %s", d)
+	}
+	if _, found, _ := unstructured.NestedMap(so.Object, "spec", "advanced", "horizontalPodAutoscalerConfig", "behavior"); !found {
+		t.Errorf("behavior should be set in the scaledobject")
+	}
+
+	// The HPA generated by KEDA should be left alone.
+	hpa := &v2.HorizontalPodAutoscaler{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "keda-hpa-so"}, hpa); err != nil {
+		t.Fatal(err)
+	}
+	if d := cmp.Diff(testKEDAHPA().Spec, hpa.Spec); d != "" {
+		t.Errorf("the HPA generated by KEDA shouldn't be changed: diff = %s", d)
+	}
+}
+
+func TestService_UpdateHPAFromTortoiseRecommendation_ScaledObject_MetricTarget(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(testScaledObject(), testKEDAHPA()).Build()
+	recorder := record.NewFakeRecorder(10)
+	s, err := New(c, recorder, 0.95, 90, 50, time.Hour, nil, 1000, 10000, 3, "", defaultEmergencyModeGracePeriod, 0, nil, false, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal})
+	tortoise.Status.Recommendations.Horizontal.MetricTargets = []v1beta3.HPAMetricTargetRecommendation{
+		{Type: v2.ExternalMetricSourceType, MetricName: "s0-prometheus", Target: resource.MustParse("50")},
+	}
+
+	if _, _, err := s.UpdateHPAFromTortoiseRecommendation(context.Background(), tortoise, now); err != nil {
+		t.Fatalf("UpdateHPAFromTortoiseRecommendation() error = %v", err)
+	}
+
+	// The prometheus trigger should be left alone.
+	triggers, _, _ := unstructured.NestedSlice(getTestScaledObject(t, c).Object, "spec", "triggers")
+	want := map[string]interface{}{
+		"type":     "prometheus",
+		"metadata": map[string]interface{}{"serverAddress": "http://prometheus:9090", "query": "sum(rate(http_requests_total[1m]))", "threshold": "100"},
+	}
+	if d := cmp.Diff(want, triggers[len(triggers)-1]); d != "" {
+		t.Errorf("the prometheus trigger shouldn't be changed: diff = %s", d)
+	}
+
+	found := false
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, event.WarningMetricTargetNotApplied) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("the %s event should be recorded", event.WarningMetricTargetNotApplied)
+	}
+}
+
+func TestService_UpdateHPASpecFromTortoiseAutoscalingPolicy_ScaledObject(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("add the trigger for the new horizontal resource", func(t *testing.T) {
+		s, c := newScaledObjectTestService(t)
+		tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{
+			v1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+			v1.ResourceMemory: v1beta3.AutoscalingTypeHorizontal,
+		})
+
+		if _, err := s.UpdateHPASpecFromTortoiseAutoscalingPolicy(context.Background(), tortoise, nil, 4, now); err != nil {
+			t.Fatalf("UpdateHPASpecFromTortoiseAutoscalingPolicy() error = %v", err)
+		}
+
+		so := getTestScaledObject(t, c)
+		spec := so.Object["spec"].(map[string]interface{})
+		wantTriggers := []interface{}{
+			map[string]interface{}{
+				"type":       "cpu",
+				"metricType": "Utilization",
+				"metadata":   map[string]interface{}{"value": "60", "containerName": "app"},
+			},
+			map[string]interface{}{
+				"type":     "prometheus",
+				"metadata": map[string]interface{}{"serverAddress": "http://prometheus:9090", "query": "sum(rate(http_requests_total[1m]))", "threshold": "100"},
+			},
+			map[string]interface{}{
+				"type":       "memory",
+				"metricType": "Utilization",
+				"metadata":   map[string]interface{}{"value": "70", "containerName": "app"},
+			},
+		}
+		if d := cmp.Diff(wantTriggers, spec["triggers"]); d != "" {
+			t.Errorf("triggers diff = %s", d)
+		}
+		// replica counts aren't changed by the policy change.
+		if d := cmp.Diff(int64(2), spec["minReplicaCount"]); d != "" {
+			t.Errorf("minReplicaCount diff = %s", d)
+		}
+	})
+
+	t.Run("disable the scaledobject when no horizontal resource", func(t *testing.T) {
+		s, c := newScaledObjectTestService(t)
+		tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{
+			v1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+			v1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+		})
+
+		if _, err := s.UpdateHPASpecFromTortoiseAutoscalingPolicy(context.Background(), tortoise, nil, 4, now); err != nil {
+			t.Fatalf("UpdateHPASpecFromTortoiseAutoscalingPolicy() error = %v", err)
+		}
+
+		so := getTestScaledObject(t, c)
+		spec := so.Object["spec"].(map[string]interface{})
+		if d := cmp.Diff(int64(4), spec["minReplicaCount"]); d != "" {
+			t.Errorf("minReplicaCount diff = %s", d)
+		}
+		if d := cmp.Diff(int64(4), spec["maxReplicaCount"]); d != "" {
+			t.Errorf("maxReplicaCount diff = %s", d)
+		}
+		wantTriggers := []interface{}{
+			map[string]interface{}{
+				"type":     "prometheus",
+				"metadata": map[string]interface{}{"serverAddress": "http://prometheus:9090", "query": "sum(rate(http_requests_total[1m]))", "threshold": "100"},
+			},
+		}
+		if d := cmp.Diff(wantTriggers, spec["triggers"]); d != "" {
+			t.Errorf("triggers diff = %s", d)
+		}
+	})
+}
+
+func TestService_GetHPAOnTortoise_ScaledObject(t *testing.T) {
+	s, _ := newScaledObjectTestService(t)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tortoise := testScaledObjectTortoise(now, map[v1.ResourceName]v1beta3.AutoscalingType{v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal})
+
+	got, ready, err := s.GetHPAOnTortoise(context.Background(), tortoise)
+	if err != nil {
+		t.Fatalf("GetHPAOnTortoise() error = %v", err)
+	}
+	if !ready {
+		t.Fatalf("GetHPAOnTortoise() should be ready")
+	}
+	want := &v2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "keda-hpa-so", Namespace: "default"},
+		Spec: v2.HorizontalPodAutoscalerSpec{
+			MinReplicas: ptr.To[int32](2),
+			MaxReplicas: 20,
+			Metrics: []v2.MetricSpec{
+				{
+					Type: v2.ContainerResourceMetricSourceType,
+					ContainerResource: &v2.ContainerResourceMetricSource{
+						Name:      v1.ResourceCPU,
+						Container: "app",
+						Target:    v2.MetricTarget{Type: v2.UtilizationMetricType, AverageUtilization: ptr.To[int32](60)},
+					},
+				},
+				{
+					Type: v2.ResourceMetricSourceType,
+					Resource: &v2.ResourceMetricSource{
+						Name:   v1.ResourceMemory,
+						Target: v2.MetricTarget{Type: v2.UtilizationMetricType, AverageUtilization: ptr.To[int32](70)},
+					},
+				},
+			},
+		},
+		Status: testKEDAHPA().Status,
+	}
+	if d := cmp.Diff(want, got, cmp.FilterPath(func(p cmp.Path) bool { return p.String() == "ObjectMeta.ResourceVersion" }, cmp.Ignore())); d != "" {
+		t.Errorf("GetHPAOnTortoise() diff = %s", d)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	v2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -23,7 +24,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/calendar"
 	"github.com/mercari/tortoise/pkg/event"
 	"github.com/mercari/tortoise/pkg/metrics"
 	"github.com/mercari/tortoise/pkg/utils"
@@ -43,9 +46,13 @@ type Service struct {
 	maximumMaxReplica                          int32
 	externalMetricExclusionRegex               *regexp.Regexp
 	emergencyModeGracePeriod                   time.Duration
-	globalDisableMode                          bool
-	excludedNamespaces                         sets.Set[string]
-	scaleopsService                            ScaleOpsService
+	minReplicasLeadTime                        time.Duration
+	// calendar is used to find the recommendation for the special days, such as public holidays.
+	calendar           *calendar.Calendar
+	globalDisableMode  bool
+	excludedNamespaces sets.Set[string]
+	scaleopsService    ScaleOpsService
+	historyService     HistoryService
 }
 
 // ScaleOpsService interface for ScaleOps detection
@@ -53,6 +60,11 @@ type ScaleOpsService interface {
 	IsScaleOpsManaged(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (bool, string, error)
 }
 
+// HistoryService records the changes applied by tortoise.
+type HistoryService interface {
+	Append(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) error
+}
+
 var defaultHPABehaviorValue = &v2.HorizontalPodAutoscalerBehavior{
 	ScaleUp: &v2.HPAScalingRules{
 		Policies: []v2.HPAScalingPolicy{
@@ -86,9 +98,12 @@ func New(
 	minimumMinReplicas int32,
 	externalMetricExclusionRegex string,
 	emergencyModeGracePeriod time.Duration,
+	minReplicasLeadTime time.Duration,
+	cal *calendar.Calendar,
 	globalDisableMode bool,
 	excludedNamespaces []string,
 	scaleopsService ScaleOpsService,
+	historyService HistoryService,
 ) (*Service, error) {
 	var regex *regexp.Regexp
 	if externalMetricExclusionRegex != "" {
@@ -120,20 +135,45 @@ func New(
 		maximumMaxReplica:                          maximumMaxReplica,
 		externalMetricExclusionRegex:               regex,
 		emergencyModeGracePeriod:                   emergencyModeGracePeriod,
+		minReplicasLeadTime:                        minReplicasLeadTime,
+		calendar:                                   cal,
 		globalDisableMode:                          globalDisableMode,
 		excludedNamespaces:                         sets.New(excludedNamespaces...),
 		scaleopsService:                            scaleopsService,
+		historyService:                             historyService,
 	}, nil
 }
 
+// minimumMinReplicasFor returns the minimum minReplicas for the tortoise, which may be overridden by .spec.recommenderOverrides.
+func (c *Service) minimumMinReplicasFor(tortoise *autoscalingv1beta3.Tortoise) int32 {
+	if o := tortoise.Spec.RecommenderOverrides; o != nil && o.MinimumMinReplicas != nil {
+		return *o.MinimumMinReplicas
+	}
+	return c.minimumMinReplicas
+}
+
 func (c *Service) InitializeHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, replicaNum int32, now time.Time) (*autoscalingv1beta3.Tortoise, error) {
 	logger := log.FromContext(ctx)
 	// if all policy is off or Vertical, we don't need HPA.
-	if !HasHorizontal(tortoise) && tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
+	if !HasHorizontal(tortoise) && tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil && !usesScaledObject(tortoise) {
 		logger.Info("no horizontal policy, no need to create HPA")
 		return tortoise, nil
 	}
 
+	if usesScaledObject(tortoise) {
+		logger.Info("user specified the existing ScaledObject, no need to create HPA")
+
+		so, err := c.getScaledObject(ctx, tortoise)
+		if err != nil {
+			return tortoise, err
+		}
+		tortoise.Status.Targets.ScaledObject = so.GetName()
+		// The HPA is generated by KEDA, and tortoise only reads its status.
+		tortoise.Status.Targets.HorizontalPodAutoscaler = scaledObjectHPAName(so)
+
+		return tortoise, nil
+	}
+
 	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName != nil {
 		logger.Info("user specified the existing HPA, no need to create HPA")
 
@@ -160,6 +200,10 @@ func (c *Service) DeleteHPACreatedByTortoise(ctx context.Context, tortoise *auto
 		// The user specified the existing HPA, so we shouldn't delete it.
 		return nil
 	}
+	if usesScaledObject(tortoise) {
+		// The HPA is owned by the ScaledObject, which the user specified.
+		return nil
+	}
 	if tortoise.Spec.DeletionPolicy == autoscalingv1beta3.DeletionPolicyNoDelete {
 		// A user specified the existing HPA and tortoise didn't create HPA by itself.
 		return nil
@@ -305,8 +349,8 @@ func (c *Service) CreateHPA(ctx context.Context, tortoise *autoscalingv1beta3.To
 		// no need to create HPA
 		return nil, tortoise, nil
 	}
-	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName != nil {
-		// we don't have to create HPA as the user specified the existing HPA.
+	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName != nil || usesScaledObject(tortoise) {
+		// we don't have to create HPA as the user specified the existing HPA or ScaledObject.
 		return nil, tortoise, nil
 	}
 
@@ -325,7 +369,7 @@ func (c *Service) CreateHPA(ctx context.Context, tortoise *autoscalingv1beta3.To
 				Name:       tortoise.Spec.TargetRefs.ScaleTargetRef.Name,
 				APIVersion: tortoise.Spec.TargetRefs.ScaleTargetRef.APIVersion,
 			},
-			MinReplicas: ptr.To[int32](c.minimumMinReplicas),
+			MinReplicas: ptr.To[int32](c.minimumMinReplicasFor(tortoise)),
 			MaxReplicas: c.maximumMaxReplica,
 			Behavior:    behavior,
 		},
@@ -340,6 +384,13 @@ func (c *Service) CreateHPA(ctx context.Context, tortoise *autoscalingv1beta3.To
 }
 
 func (c *Service) GetHPAOnTortoiseSpec(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v2.HorizontalPodAutoscaler, error) {
+	if usesScaledObject(tortoise) {
+		so, err := c.getScaledObject(ctx, tortoise)
+		if err != nil {
+			return nil, err
+		}
+		return hpaFromScaledObject(so)
+	}
 	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
 		return nil, nil
 	}
@@ -355,8 +406,8 @@ func (c *Service) GetHPAOnTortoise(ctx context.Context, tortoise *autoscalingv1b
 		// there should be no HPA
 		return nil, true, nil
 	}
-	hpa := &v2.HorizontalPodAutoscaler{}
-	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Status.Targets.HorizontalPodAutoscaler}, hpa); err != nil {
+	hpa, err := c.getHPA(ctx, tortoise)
+	if err != nil {
 		return nil, false, fmt.Errorf("failed to get hpa on tortoise: %w", err)
 	}
 	if reflect.DeepEqual(hpa.Status, v2.HorizontalPodAutoscalerStatus{}) || hpa.Status.Conditions == nil || hpa.Status.CurrentMetrics == nil {
@@ -433,6 +484,9 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(ctx context.Context, torto
 	var allowed bool
 	tortoise, allowed = c.UpdatingHPATargetUtilizationAllowed(tortoise, now)
 	disabled, _ := c.IsChangeApplicationDisabled(ctx, tortoise)
+	// We record the history only when the change is actually applied, in the same way as applied* metrics.
+	recordHistory := tortoise.Spec.UpdateMode != autoscalingv1beta3.UpdateModeOff && !disabled && recordMetrics
+	var historyEntries []v1alpha1.RecommendationHistoryEntry
 	for _, t := range tortoise.Status.Recommendations.Horizontal.TargetUtilizations {
 		for resourcename, proposedTarget := range t.TargetUtilization {
 			if !readyHorizontalResourceAndContainer.Has(resourceNameAndContainerName{resourcename, t.ContainerName}) {
@@ -451,17 +505,62 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(ctx context.Context, torto
 				metrics.AppliedHPATargetUtilization.WithLabelValues(tortoise.Name, tortoise.Namespace, t.ContainerName, resourcename.String(), hpa.Name).Set(float64(proposedTarget))
 			}
 
+			oldTarget, _ := GetHPATargetValue(ctx, hpa, t.ContainerName, resourcename)
 			if err := c.updateHPATargetValue(hpa, t.ContainerName, resourcename, proposedTarget); err != nil {
 				return nil, tortoise, fmt.Errorf("update HPA from the recommendation from tortoise")
 			}
+			if newTarget, _ := GetHPATargetValue(ctx, hpa, t.ContainerName, resourcename); recordHistory && newTarget != oldTarget {
+				historyEntries = append(historyEntries, v1alpha1.RecommendationHistoryEntry{
+					Time:          metav1.NewTime(now),
+					Target:        v1alpha1.HistoryTargetHPATargetUtilization,
+					ContainerName: t.ContainerName,
+					ResourceName:  resourcename,
+					OldValue:      fmt.Sprintf("%d", oldTarget),
+					NewValue:      fmt.Sprintf("%d", newTarget),
+					Reason:        fmt.Sprintf("the target utilization recommendation is %d", proposedTarget),
+				})
+			}
+
+		}
+	}
+	for _, r := range tortoise.Status.Recommendations.Horizontal.MetricTargets {
+		if !allowed {
+			// we don't want to update the HPA too frequently.
+			continue
+		}
+		if usesScaledObject(tortoise) {
+			// The Pods and External metrics in the HPA generated by KEDA come from the triggers (e.g., prometheus),
+			// and how the target is written in the trigger metadata depends on the trigger type.
+			if recordMetrics {
+				c.recorder.Event(tortoise, corev1.EventTypeWarning, event.WarningMetricTargetNotApplied, fmt.Sprintf("The target recommendation of the %s metric %s isn't applied because Tortoise doesn't support tuning the targets of the triggers other than cpu/memory in ScaledObject", r.Type, r.MetricName))
+			}
+			continue
+		}
 
+		oldTarget, ok := GetHPAMetricTarget(hpa, r.Type, r.MetricName)
+		if !ok {
+			// The metric is removed from the HPA.
+			continue
+		}
+		if err := c.updateHPAMetricTarget(hpa, r.Type, r.MetricName, r.Target); err != nil {
+			return nil, tortoise, fmt.Errorf("update HPA from the recommendation from tortoise: %w", err)
+		}
+		if newTarget, _ := GetHPAMetricTarget(hpa, r.Type, r.MetricName); recordHistory && newTarget.Cmp(oldTarget) != 0 {
+			historyEntries = append(historyEntries, v1alpha1.RecommendationHistoryEntry{
+				Time:       metav1.NewTime(now),
+				Target:     v1alpha1.HistoryTargetHPAMetricTarget,
+				MetricName: r.MetricName,
+				OldValue:   oldTarget.String(),
+				NewValue:   newTarget.String(),
+				Reason:     fmt.Sprintf("the target recommendation of the %s metric is %s", r.Type, r.Target.String()),
+			})
 		}
 	}
 	if allowed && tortoise.Spec.UpdateMode != autoscalingv1beta3.UpdateModeOff && !disabled {
 		tortoise = c.RecordHPATargetUtilizationUpdate(tortoise, now)
 	}
 
-	recommendMax, err := GetReplicasRecommendation(tortoise.Status.Recommendations.Horizontal.MaxReplicas, now)
+	recommendMax, err := GetReplicasRecommendation(tortoise.Status.Recommendations.Horizontal.MaxReplicas, now, c.calendar)
 	if err != nil {
 		return nil, tortoise, fmt.Errorf("get maxReplicas recommendation: %w", err)
 	}
@@ -475,16 +574,42 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(ctx context.Context, torto
 		recommendMax = c.maximumMaxReplica
 	}
 
+	oldMax := hpa.Spec.MaxReplicas
+	oldMin := hpa.Spec.MinReplicas
 	hpa.Spec.MaxReplicas = recommendMax
 
-	recommendMin, err := GetReplicasRecommendation(tortoise.Status.Recommendations.Horizontal.MinReplicas, now)
+	recommendMin, err := GetReplicasRecommendation(tortoise.Status.Recommendations.Horizontal.MinReplicas, now, c.calendar)
 	if err != nil {
 		return nil, tortoise, fmt.Errorf("get minReplicas recommendation: %w", err)
 	}
+	minReason := "the minReplicas recommendation for the current time slot"
+	if c.minReplicasLeadTime > 0 {
+		if ahead := getReplicasRecommendationAhead(tortoise.Status.Recommendations.Horizontal.MinReplicas, now, c.minReplicasLeadTime, c.calendar); ahead > recommendMin {
+			// Raise minReplicas before the traffic ramps up in the next time slot.
+			recommendMin = ahead
+			minReason = fmt.Sprintf("the minReplicas recommendation for the time slot starting within %s", c.minReplicasLeadTime)
+		}
+	}
+	if constraints := tortoise.Status.Recommendations.Constraints; constraints != nil && constraints.MinimumMinReplicas != nil && recommendMin < *constraints.MinimumMinReplicas {
+		// The constraints (e.g., from ScheduledScaling) can only raise minReplicas.
+		recommendMin = *constraints.MinimumMinReplicas
+		minReason = "the minimumMinReplicas constraint (e.g., from ScheduledScaling)"
+	}
+	if tortoise.Spec.MaxReplicas != nil && recommendMin > *tortoise.Spec.MaxReplicas {
+		// The minReplicas raised above (the lead time or the constraints) must not exceed the maxReplicas set by the user.
+		recommendMin = *tortoise.Spec.MaxReplicas
+	}
 	if recommendMin > c.maximumMinReplica {
 		recommendMin = c.maximumMinReplica
 		// We don't change the maxReplica because it's dangerous to limit.
 	}
+	if recommendMax < recommendMin {
+		// maxReplicas must not be smaller than minReplicas.
+		// It happens when minReplicas is raised by the lead time or the constraints,
+		// while the maxReplicas recommendation is still the one for the current time slot.
+		recommendMax = min(recommendMin, c.maximumMaxReplica)
+		hpa.Spec.MaxReplicas = recommendMax
+	}
 
 	if recordMetrics {
 		metrics.ProposedHPAMinReplicas.WithLabelValues(tortoise.Name, tortoise.Namespace, hpa.Name).Set(float64(recommendMin))
@@ -497,6 +622,7 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(ctx context.Context, torto
 	case autoscalingv1beta3.TortoisePhaseEmergency:
 		// when emergency mode, we set the same value on minReplicas.
 		minToActuallyApply = recommendMax
+		minReason = "emergency mode: minReplicas is set to the maxReplicas recommendation"
 	case autoscalingv1beta3.TortoisePhaseBackToNormal:
 		// gradually reduce the minReplicas.
 		currentMin := *hpa.Spec.MinReplicas
@@ -508,6 +634,7 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(ctx context.Context, torto
 			c.recorder.Event(tortoise, corev1.EventTypeNormal, event.Working, fmt.Sprintf("Tortoise %s/%s is working %v", tortoise.Namespace, tortoise.Name, currentMin))
 		} else {
 			minToActuallyApply = reduced
+			minReason = "back to normal: minReplicas is gradually reduced"
 		}
 	default:
 		minToActuallyApply = recommendMin
@@ -530,11 +657,64 @@ func (c *Service) ChangeHPAFromTortoiseRecommendation(ctx context.Context, torto
 		metrics.AppliedHPAMaxReplicas.WithLabelValues(tortoise.Name, tortoise.Namespace, hpa.Name).Set(float64(hpa.Spec.MaxReplicas))
 	}
 
+	if recordHistory {
+		if oldMin == nil || *oldMin != *hpa.Spec.MinReplicas {
+			e := v1alpha1.RecommendationHistoryEntry{
+				Time:     metav1.NewTime(now),
+				Target:   v1alpha1.HistoryTargetHPAMinReplicas,
+				NewValue: fmt.Sprintf("%d", *hpa.Spec.MinReplicas),
+				Reason:   minReason,
+			}
+			if oldMin != nil {
+				e.OldValue = fmt.Sprintf("%d", *oldMin)
+			}
+			historyEntries = append(historyEntries, e)
+		}
+		if oldMax != hpa.Spec.MaxReplicas {
+			historyEntries = append(historyEntries, v1alpha1.RecommendationHistoryEntry{
+				Time:     metav1.NewTime(now),
+				Target:   v1alpha1.HistoryTargetHPAMaxReplicas,
+				OldValue: fmt.Sprintf("%d", oldMax),
+				NewValue: fmt.Sprintf("%d", hpa.Spec.MaxReplicas),
+				Reason:   "the maxReplicas recommendation for the current time slot",
+			})
+		}
+		c.appendHistory(ctx, tortoise, historyEntries, now)
+	}
+
 	return hpa, tortoise, nil
 }
 
+// appendHistory records the entries in the recommendation history.
+// It only logs the error because failing to record the history shouldn't block the reconciliation.
+func (c *Service) appendHistory(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) {
+	if c.historyService == nil || len(entries) == 0 {
+		return
+	}
+	if err := c.historyService.Append(ctx, tortoise, entries, now); err != nil {
+		log.FromContext(ctx).Error(err, "failed to record the recommendation history", "tortoise", klog.KObj(tortoise))
+	}
+}
+
 // disableHPA disables the HPA created by users without removing it, by removing all metrics and setting the minReplicas to the specified value.
+// When the tortoise manages the ScaledObject, the cpu/memory triggers are removed from the ScaledObject instead.
 func (c *Service) disableHPA(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, replicaNum int32) error {
+	if usesScaledObject(tortoise) {
+		updateFn := func() error {
+			hpa, err := c.getHPA(ctx, tortoise)
+			if err != nil {
+				return fmt.Errorf("failed to get hpa on tortoise: %w", err)
+			}
+
+			hpa.Spec.Metrics = nil
+			hpa.Spec.MaxReplicas = replicaNum
+			hpa.Spec.MinReplicas = ptr.To(replicaNum)
+
+			return c.updateHPA(ctx, tortoise, hpa)
+		}
+
+		return retry.RetryOnConflict(retry.DefaultRetry, updateFn)
+	}
 	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
 		// nothing to do.
 		return nil
@@ -574,7 +754,7 @@ func (c *Service) UpdateHPASpecFromTortoiseAutoscalingPolicy(
 	}
 
 	if !HasHorizontal(tortoise) {
-		if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil {
+		if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName == nil && !usesScaledObject(tortoise) {
 			// HPA should be created by Tortoise, which can be deleted.
 			err := c.DeleteHPACreatedByTortoise(ctx, tortoise)
 			if err != nil && !apierrors.IsNotFound(err) {
@@ -582,12 +762,12 @@ func (c *Service) UpdateHPASpecFromTortoiseAutoscalingPolicy(
 			}
 			c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPADeleted, fmt.Sprintf("Deleted a HPA %s/%s because tortoise has no resource to scale horizontally", tortoise.Namespace, tortoise.Status.Targets.HorizontalPodAutoscaler))
 		} else {
-			// We cannot delete the HPA because it's specified by the user.
+			// We cannot delete the HPA (or ScaledObject) because it's specified by the user.
 			err := c.disableHPA(ctx, tortoise, replicaNum)
 			if err != nil {
 				return tortoise, fmt.Errorf("disable hpa: %w", err)
 			}
-			c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPADisabled, fmt.Sprintf("Disabled a HPA %s/%s because tortoise has no resource to scale horizontally", tortoise.Namespace, tortoise.Status.Targets.HorizontalPodAutoscaler))
+			c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPADisabled, fmt.Sprintf("Disabled a %s because tortoise has no resource to scale horizontally", horizontalTargetDescription(tortoise)))
 		}
 
 		// No need to edit container resource phase.
@@ -595,9 +775,9 @@ func (c *Service) UpdateHPASpecFromTortoiseAutoscalingPolicy(
 		return tortoise, nil
 	}
 
-	hpa := &v2.HorizontalPodAutoscaler{}
-	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Status.Targets.HorizontalPodAutoscaler}, hpa); err != nil {
-		if apierrors.IsNotFound(err) {
+	hpa, err := c.getHPA(ctx, tortoise)
+	if err != nil {
+		if apierrors.IsNotFound(err) && !usesScaledObject(tortoise) {
 			// If not found, it's one of:
 			// - the user didn't specify Horizontal in any autoscalingPolicy previously,
 			//   but just updated tortoise to have Horizontal in some.
@@ -625,8 +805,8 @@ func (c *Service) UpdateHPASpecFromTortoiseAutoscalingPolicy(
 	retryNumber := -1
 	updateFn := func() error {
 		retryNumber++
-		hpa := &v2.HorizontalPodAutoscaler{}
-		if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Status.Targets.HorizontalPodAutoscaler}, hpa); err != nil {
+		hpa, err := c.getHPA(ctx, tortoise)
+		if err != nil {
 			return fmt.Errorf("failed to get hpa on tortoise: %w", err)
 		}
 
@@ -634,18 +814,26 @@ func (c *Service) UpdateHPASpecFromTortoiseAutoscalingPolicy(
 		// update only metrics
 		hpa.Spec.Metrics = newhpa.Spec.Metrics
 
-		return c.c.Update(ctx, hpa)
+		return c.updateHPA(ctx, tortoise, hpa)
 	}
 
 	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
 		return tortoise, fmt.Errorf("update hpa: %w (%v times retried)", err, replicaNum)
 	}
 
-	c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPAUpdated, fmt.Sprintf("Updated a HPA %s/%s because the autoscaling policy is changed in the tortoise", tortoise.Namespace, tortoise.Status.Targets.HorizontalPodAutoscaler))
+	c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPAUpdated, fmt.Sprintf("Updated a %s because the autoscaling policy is changed in the tortoise", horizontalTargetDescription(tortoise)))
 
 	return tortoise, nil
 }
 
+// horizontalTargetDescription returns the description of the HPA (or ScaledObject) managed by the tortoise, which is used in events.
+func horizontalTargetDescription(tortoise *autoscalingv1beta3.Tortoise) string {
+	if usesScaledObject(tortoise) {
+		return fmt.Sprintf("ScaledObject %s/%s", tortoise.Namespace, *tortoise.Spec.TargetRefs.ScaledObjectName)
+	}
+	return fmt.Sprintf("HPA %s/%s", tortoise.Namespace, tortoise.Status.Targets.HorizontalPodAutoscaler)
+}
+
 func HasHorizontal(tortoise *autoscalingv1beta3.Tortoise) bool {
 	for _, r := range tortoise.Status.AutoscalingPolicy {
 		for _, p := range r.Policy {
@@ -675,8 +863,8 @@ func (c *Service) UpdateHPAFromTortoiseRecommendation(ctx context.Context, torto
 	// we only want to record metric once in every reconcile loop.
 	metricsRecorded := false
 	updateFn := func() error {
-		hpa := &v2.HorizontalPodAutoscaler{}
-		if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Status.Targets.HorizontalPodAutoscaler}, hpa); err != nil {
+		hpa, err := c.getHPA(ctx, tortoise)
+		if err != nil {
 			return fmt.Errorf("failed to get hpa on tortoise: %w", err)
 		}
 		retHPA = hpa.DeepCopy()
@@ -700,7 +888,7 @@ func (c *Service) UpdateHPAFromTortoiseRecommendation(ctx context.Context, torto
 
 		hpa = c.excludeExternalMetric(ctx, hpa)
 		retHPA = hpa
-		return c.c.Update(ctx, hpa)
+		return c.updateHPA(ctx, tortoise, hpa)
 	}
 
 	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
@@ -709,14 +897,19 @@ func (c *Service) UpdateHPAFromTortoiseRecommendation(ctx context.Context, torto
 
 	disabled, _ := c.IsChangeApplicationDisabled(ctx, tortoise)
 	if tortoise.Spec.UpdateMode != autoscalingv1beta3.UpdateModeOff && !disabled {
-		c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPAUpdated, fmt.Sprintf("HPA %s/%s is updated by the recommendation", retHPA.Namespace, retHPA.Name))
+		if usesScaledObject(tortoise) {
+			c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPAUpdated, fmt.Sprintf("ScaledObject %s/%s is updated by the recommendation", tortoise.Namespace, *tortoise.Spec.TargetRefs.ScaledObjectName))
+		} else {
+			c.recorder.Event(tortoise, corev1.EventTypeNormal, event.HPAUpdated, fmt.Sprintf("HPA %s/%s is updated by the recommendation", retHPA.Namespace, retHPA.Name))
+		}
 	}
 
 	return retHPA, retTortoise, nil
 }
 
 // GetReplicasRecommendation finds the corresponding recommendations.
-func GetReplicasRecommendation(recommendations []autoscalingv1beta3.ReplicasRecommendation, now time.Time) (int32, error) {
+// On the special days in the calendar, the recommendation of the day of the week specified in the calendar is used.
+func GetReplicasRecommendation(recommendations []autoscalingv1beta3.ReplicasRecommendation, now time.Time, cal *calendar.Calendar) (int32, error) {
 	for _, r := range recommendations {
 		tz, err := time.LoadLocation(r.TimeZone)
 		if err == nil {
@@ -724,13 +917,29 @@ func GetReplicasRecommendation(recommendations []autoscalingv1beta3.ReplicasReco
 			now = now.In(tz)
 		}
 
-		if now.Hour() < r.To && now.Hour() >= r.From && (r.WeekDay == nil || now.Weekday().String() == *r.WeekDay) {
+		if utils.InReplicasRecommendationSlot(r, now, cal.Weekday(now)) {
 			return r.Value, nil
 		}
 	}
 	return 0, errors.New("no recommendation slot")
 }
 
+// getReplicasRecommendationAhead returns the maximum recommendation of the time slots from now until now+leadTime.
+// It returns 0 if there is no recommendation slot within the period.
+func getReplicasRecommendationAhead(recommendations []autoscalingv1beta3.ReplicasRecommendation, now time.Time, leadTime time.Duration, cal *calendar.Calendar) int32 {
+	var maxValue int32
+	until := now.Add(leadTime)
+	step := utils.ReplicasRecommendationStep(recommendations)
+	for t := now; !t.After(until); t = t.Truncate(step).Add(step) {
+		v, err := GetReplicasRecommendation(recommendations, t, cal)
+		if err != nil {
+			continue
+		}
+		maxValue = max(maxValue, v)
+	}
+	return maxValue
+}
+
 // updateHPATargetValue updates the target value of the HPA.
 // It looks for the corresponding metric (ContainerResource) and updates the target value.
 func (c *Service) updateHPATargetValue(hpa *v2.HorizontalPodAutoscaler, containerName string, k corev1.ResourceName, targetValue int32) error {
@@ -815,6 +1024,58 @@ func GetHPATargetValue(ctx context.Context, hpa *v2.HorizontalPodAutoscaler, con
 	return 0, fmt.Errorf("the metric for the container isn't found in the hpa: %s. (resource name: %s, container name: %s)", client.ObjectKeyFromObject(hpa).String(), k, containerName)
 }
 
+// GetHPAMetricTarget gets the target average value of the Pods or External metric in the HPA.
+// It returns false if the metric isn't found or doesn't have the AverageValue target.
+func GetHPAMetricTarget(hpa *v2.HorizontalPodAutoscaler, metricType v2.MetricSourceType, metricName string) (resource.Quantity, bool) {
+	for _, m := range hpa.Spec.Metrics {
+		if target := metricTarget(m, metricType, metricName); target != nil && target.AverageValue != nil {
+			return *target.AverageValue, true
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+// updateHPAMetricTarget updates the target average value of the Pods or External metric in the HPA.
+// Like the target utilization, the target is increased by tortoiseHPATargetUtilizationMaxIncrease percent at most at once.
+func (c *Service) updateHPAMetricTarget(hpa *v2.HorizontalPodAutoscaler, metricType v2.MetricSourceType, metricName string, targetValue resource.Quantity) error {
+	for _, m := range hpa.Spec.Metrics {
+		target := metricTarget(m, metricType, metricName)
+		if target == nil || target.AverageValue == nil {
+			continue
+		}
+
+		maxIncrease := target.AverageValue.MilliValue() * int64(100+c.tortoiseHPATargetUtilizationMaxIncrease) / 100
+		if targetValue.MilliValue() > maxIncrease {
+			// We don't want to increase the target that much because it might be dangerous.
+			// (Reduce is OK)
+			targetValue = *resource.NewMilliQuantity(maxIncrease, target.AverageValue.Format)
+		}
+		target.AverageValue = &targetValue
+
+		return nil
+	}
+
+	return fmt.Errorf("no corresponding metric found: %s/%s", hpa.Namespace, hpa.Name)
+}
+
+// metricTarget returns the target of the Pods or External metric which has the name.
+func metricTarget(m v2.MetricSpec, metricType v2.MetricSourceType, metricName string) *v2.MetricTarget {
+	if m.Type != metricType {
+		return nil
+	}
+	switch metricType {
+	case v2.PodsMetricSourceType:
+		if m.Pods != nil && m.Pods.Metric.Name == metricName {
+			return &m.Pods.Target
+		}
+	case v2.ExternalMetricSourceType:
+		if m.External != nil && m.External.Metric.Name == metricName {
+			return &m.External.Target
+		}
+	}
+	return nil
+}
+
 // excludeExternalMetric excludes the external metric from the HPA, based on the regex.
 func (c *Service) excludeExternalMetric(ctx context.Context, hpa *v2.HorizontalPodAutoscaler) *v2.HorizontalPodAutoscaler {
 	if c.externalMetricExclusionRegex == nil {
@@ -18,6 +18,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/calendar"
+	"github.com/mercari/tortoise/pkg/config"
 )
 
 const (
@@ -312,6 +314,283 @@ func TestClient_UpdateHPAFromTortoiseRecommendation(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "minReplicas is raised by the constraints",
+			args: args{
+				ctx: context.Background(),
+				tortoise: &v1beta3.Tortoise{
+					Spec: v1beta3.TortoiseSpec{
+						UpdateMode: v1beta3.UpdateModeAuto,
+					},
+					Status: v1beta3.TortoiseStatus{
+						TortoisePhase: v1beta3.TortoisePhaseWorking,
+						AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+							{
+								ContainerName: "app",
+								Policy: map[v1.ResourceName]v1beta3.AutoscalingType{
+									v1.ResourceMemory: v1beta3.AutoscalingTypeHorizontal,
+								},
+							},
+							{
+								ContainerName: "istio-proxy",
+								Policy: map[v1.ResourceName]v1beta3.AutoscalingType{
+									v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal,
+								},
+							},
+						},
+						Conditions: v1beta3.Conditions{
+							TortoiseConditions: []v1beta3.TortoiseCondition{
+								{
+									Type:               v1beta3.TortoiseConditionTypeHPATargetUtilizationUpdated,
+									Status:             v1.ConditionTrue,
+									LastUpdateTime:     metav1.NewTime(now.Add(-3 * time.Hour)),
+									LastTransitionTime: metav1.NewTime(now.Add(-3 * time.Hour)),
+									Reason:             "HPATargetUtilizationUpdated",
+									Message:            "HPA target utilization is updated",
+								},
+							},
+						},
+						ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+							{
+								ContainerName: "app",
+								ResourcePhases: map[v1.ResourceName]v1beta3.ResourcePhase{
+									v1.ResourceMemory: {
+										Phase: v1beta3.ContainerResourcePhaseWorking,
+									},
+								},
+							},
+							{
+								ContainerName: "istio-proxy",
+								ResourcePhases: map[v1.ResourceName]v1beta3.ResourcePhase{
+									v1.ResourceCPU: {
+										Phase: v1beta3.ContainerResourcePhaseWorking,
+									},
+								},
+							},
+						},
+						Targets: v1beta3.TargetsStatus{
+							HorizontalPodAutoscaler: "hpa",
+						},
+						Recommendations: v1beta3.Recommendations{
+							Constraints: &v1beta3.Constraints{
+								MinimumMinReplicas: ptr.To[int32](10),
+							},
+							Horizontal: v1beta3.HorizontalRecommendations{
+								TargetUtilizations: []v1beta3.HPATargetUtilizationRecommendationPerContainer{
+									{
+										ContainerName: "app",
+										TargetUtilization: map[v1.ResourceName]int32{
+											v1.ResourceMemory: 90,
+										},
+									},
+									{
+										ContainerName: "istio-proxy",
+										TargetUtilization: map[v1.ResourceName]int32{
+											v1.ResourceCPU: 80,
+										},
+									},
+								},
+								MaxReplicas: []v1beta3.ReplicasRecommendation{
+									{
+										From:      0,
+										To:        2,
+										Value:     6,
+										UpdatedAt: now,
+										WeekDay:   ptr.To(now.Weekday().String()),
+									},
+								},
+								MinReplicas: []v1beta3.ReplicasRecommendation{
+									{
+										From:      0,
+										To:        2,
+										Value:     3,
+										UpdatedAt: now,
+										WeekDay:   ptr.To(now.Weekday().String()),
+									},
+								},
+							},
+						},
+					},
+				},
+				now: now.Time,
+			},
+			initialHPA: &v2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "hpa",
+				},
+				Spec: v2.HorizontalPodAutoscalerSpec{
+					MinReplicas: ptrInt32(1),
+					MaxReplicas: 2,
+					Metrics: []v2.MetricSpec{
+						{
+							Type: v2.ExternalMetricSourceType,
+							// should be kept
+							External: &v2.ExternalMetricSource{
+								Metric: v2.MetricIdentifier{
+									Name: "kept",
+								},
+							},
+						},
+						{
+							Type: v2.ContainerResourceMetricSourceType,
+							ContainerResource: &v2.ContainerResourceMetricSource{
+								Name: v1.ResourceMemory,
+								Target: v2.MetricTarget{
+									AverageUtilization: ptr.To[int32](60),
+								},
+								Container: "app",
+							},
+						},
+						{
+							Type: v2.ContainerResourceMetricSourceType,
+							ContainerResource: &v2.ContainerResourceMetricSource{
+								Name: v1.ResourceCPU,
+								Target: v2.MetricTarget{
+									AverageUtilization: ptr.To[int32](50),
+								},
+								Container: "istio-proxy",
+							},
+						},
+					},
+				},
+			},
+			want: &v2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "hpa",
+				},
+				Spec: v2.HorizontalPodAutoscalerSpec{
+					Behavior:    defaultHPABehaviorValue.DeepCopy(),
+					MinReplicas: ptrInt32(10),
+					MaxReplicas: 10,
+					Metrics: []v2.MetricSpec{
+						{
+							Type: v2.ExternalMetricSourceType,
+							// should be kept
+							External: &v2.ExternalMetricSource{
+								Metric: v2.MetricIdentifier{
+									Name: "kept",
+								},
+							},
+						},
+						{
+							Type: v2.ContainerResourceMetricSourceType,
+							ContainerResource: &v2.ContainerResourceMetricSource{
+								Name: v1.ResourceMemory,
+								Target: v2.MetricTarget{
+									AverageUtilization: ptr.To[int32](90),
+								},
+								Container: "app",
+							},
+						},
+						{
+							Type: v2.ContainerResourceMetricSourceType,
+							ContainerResource: &v2.ContainerResourceMetricSource{
+								Name: v1.ResourceCPU,
+								Target: v2.MetricTarget{
+									AverageUtilization: ptr.To[int32](80),
+								},
+								Container: "istio-proxy",
+							},
+						},
+					},
+				},
+			},
+			wantTortoise: &v1beta3.Tortoise{
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode: v1beta3.UpdateModeAuto,
+				},
+				Status: v1beta3.TortoiseStatus{
+					TortoisePhase: v1beta3.TortoisePhaseWorking,
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{
+							ContainerName: "app",
+							Policy: map[v1.ResourceName]v1beta3.AutoscalingType{
+								v1.ResourceMemory: v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+						{
+							ContainerName: "istio-proxy",
+							Policy: map[v1.ResourceName]v1beta3.AutoscalingType{
+								v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal,
+							},
+						},
+					},
+					Conditions: v1beta3.Conditions{
+						TortoiseConditions: []v1beta3.TortoiseCondition{
+							{
+								Type:               v1beta3.TortoiseConditionTypeHPATargetUtilizationUpdated,
+								Status:             v1.ConditionTrue,
+								LastUpdateTime:     now,
+								LastTransitionTime: now,
+								Reason:             "HPATargetUtilizationUpdated",
+								Message:            "HPA target utilization is updated",
+							},
+						},
+					},
+					ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+						{
+							ContainerName: "app",
+							ResourcePhases: map[v1.ResourceName]v1beta3.ResourcePhase{
+								v1.ResourceMemory: {
+									Phase: v1beta3.ContainerResourcePhaseWorking,
+								},
+							},
+						},
+						{
+							ContainerName: "istio-proxy",
+							ResourcePhases: map[v1.ResourceName]v1beta3.ResourcePhase{
+								v1.ResourceCPU: {
+									Phase: v1beta3.ContainerResourcePhaseWorking,
+								},
+							},
+						},
+					},
+					Targets: v1beta3.TargetsStatus{
+						HorizontalPodAutoscaler: "hpa",
+					},
+					Recommendations: v1beta3.Recommendations{
+						Constraints: &v1beta3.Constraints{
+							MinimumMinReplicas: ptr.To[int32](10),
+						},
+						Horizontal: v1beta3.HorizontalRecommendations{
+							TargetUtilizations: []v1beta3.HPATargetUtilizationRecommendationPerContainer{
+								{
+									ContainerName: "app",
+									TargetUtilization: map[v1.ResourceName]int32{
+										v1.ResourceMemory: 90,
+									},
+								},
+								{
+									ContainerName: "istio-proxy",
+									TargetUtilization: map[v1.ResourceName]int32{
+										v1.ResourceCPU: 80,
+									},
+								},
+							},
+							MaxReplicas: []v1beta3.ReplicasRecommendation{
+								{
+									From:      0,
+									To:        2,
+									Value:     6,
+									UpdatedAt: now,
+									WeekDay:   ptr.To(now.Weekday().String()),
+								},
+							},
+							MinReplicas: []v1beta3.ReplicasRecommendation{
+								{
+									From:      0,
+									To:        2,
+									Value:     3,
+									UpdatedAt: now,
+									WeekDay:   ptr.To(now.Weekday().String()),
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "exclude external metrics correctly",
 			args: args{
@@ -2754,7 +3033,7 @@ func TestClient_UpdateHPAFromTortoiseRecommendation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := New(fake.NewClientBuilder().WithRuntimeObjects(tt.initialHPA).Build(), record.NewFakeRecorder(10), 0.95, 90, 50, time.Hour, nil, 1000, 10001, 3, tt.excludeMetricRegex, 5*time.Minute, false, nil, nil)
+			c, err := New(fake.NewClientBuilder().WithRuntimeObjects(tt.initialHPA).Build(), record.NewFakeRecorder(10), 0.95, 90, 50, time.Hour, nil, 1000, 10001, 3, tt.excludeMetricRegex, 5*time.Minute, 0, nil, false, nil, nil, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
@@ -3098,12 +3377,12 @@ func TestService_InitializeHPA(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 100, 1000, 3, "", 5*time.Minute, false, nil, nil)
+			c, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 100, 1000, 3, "", 5*time.Minute, 0, nil, false, nil, nil, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
 			if tt.initialHPA != nil {
-				c, err = New(fake.NewClientBuilder().WithRuntimeObjects(tt.initialHPA).Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 100, 1000, 3, "", 5*time.Minute, false, nil, nil)
+				c, err = New(fake.NewClientBuilder().WithRuntimeObjects(tt.initialHPA).Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 100, 1000, 3, "", 5*time.Minute, 0, nil, false, nil, nil, nil)
 				if err != nil {
 					t.Fatalf("New() error = %v", err)
 				}
@@ -4656,12 +4935,12 @@ func TestService_UpdateHPASpecFromTortoiseAutoscalingPolicy(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 1000, 10000, 3, "", 5*time.Minute, false, nil, nil)
+			c, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 1000, 10000, 3, "", 5*time.Minute, 0, nil, false, nil, nil, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
 			if tt.initialHPA != nil {
-				c, err = New(fake.NewClientBuilder().WithRuntimeObjects(tt.initialHPA).Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 1000, 10000, 3, "", 5*time.Minute, false, nil, nil)
+				c, err = New(fake.NewClientBuilder().WithRuntimeObjects(tt.initialHPA).Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 1000, 10000, 3, "", 5*time.Minute, 0, nil, false, nil, nil, nil)
 				if err != nil {
 					t.Fatalf("New() error = %v", err)
 				}
@@ -5285,7 +5564,7 @@ func TestService_IsHpaMetricAvailable(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 100, 1000, 3, "", 5*time.Minute, false, nil, nil)
+			c, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 100, time.Hour, nil, 100, 1000, 3, "", 5*time.Minute, 0, nil, false, nil, nil, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
@@ -5538,9 +5817,12 @@ func TestService_IsHpaMetricAvailable_EmergencyModeGracePeriod(t *testing.T) {
 				100, 1000, 3,
 				"",
 				tt.emergencyModeGracePeriod,
+				0,
+				nil,
 				false,
 				nil,
 				nil,
+				nil,
 			)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
@@ -5555,3 +5837,342 @@ func TestService_IsHpaMetricAvailable_EmergencyModeGracePeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestService_updateHPAMetricTarget(t *testing.T) {
+	hpa := func(target string) *v2.HorizontalPodAutoscaler {
+		return &v2.HorizontalPodAutoscaler{
+			Spec: v2.HorizontalPodAutoscalerSpec{
+				Metrics: []v2.MetricSpec{
+					{
+						Type: v2.ExternalMetricSourceType,
+						External: &v2.ExternalMetricSource{
+							Metric: v2.MetricIdentifier{Name: "queue"},
+							Target: v2.MetricTarget{Type: v2.AverageValueMetricType, AverageValue: ptr.To(resource.MustParse(target))},
+						},
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name       string
+		hpa        *v2.HorizontalPodAutoscaler
+		metricType v2.MetricSourceType
+		target     string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "the target is reduced",
+			hpa:        hpa("50"),
+			metricType: v2.ExternalMetricSourceType,
+			target:     "30",
+			want:       "30",
+		},
+		{
+			name:       "the target is increased by 5% at most",
+			hpa:        hpa("100"),
+			metricType: v2.ExternalMetricSourceType,
+			target:     "200",
+			want:       "105",
+		},
+		{
+			name:       "the metric isn't found",
+			hpa:        hpa("50"),
+			metricType: v2.PodsMetricSourceType,
+			target:     "30",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Service{tortoiseHPATargetUtilizationMaxIncrease: 5}
+			err := c.updateHPAMetricTarget(tt.hpa, tt.metricType, "queue", resource.MustParse(tt.target))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("updateHPAMetricTarget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got, _ := GetHPAMetricTarget(tt.hpa, tt.metricType, "queue")
+			if got.Cmp(resource.MustParse(tt.want)) != 0 {
+				t.Errorf("updateHPAMetricTarget() target = %v, want %v", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_getReplicasRecommendationAhead(t *testing.T) {
+	// 2023-01-01 is Sunday.
+	now := time.Date(2023, 1, 1, 8, 40, 0, 0, time.UTC)
+	recommendations := []v1beta3.ReplicasRecommendation{
+		{From: 8, To: 9, Value: 3, WeekDay: ptr.To("Sunday"), TimeZone: "UTC"},
+		{From: 9, To: 10, Value: 8, WeekDay: ptr.To("Sunday"), TimeZone: "UTC"},
+		{From: 10, To: 11, Value: 5, WeekDay: ptr.To("Sunday"), TimeZone: "UTC"},
+		{From: 11, To: 12, Value: 10, WeekDay: ptr.To("Sunday"), TimeZone: "UTC"},
+	}
+	tests := []struct {
+		name     string
+		now      time.Time
+		leadTime time.Duration
+		want     int32
+	}{
+		{
+			name:     "the next slot doesn't start within the lead time",
+			now:      now,
+			leadTime: 15 * time.Minute,
+			want:     3,
+		},
+		{
+			name:     "the next slot starts within the lead time",
+			now:      now,
+			leadTime: 20 * time.Minute,
+			want:     8,
+		},
+		{
+			name:     "the maximum of the slots within the lead time",
+			now:      now,
+			leadTime: 2 * time.Hour,
+			want:     8,
+		},
+		{
+			name:     "the slot starting at the end of the lead time is included",
+			now:      now,
+			leadTime: 2*time.Hour + 20*time.Minute,
+			want:     10,
+		},
+		{
+			name:     "no slot",
+			now:      now.Add(-12 * time.Hour),
+			leadTime: time.Hour,
+			want:     0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getReplicasRecommendationAhead(recommendations, tt.now, tt.leadTime, nil); got != tt.want {
+				t.Errorf("getReplicasRecommendationAhead() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_ChangeHPAFromTortoiseRecommendation_MinReplicasLeadTime(t *testing.T) {
+	// 2023-01-01 is Sunday.
+	now := time.Date(2023, 1, 1, 8, 40, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		leadTime time.Duration
+		nextMin  int32
+		// specMaxReplicas is .spec.maxReplicas set by the user.
+		specMaxReplicas *int32
+		wantMin         int32
+		wantMax         int32
+	}{
+		{
+			name:    "minReplicas follows the current slot without the lead time",
+			nextMin: 8,
+			wantMin: 3,
+			wantMax: 20,
+		},
+		{
+			name:     "minReplicas is raised ahead of the next slot",
+			leadTime: 30 * time.Minute,
+			nextMin:  8,
+			wantMin:  8,
+			wantMax:  20,
+		},
+		{
+			name:     "maxReplicas is raised together if the minReplicas for the next slot is above the current maxReplicas",
+			leadTime: 30 * time.Minute,
+			nextMin:  50,
+			wantMin:  50,
+			wantMax:  50,
+		},
+		{
+			name:            "minReplicas for the next slot doesn't exceed .spec.maxReplicas",
+			leadTime:        30 * time.Minute,
+			nextMin:         50,
+			specMaxReplicas: ptr.To[int32](30),
+			wantMin:         30,
+			wantMax:         30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(fake.NewClientBuilder().Build(), record.NewFakeRecorder(10), 0.95, 90, 50, time.Hour, nil, 1000, 10000, 3, "", defaultEmergencyModeGracePeriod, tt.leadTime, nil, false, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			tortoise := &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+				Spec: v1beta3.TortoiseSpec{
+					UpdateMode:  v1beta3.UpdateModeAuto,
+					MaxReplicas: tt.specMaxReplicas,
+					TargetRefs: v1beta3.TargetRefs{
+						ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"},
+					},
+				},
+				Status: v1beta3.TortoiseStatus{
+					TortoisePhase: v1beta3.TortoisePhaseWorking,
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{ContainerName: "app", Policy: map[v1.ResourceName]v1beta3.AutoscalingType{v1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal}},
+					},
+					ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+						{ContainerName: "app", ResourcePhases: map[v1.ResourceName]v1beta3.ResourcePhase{v1.ResourceCPU: {Phase: v1beta3.ContainerResourcePhaseWorking}}},
+					},
+					Targets: v1beta3.TargetsStatus{HorizontalPodAutoscaler: "hpa"},
+					Recommendations: v1beta3.Recommendations{
+						Horizontal: v1beta3.HorizontalRecommendations{
+							TargetUtilizations: []v1beta3.HPATargetUtilizationRecommendationPerContainer{
+								{ContainerName: "app", TargetUtilization: map[v1.ResourceName]int32{v1.ResourceCPU: 80}},
+							},
+							MaxReplicas: []v1beta3.ReplicasRecommendation{
+								{From: 0, To: 9, Value: 20, WeekDay: ptr.To("Sunday")},
+								{From: 9, To: 24, Value: 100, WeekDay: ptr.To("Sunday")},
+							},
+							MinReplicas: []v1beta3.ReplicasRecommendation{
+								{From: 0, To: 9, Value: 3, WeekDay: ptr.To("Sunday")},
+								{From: 9, To: 24, Value: tt.nextMin, WeekDay: ptr.To("Sunday")},
+							},
+						},
+					},
+				},
+			}
+			hpa := &v2.HorizontalPodAutoscaler{
+				ObjectMeta: metav1.ObjectMeta{Name: "hpa", Namespace: "default"},
+				Spec: v2.HorizontalPodAutoscalerSpec{
+					ScaleTargetRef: v2.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+					MinReplicas:    ptr.To[int32](3),
+					MaxReplicas:    20,
+					Metrics: []v2.MetricSpec{
+						{
+							Type: v2.ContainerResourceMetricSourceType,
+							ContainerResource: &v2.ContainerResourceMetricSource{
+								Name:      v1.ResourceCPU,
+								Container: "app",
+								Target:    v2.MetricTarget{Type: v2.UtilizationMetricType, AverageUtilization: ptr.To[int32](60)},
+							},
+						},
+					},
+				},
+			}
+
+			got, _, err := s.ChangeHPAFromTortoiseRecommendation(context.Background(), tortoise, hpa, now, false)
+			if err != nil {
+				t.Fatalf("ChangeHPAFromTortoiseRecommendation() error = %v", err)
+			}
+			if *got.Spec.MinReplicas != tt.wantMin {
+				t.Errorf("minReplicas = %v, want %v", *got.Spec.MinReplicas, tt.wantMin)
+			}
+			if got.Spec.MaxReplicas != tt.wantMax {
+				t.Errorf("maxReplicas = %v, want %v", got.Spec.MaxReplicas, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestGetReplicasRecommendation_calendar(t *testing.T) {
+	// 2024-01-08 is Monday, and a public holiday in Japan.
+	holiday := time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC)
+	cal, err := calendar.New([]config.Calendar{{SpecialDays: []config.SpecialDay{{Date: "2024-01-08", Weekday: "Sunday"}}}}, "UTC")
+	if err != nil {
+		t.Fatalf("calendar.New() error = %v", err)
+	}
+	recommendations := []v1beta3.ReplicasRecommendation{
+		{From: 0, To: 24, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+		{From: 0, To: 24, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: 20},
+	}
+	tests := []struct {
+		name     string
+		calendar *calendar.Calendar
+		now      time.Time
+		want     int32
+	}{
+		{
+			name:     "the special day uses the recommendation of the day of the week in the calendar",
+			calendar: cal,
+			now:      holiday,
+			want:     3,
+		},
+		{
+			name:     "the normal day",
+			calendar: cal,
+			now:      holiday.AddDate(0, 0, 7),
+			want:     20,
+		},
+		{
+			name: "without the calendar",
+			now:  holiday,
+			want: 20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetReplicasRecommendation(recommendations, tt.now, tt.calendar)
+			if err != nil {
+				t.Fatalf("GetReplicasRecommendation() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetReplicasRecommendation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetReplicasRecommendation_minutes(t *testing.T) {
+	// 2023-01-01 is Sunday.
+	recommendations := []v1beta3.ReplicasRecommendation{
+		{From: 11, To: 12, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+		{From: 12, FromMinute: 0, To: 12, ToMinute: 5, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 10},
+		{From: 12, FromMinute: 5, To: 12, ToMinute: 10, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 20},
+		{From: 12, FromMinute: 10, To: 13, ToMinute: 0, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 5},
+	}
+	tests := []struct {
+		name     string
+		now      time.Time
+		leadTime time.Duration
+		want     int32
+	}{
+		{
+			name: "the hour slot",
+			now:  time.Date(2023, 1, 1, 11, 59, 0, 0, time.UTC),
+			want: 3,
+		},
+		{
+			name: "the start of the sub-hour slot",
+			now:  time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+			want: 10,
+		},
+		{
+			name: "the end of the sub-hour slot is excluded",
+			now:  time.Date(2023, 1, 1, 12, 5, 0, 0, time.UTC),
+			want: 20,
+		},
+		{
+			name: "the sub-hour slot ending at the next hour",
+			now:  time.Date(2023, 1, 1, 12, 59, 0, 0, time.UTC),
+			want: 5,
+		},
+		{
+			name:     "the 5 minutes slot within the lead time isn't missed",
+			now:      time.Date(2023, 1, 1, 11, 50, 0, 0, time.UTC),
+			leadTime: 17 * time.Minute,
+			want:     20,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetReplicasRecommendation(recommendations, tt.now, nil)
+			if err != nil {
+				t.Fatalf("GetReplicasRecommendation() error = %v", err)
+			}
+			if tt.leadTime != 0 {
+				got = getReplicasRecommendationAhead(recommendations, tt.now, tt.leadTime, nil)
+			}
+			if got != tt.want {
+				t.Errorf("GetReplicasRecommendation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
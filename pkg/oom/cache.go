@@ -0,0 +1,75 @@
+package oom
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StripPod is the transform function for the Pod cache, which drops everything from the Pod except what Observe needs:
+// the labels, the memory requests of the containers, the restartPolicy of the init containers (to find the native sidecars),
+// and the states of the containers.
+//
+// The watcher of OOMKilled caches all the Pods in the cluster,
+// and the full Pods (e.g., env, volumes, managedFields) take a lot of memory in large clusters.
+// Note that the Pods got from the cached client are always stripped; read them via the API reader if you need the full Pods.
+func StripPod(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil
+	}
+
+	stripped := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pod.Name,
+			Namespace:         pod.Namespace,
+			UID:               pod.UID,
+			ResourceVersion:   pod.ResourceVersion,
+			Labels:            pod.Labels,
+			DeletionTimestamp: pod.DeletionTimestamp,
+		},
+	}
+	for _, c := range pod.Spec.Containers {
+		stripped.Spec.Containers = append(stripped.Spec.Containers, stripContainer(c))
+	}
+	for _, c := range pod.Spec.InitContainers {
+		stripped.Spec.InitContainers = append(stripped.Spec.InitContainers, stripContainer(c))
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		stripped.Status.ContainerStatuses = append(stripped.Status.ContainerStatuses, stripContainerStatus(cs))
+	}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		stripped.Status.InitContainerStatuses = append(stripped.Status.InitContainerStatuses, stripContainerStatus(cs))
+	}
+	return stripped, nil
+}
+
+func stripContainer(c corev1.Container) corev1.Container {
+	stripped := corev1.Container{
+		Name:          c.Name,
+		RestartPolicy: c.RestartPolicy,
+	}
+	if q, ok := c.Resources.Requests[corev1.ResourceMemory]; ok {
+		stripped.Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: q}
+	}
+	return stripped
+}
+
+func stripContainerStatus(cs corev1.ContainerStatus) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:                 cs.Name,
+		State:                corev1.ContainerState{Terminated: stripTerminated(cs.State.Terminated)},
+		LastTerminationState: corev1.ContainerState{Terminated: stripTerminated(cs.LastTerminationState.Terminated)},
+	}
+}
+
+func stripTerminated(t *corev1.ContainerStateTerminated) *corev1.ContainerStateTerminated {
+	if t == nil {
+		return nil
+	}
+	// The message can be long (e.g., the termination log), and it's not used.
+	return &corev1.ContainerStateTerminated{
+		Reason:     t.Reason,
+		ExitCode:   t.ExitCode,
+		FinishedAt: t.FinishedAt,
+	}
+}
@@ -0,0 +1,102 @@
+package oom
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestStripPod(t *testing.T) {
+	finishedAt := metav1.NewTime(now)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "app-1",
+			Namespace:   "default",
+			Labels:      map[string]string{"app": "app"},
+			Annotations: map[string]string{"foo": "bar"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "app",
+					Image: "app:latest",
+					Env:   []corev1.EnvVar{{Name: "GOMAXPROCS", Value: "2"}},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+			InitContainers: []corev1.Container{
+				{Name: "sidecar", Image: "sidecar:latest", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)},
+			},
+			Volumes: []corev1.Volume{{Name: "data"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:  "app",
+					Image: "app:latest",
+					State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: reasonOOMKilled, ExitCode: 137, Message: "long termination log", FinishedAt: finishedAt},
+					},
+				},
+			},
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "sidecar", Image: "sidecar:latest"},
+			},
+		},
+	}
+	want := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app-1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "app"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				},
+			},
+			InitContainers: []corev1.Container{
+				{Name: "sidecar", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "app",
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: reasonOOMKilled, ExitCode: 137, FinishedAt: finishedAt},
+					},
+				},
+			},
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "sidecar"},
+			},
+		},
+	}
+
+	got, err := StripPod(pod)
+	if err != nil {
+		t.Fatalf("StripPod() error = %v", err)
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("StripPod() diff = %s", d)
+	}
+	if !HasOOMKilledContainer(got.(*corev1.Pod)) {
+		t.Errorf("the stripped Pod should still have the OOMKilled container")
+	}
+}
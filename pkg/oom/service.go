@@ -0,0 +1,247 @@
+package oom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+// reasonOOMKilled is the reason of the container termination when the container is killed by OOM.
+const reasonOOMKilled = "OOMKilled"
+
+// oomKill is the OOMKilled termination of the container observed from the Pod status.
+type oomKill struct {
+	podName       string
+	podLabels     labels.Set
+	containerName string
+	finishedAt    time.Time
+	// memoryRequest is the memory request of the container when it's OOMKilled.
+	memoryRequest resource.Quantity
+}
+
+// Service keeps track of the recent OOMKilled terminations of the containers,
+// and bumps up the memory recommendation of the containers in the tortoises.
+type Service struct {
+	recorder record.EventRecorder
+
+	// bumpUpRatio is the ratio to bump up the memory request at the OOMKill.
+	bumpUpRatio float64
+	// minBumpUp is the minimum amount of memory to bump up.
+	minBumpUp resource.Quantity
+	// cooldown is the period after the OOMKill, during which the memory request isn't scaled down.
+	// 0 means the OOMKilled terminations are ignored.
+	cooldown time.Duration
+
+	mu sync.Mutex
+	// oomKills has the OOMKilled terminations within the cooldown period.
+	// The key is the namespace.
+	oomKills map[string][]oomKill
+}
+
+func New(recorder record.EventRecorder, bumpUpRatio float64, minBumpUp string, cooldown time.Duration) *Service {
+	return &Service{
+		recorder:    recorder,
+		bumpUpRatio: bumpUpRatio,
+		minBumpUp:   resource.MustParse(minBumpUp),
+		cooldown:    cooldown,
+		oomKills:    map[string][]oomKill{},
+	}
+}
+
+// Enabled returns true if the OOMKilled terminations are taken into account.
+func (s *Service) Enabled() bool {
+	return s.cooldown > 0
+}
+
+// HasOOMKilledContainer returns true if any container (or native sidecar) in the Pod was OOMKilled.
+func HasOOMKilledContainer(pod *corev1.Pod) bool {
+	for _, cs := range containerStatuses(pod) {
+		if oomKilledTermination(cs) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Observe records the OOMKilled terminations in the Pod status.
+// The same termination is recorded only once even if it's observed many times.
+func (s *Service) Observe(pod *corev1.Pod, now time.Time) {
+	if !s.Enabled() {
+		return
+	}
+
+	memoryRequests := map[string]resource.Quantity{}
+	for _, c := range utils.ScalableContainers(&pod.Spec) {
+		memoryRequests[c.Name] = c.Resources.Requests[corev1.ResourceMemory]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+	for _, cs := range containerStatuses(pod) {
+		t := oomKilledTermination(cs)
+		if t == nil || t.FinishedAt.IsZero() {
+			// Without the time of the termination, we cannot tell whether it's recent.
+			continue
+		}
+		if t.FinishedAt.Add(s.cooldown).Before(now) {
+			continue
+		}
+		if s.recorded(pod.Namespace, pod.Name, cs.Name, t.FinishedAt.Time) {
+			continue
+		}
+		s.oomKills[pod.Namespace] = append(s.oomKills[pod.Namespace], oomKill{
+			podName:       pod.Name,
+			podLabels:     labels.Set(pod.Labels),
+			containerName: cs.Name,
+			finishedAt:    t.FinishedAt.Time,
+			memoryRequest: memoryRequests[cs.Name],
+		})
+	}
+}
+
+// UpdateOOMBumps updates .status.recommendations.vertical.oomBumps in the tortoise
+// based on the OOMKilled terminations of the Pods matching the selector.
+// The bumps whose cooldown period is over are removed.
+func (s *Service) UpdateOOMBumps(ctx context.Context, tortoise *v1beta3.Tortoise, selector *metav1.LabelSelector, now time.Time) (*v1beta3.Tortoise, error) {
+	if !s.Enabled() {
+		tortoise.Status.Recommendations.Vertical.OOMBumps = nil
+		return tortoise, nil
+	}
+
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return tortoise, fmt.Errorf("failed to convert the label selector: %w", err)
+	}
+
+	bumps := map[string]v1beta3.ContainerOOMBump{}
+	for _, b := range tortoise.Status.Recommendations.Vertical.OOMBumps {
+		if b.ScaleDownStoppedUntil.Time.After(now) {
+			bumps[b.ContainerName] = b
+		}
+	}
+
+	for _, k := range s.oomKillsMatching(tortoise.Namespace, sel, now) {
+		current, ok := bumps[k.containerName]
+		if ok && !k.finishedAt.After(current.LastOOMKilledTime.Time) {
+			// This OOMKill is already taken into account.
+			continue
+		}
+
+		bumped := s.bumpUp(k.memoryRequest)
+		if ok && current.BumpedMemory.Cmp(bumped) > 0 {
+			// The bumped memory is never lowered during the cooldown period.
+			bumped = current.BumpedMemory
+		}
+		bumps[k.containerName] = v1beta3.ContainerOOMBump{
+			ContainerName:         k.containerName,
+			LastOOMKilledTime:     metav1.NewTime(k.finishedAt),
+			BumpedMemory:          bumped,
+			ScaleDownStoppedUntil: metav1.NewTime(k.finishedAt.Add(s.cooldown)),
+		}
+
+		message := fmt.Sprintf("The memory recommendation of the container %s is bumped up to %s because the container in the Pod %s was OOMKilled with %s memory request", k.containerName, bumped.String(), k.podName, k.memoryRequest.String())
+		s.recorder.Event(tortoise, corev1.EventTypeWarning, event.MemoryBumpedUpOnOOMKilled, message)
+		log.FromContext(ctx).Info("the memory recommendation is bumped up because of OOMKilled", "tortoise", klog.KObj(tortoise), "container", k.containerName, "pod", k.podName, "bumpedMemory", bumped.String())
+	}
+
+	var result []v1beta3.ContainerOOMBump
+	for _, b := range bumps {
+		result = append(result, b)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ContainerName < result[j].ContainerName })
+	tortoise.Status.Recommendations.Vertical.OOMBumps = result
+
+	return tortoise, nil
+}
+
+// bumpUp returns max(request * bumpUpRatio, request + minBumpUp).
+func (s *Service) bumpUp(request resource.Quantity) resource.Quantity {
+	bumped := int64(float64(request.Value()) * s.bumpUpRatio)
+	if min := request.Value() + s.minBumpUp.Value(); bumped < min {
+		bumped = min
+	}
+	return *resource.NewQuantity(bumped, resource.BinarySI)
+}
+
+// oomKillsMatching returns the OOMKilled terminations of the Pods matching the selector in the namespace, from the oldest to the newest.
+func (s *Service) oomKillsMatching(namespace string, sel labels.Selector, now time.Time) []oomKill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+	var matched []oomKill
+	for _, k := range s.oomKills[namespace] {
+		if sel.Matches(k.podLabels) {
+			matched = append(matched, k)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].finishedAt.Before(matched[j].finishedAt) })
+	return matched
+}
+
+// prune removes the OOMKilled terminations whose cooldown period is over.
+// s.mu has to be locked by the caller.
+func (s *Service) prune(now time.Time) {
+	for ns, kills := range s.oomKills {
+		var kept []oomKill
+		for _, k := range kills {
+			if !k.finishedAt.Add(s.cooldown).Before(now) {
+				kept = append(kept, k)
+			}
+		}
+		if len(kept) == 0 {
+			delete(s.oomKills, ns)
+			continue
+		}
+		s.oomKills[ns] = kept
+	}
+}
+
+// recorded returns true if the OOMKilled termination is already recorded.
+// s.mu has to be locked by the caller.
+func (s *Service) recorded(namespace, podName, containerName string, finishedAt time.Time) bool {
+	for _, k := range s.oomKills[namespace] {
+		if k.podName == podName && k.containerName == containerName && k.finishedAt.Equal(finishedAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// containerStatuses returns the statuses of the containers and the native sidecars in the Pod.
+// The native sidecars' statuses are in .status.initContainerStatuses.
+func containerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...)
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if utils.HasNativeSidecar(&pod.Spec, cs.Name) {
+			statuses = append(statuses, cs)
+		}
+	}
+	return statuses
+}
+
+func oomKilledTermination(cs corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if cs.State.Terminated != nil && cs.State.Terminated.Reason == reasonOOMKilled {
+		return cs.State.Terminated
+	}
+	if cs.LastTerminationState.Terminated != nil && cs.LastTerminationState.Terminated.Reason == reasonOOMKilled {
+		return cs.LastTerminationState.Terminated
+	}
+	return nil
+}
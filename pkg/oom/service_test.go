@@ -0,0 +1,195 @@
+package oom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+var now = time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC)
+
+func testPod(name string, appLabel string, memoryRequest string, finishedAt time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": appLabel}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memoryRequest)},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name: "app",
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(finishedAt)},
+					},
+				},
+			},
+		},
+	}
+}
+
+func multiply(q string, ratio float64) resource.Quantity {
+	v := resource.MustParse(q)
+	return *resource.NewQuantity(int64(float64(v.Value())*ratio), resource.BinarySI)
+}
+
+func TestService_UpdateOOMBumps(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "mercari"}}
+	tests := []struct {
+		name     string
+		cooldown time.Duration
+		pods     []*corev1.Pod
+		bumps    []v1beta3.ContainerOOMBump
+		want     []v1beta3.ContainerOOMBump
+	}{
+		{
+			name:     "bumped up by the ratio",
+			cooldown: time.Hour,
+			pods:     []*corev1.Pod{testPod("pod", "mercari", "1Gi", now.Add(-10*time.Minute))},
+			want: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-10 * time.Minute)),
+					BumpedMemory:          multiply("1Gi", 1.2),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(50 * time.Minute)),
+				},
+			},
+		},
+		{
+			name:     "bumped up by the minimum bump up",
+			cooldown: time.Hour,
+			pods:     []*corev1.Pod{testPod("pod", "mercari", "100Mi", now.Add(-10*time.Minute))},
+			want: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-10 * time.Minute)),
+					BumpedMemory:          resource.MustParse("200Mi"),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(50 * time.Minute)),
+				},
+			},
+		},
+		{
+			name:     "the Pods of another workload are ignored",
+			cooldown: time.Hour,
+			pods:     []*corev1.Pod{testPod("pod", "another", "1Gi", now.Add(-10*time.Minute))},
+		},
+		{
+			name:     "the OOMKill before the cooldown period is ignored",
+			cooldown: time.Hour,
+			pods:     []*corev1.Pod{testPod("pod", "mercari", "1Gi", now.Add(-2*time.Hour))},
+		},
+		{
+			name:     "the latest OOMKill bumps up again from the bumped memory request",
+			cooldown: time.Hour,
+			pods: []*corev1.Pod{
+				testPod("pod1", "mercari", "1000Mi", now.Add(-20*time.Minute)),
+				testPod("pod2", "mercari", "1200Mi", now.Add(-5*time.Minute)),
+			},
+			want: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-5 * time.Minute)),
+					BumpedMemory:          multiply("1200Mi", 1.2),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(55 * time.Minute)),
+				},
+			},
+		},
+		{
+			name:     "the OOMKill already taken into account doesn't change the bump",
+			cooldown: time.Hour,
+			pods:     []*corev1.Pod{testPod("pod", "mercari", "1Gi", now.Add(-10*time.Minute))},
+			bumps: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-10 * time.Minute)),
+					BumpedMemory:          resource.MustParse("2Gi"),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(50 * time.Minute)),
+				},
+			},
+			want: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-10 * time.Minute)),
+					BumpedMemory:          resource.MustParse("2Gi"),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(50 * time.Minute)),
+				},
+			},
+		},
+		{
+			name:     "the bump whose cooldown is over is removed",
+			cooldown: time.Hour,
+			bumps: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-2 * time.Hour)),
+					BumpedMemory:          resource.MustParse("2Gi"),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(-time.Hour)),
+				},
+			},
+		},
+		{
+			name:     "disabled",
+			cooldown: 0,
+			pods:     []*corev1.Pod{testPod("pod", "mercari", "1Gi", now.Add(-10*time.Minute))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(record.NewFakeRecorder(10), 1.2, "100Mi", tt.cooldown)
+			for _, p := range tt.pods {
+				s.Observe(p, now)
+				// The same OOMKill is observed many times.
+				s.Observe(p, now)
+			}
+
+			tortoise := &v1beta3.Tortoise{ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"}}
+			tortoise.Status.Recommendations.Vertical.OOMBumps = tt.bumps
+			got, err := s.UpdateOOMBumps(context.Background(), tortoise, selector, now)
+			if err != nil {
+				t.Fatalf("UpdateOOMBumps() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, got.Status.Recommendations.Vertical.OOMBumps); d != "" {
+				t.Errorf("UpdateOOMBumps() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestHasOOMKilledContainer(t *testing.T) {
+	if !HasOOMKilledContainer(testPod("pod", "mercari", "1Gi", now)) {
+		t.Errorf("HasOOMKilledContainer() = false, want true")
+	}
+	if HasOOMKilledContainer(&corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{Name: "app"}}}}) {
+		t.Errorf("HasOOMKilledContainer() = true, want false")
+	}
+
+	// The native sidecar is OOMKilled.
+	sidecar := testPod("pod", "mercari", "1Gi", now)
+	sidecar.Spec.InitContainers = []corev1.Container{{Name: "sidecar", RestartPolicy: ptr.To(corev1.ContainerRestartPolicyAlways)}}
+	sidecar.Status.InitContainerStatuses = sidecar.Status.ContainerStatuses
+	sidecar.Status.InitContainerStatuses[0].Name = "sidecar"
+	sidecar.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app"}}
+	if !HasOOMKilledContainer(sidecar) {
+		t.Errorf("HasOOMKilledContainer() = false for the native sidecar, want true")
+	}
+	// The init container which isn't a native sidecar is ignored.
+	sidecar.Spec.InitContainers[0].RestartPolicy = nil
+	if HasOOMKilledContainer(sidecar) {
+		t.Errorf("HasOOMKilledContainer() = true for the init container, want false")
+	}
+}
@@ -0,0 +1,182 @@
+package pacing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+// rolloutTimeout is the period after which a rollout is no longer counted as running, even if it's not completed yet.
+// It prevents the stuck rollout from blocking the other restarts forever.
+const rolloutTimeout = time.Hour
+
+// Service paces the restarts of the scale targets triggered by Tortoise.
+// It defers the restart while the PodDisruptionBudget of the scale target doesn't allow any disruption,
+// or while too many rollouts triggered by Tortoise are running in the cluster or in the namespace.
+//
+// The running rollouts are tracked in memory, so they're forgotten when the controller is restarted.
+type Service struct {
+	// reader is used to get the PodDisruptionBudgets and the scale targets.
+	// It's expected to be the uncached reader so that the controller doesn't have to cache all the PodDisruptionBudgets in the cluster.
+	reader   client.Reader
+	recorder record.EventRecorder
+
+	maxConcurrentRestarts             int
+	maxConcurrentRestartsPerNamespace int
+
+	mu sync.Mutex
+	// rollouts are the rollouts triggered by Tortoise, which may be still running.
+	// The key is the tortoise which triggered the rollout.
+	rollouts map[client.ObjectKey]rollout
+}
+
+type rollout struct {
+	// adapter handles the kind of the workload.
+	adapter   workload.Adapter
+	workload  client.Object
+	startedAt time.Time
+}
+
+func New(reader client.Reader, recorder record.EventRecorder, maxConcurrentRestarts, maxConcurrentRestartsPerNamespace int) *Service {
+	return &Service{
+		reader:                            reader,
+		recorder:                          recorder,
+		maxConcurrentRestarts:             maxConcurrentRestarts,
+		maxConcurrentRestartsPerNamespace: maxConcurrentRestartsPerNamespace,
+		rollouts:                          map[client.ObjectKey]rollout{},
+	}
+}
+
+// Deferred returns true if the restart of the scale target is deferred and has to be retried.
+func Deferred(tortoise *v1beta3.Tortoise) bool {
+	cond := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeRestartDeferred)
+	return cond != nil && cond.Status == corev1.ConditionTrue
+}
+
+// TryStart returns true if the scale target can be restarted now, and records the rollout as running.
+// Otherwise, it marks the restart as deferred with the RestartDeferred condition.
+func (s *Service) TryStart(ctx context.Context, tortoise *v1beta3.Tortoise, adapter workload.Adapter, w client.Object, now time.Time) (*v1beta3.Tortoise, bool, error) {
+	pdb, err := s.exhaustedPDB(ctx, adapter, w)
+	if err != nil {
+		return tortoise, false, err
+	}
+	if pdb != "" {
+		return s.deferRestart(ctx, tortoise, "PodDisruptionBudget", fmt.Sprintf("The restart is deferred because the PodDisruptionBudget %s doesn't allow any disruption now", pdb), now), false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.forgetCompletedRollouts(ctx, now); err != nil {
+		return tortoise, false, err
+	}
+
+	key := client.ObjectKeyFromObject(tortoise)
+	running, runningInNamespace := 0, 0
+	for k := range s.rollouts {
+		if k == key {
+			// The same tortoise restarts the scale target again.
+			continue
+		}
+		running++
+		if k.Namespace == tortoise.Namespace {
+			runningInNamespace++
+		}
+	}
+	if s.maxConcurrentRestarts > 0 && running >= s.maxConcurrentRestarts {
+		return s.deferRestart(ctx, tortoise, "ConcurrentRestartLimit", fmt.Sprintf("The restart is deferred because %d rollouts are running in the cluster", running), now), false, nil
+	}
+	if s.maxConcurrentRestartsPerNamespace > 0 && runningInNamespace >= s.maxConcurrentRestartsPerNamespace {
+		return s.deferRestart(ctx, tortoise, "ConcurrentRestartLimit", fmt.Sprintf("The restart is deferred because %d rollouts are running in the namespace", runningInNamespace), now), false, nil
+	}
+
+	s.rollouts[key] = rollout{adapter: adapter, workload: w.DeepCopyObject().(client.Object), startedAt: now}
+	if utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeRestartDeferred) != nil {
+		tortoise = utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeRestartDeferred, corev1.ConditionFalse, "Restarted", "The scale target is restarted", now)
+	}
+	return tortoise, true, nil
+}
+
+func (s *Service) deferRestart(ctx context.Context, tortoise *v1beta3.Tortoise, reason, message string, now time.Time) *v1beta3.Tortoise {
+	if !Deferred(tortoise) {
+		s.recorder.Event(tortoise, corev1.EventTypeNormal, event.RestartDeferred, message)
+	}
+	log.FromContext(ctx).Info(message, "tortoise", klog.KObj(tortoise))
+	return utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeRestartDeferred, corev1.ConditionTrue, reason, message, now)
+}
+
+// exhaustedPDB returns the name of the PodDisruptionBudget matching the Pods of the workload,
+// which doesn't allow any disruption because some Pods are already unhealthy.
+// It returns the empty string if there is no such PodDisruptionBudget.
+func (s *Service) exhaustedPDB(ctx context.Context, adapter workload.Adapter, w client.Object) (string, error) {
+	template, err := adapter.PodTemplate(w)
+	if err != nil {
+		return "", err
+	}
+	podLabels := template.Labels
+
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := s.reader.List(ctx, pdbs, client.InNamespace(w.GetNamespace())); err != nil {
+		return "", fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	for _, pdb := range pdbs.Items {
+		if pdb.Spec.Selector == nil {
+			// A nil selector selects no Pods.
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert the label selector of the PodDisruptionBudget %s: %w", pdb.Name, err)
+		}
+		if !selector.Matches(labels.Set(podLabels)) {
+			continue
+		}
+		if pdb.Status.DisruptionsAllowed == 0 && pdb.Status.CurrentHealthy < pdb.Status.ExpectedPods {
+			return pdb.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// forgetCompletedRollouts removes the completed rollouts from the running rollouts.
+func (s *Service) forgetCompletedRollouts(ctx context.Context, now time.Time) error {
+	for k, r := range s.rollouts {
+		if r.startedAt.Add(rolloutTimeout).Before(now) {
+			delete(s.rollouts, k)
+			continue
+		}
+
+		w := r.workload.DeepCopyObject().(client.Object)
+		if err := s.reader.Get(ctx, client.ObjectKeyFromObject(r.workload), w); err != nil {
+			if apierrors.IsNotFound(err) {
+				delete(s.rollouts, k)
+				continue
+			}
+			return fmt.Errorf("failed to get the scale target %s: %w", client.ObjectKeyFromObject(r.workload), err)
+		}
+		completed, err := r.adapter.RolloutCompleted(w)
+		if err != nil {
+			return err
+		}
+		if completed {
+			delete(s.rollouts, k)
+		}
+	}
+	return nil
+}
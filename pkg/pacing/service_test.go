@@ -0,0 +1,172 @@
+package pacing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+var now = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testTortoise(namespace string, conditions ...v1beta3.TortoiseCondition) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: namespace},
+		Status: v1beta3.TortoiseStatus{
+			Conditions: v1beta3.Conditions{TortoiseConditions: conditions},
+		},
+	}
+}
+
+func testDeployment(namespace, name string, rolledOut bool) *appsv1.Deployment {
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Generation: 2},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: ptr.To[int32](3),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": name}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+	}
+	if !rolledOut {
+		d.Status.ObservedGeneration = 1
+		d.Status.UpdatedReplicas = 1
+	}
+	return d
+}
+
+func testPDB(app string, disruptionsAllowed, currentHealthy int32) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: app, Namespace: "default"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": app}},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: disruptionsAllowed, CurrentHealthy: currentHealthy, ExpectedPods: 3},
+	}
+}
+
+func TestService_TryStart(t *testing.T) {
+	deferred := v1beta3.TortoiseCondition{
+		Type:   v1beta3.TortoiseConditionTypeRestartDeferred,
+		Status: corev1.ConditionTrue,
+		Reason: "ConcurrentRestartLimit",
+	}
+	tests := []struct {
+		name                   string
+		tortoise               *v1beta3.Tortoise
+		objects                []client.Object
+		maxConcurrent          int
+		maxConcurrentNamespace int
+		// running are the scale targets being restarted by other tortoises.
+		running    []*appsv1.Deployment
+		wantStart  bool
+		wantReason string
+	}{
+		{
+			name:      "restart without limits",
+			tortoise:  testTortoise("default"),
+			wantStart: true,
+		},
+		{
+			name:       "the PodDisruptionBudget doesn't allow any disruption",
+			tortoise:   testTortoise("default"),
+			objects:    []client.Object{testPDB("app", 0, 2)},
+			wantReason: "PodDisruptionBudget",
+		},
+		{
+			name:      "the PodDisruptionBudget doesn't allow any disruption, but all the Pods are healthy",
+			tortoise:  testTortoise("default"),
+			objects:   []client.Object{testPDB("app", 0, 3)},
+			wantStart: true,
+		},
+		{
+			name:      "the PodDisruptionBudget of another workload is ignored",
+			tortoise:  testTortoise("default"),
+			objects:   []client.Object{testPDB("another", 0, 2)},
+			wantStart: true,
+		},
+		{
+			name:          "too many rollouts are running in the cluster",
+			tortoise:      testTortoise("default"),
+			maxConcurrent: 1,
+			running:       []*appsv1.Deployment{testDeployment("another", "running", false)},
+			wantReason:    "ConcurrentRestartLimit",
+		},
+		{
+			name:          "the completed rollouts are not counted",
+			tortoise:      testTortoise("default"),
+			maxConcurrent: 1,
+			running:       []*appsv1.Deployment{testDeployment("another", "completed", true)},
+			wantStart:     true,
+		},
+		{
+			name:                   "too many rollouts are running in the namespace",
+			tortoise:               testTortoise("default"),
+			maxConcurrentNamespace: 1,
+			running:                []*appsv1.Deployment{testDeployment("default", "running", false)},
+			wantReason:             "ConcurrentRestartLimit",
+		},
+		{
+			name:                   "the rollouts in another namespace are not counted in the namespace limit",
+			tortoise:               testTortoise("default"),
+			maxConcurrentNamespace: 1,
+			running:                []*appsv1.Deployment{testDeployment("another", "running", false)},
+			wantStart:              true,
+		},
+		{
+			name:          "the deferred restart is started",
+			tortoise:      testTortoise("default", deferred),
+			maxConcurrent: 1,
+			wantStart:     true,
+			wantReason:    "Restarted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := tt.objects
+			for _, d := range tt.running {
+				objects = append(objects, d)
+			}
+			c := fake.NewClientBuilder().WithObjects(objects...).WithStatusSubresource(&appsv1.Deployment{}).Build()
+			s := New(c, record.NewFakeRecorder(10), tt.maxConcurrent, tt.maxConcurrentNamespace)
+			adapter := deployment.New(c, nil, record.NewFakeRecorder(10))
+			for _, d := range tt.running {
+				s.rollouts[client.ObjectKey{Namespace: d.Namespace, Name: d.Name}] = rollout{adapter: adapter, workload: d, startedAt: now.Add(-time.Minute)}
+			}
+
+			got, start, err := s.TryStart(context.Background(), tt.tortoise, adapter, testDeployment("default", "app", true), now)
+			if err != nil {
+				t.Fatalf("TryStart() error = %v", err)
+			}
+			if start != tt.wantStart {
+				t.Errorf("TryStart() start = %v, want %v", start, tt.wantStart)
+			}
+			cond := utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeRestartDeferred)
+			if tt.wantReason == "" {
+				if cond != nil {
+					t.Errorf("RestartDeferred condition should not be set: %v", cond)
+				}
+				return
+			}
+			if cond == nil || cond.Reason != tt.wantReason || (cond.Status == corev1.ConditionTrue) == tt.wantStart {
+				t.Errorf("RestartDeferred condition = %v, want reason %v", cond, tt.wantReason)
+			}
+			if _, ok := s.rollouts[client.ObjectKeyFromObject(tt.tortoise)]; ok != tt.wantStart {
+				t.Errorf("the rollout is recorded = %v, want %v", ok, tt.wantStart)
+			}
+		})
+	}
+}
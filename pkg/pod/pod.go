@@ -2,9 +2,9 @@ package pod
 
 import (
 	"fmt"
-	"math"
-	"strconv"
+	"sort"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -12,23 +12,28 @@ import (
 	"k8s.io/utils/ptr"
 
 	"github.com/mercari/tortoise/api/v1beta3"
-	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/features"
 	"github.com/mercari/tortoise/pkg/utils"
 )
 
 type Service struct {
 	// For example, if it's 3 and Pod's resource request is 100m, the limit will be changed to 300m.
-	resourceLimitMultiplier       map[string]int64
-	minimumCPULimit               resource.Quantity
-	controllerFetcher             controllerfetcher.ControllerFetcher
-	goMemLimitModificationEnabled bool
+	resourceLimitMultiplier map[string]int64
+	minimumCPULimit         resource.Quantity
+	controllerFetcher       controllerfetcher.ControllerFetcher
+	// runtimeTuners keep the runtime configuration in the environment variables (e.g., GOMAXPROCS) proportional to the requests.
+	runtimeTuners []RuntimeTuner
+	// sidecarInjectors is the map from the name of the sidecar container injected by a mutating webhook (e.g., istio-proxy)
+	// to the annotations which configure its resources.
+	sidecarInjectors map[string]config.SidecarInjector
 }
 
 func New(
 	resourceLimitMultiplier map[string]int64,
 	minimumCPULimit string,
 	cf controllerfetcher.ControllerFetcher,
+	sidecarInjectors map[string]config.SidecarInjector,
 	featureFlags []features.FeatureFlag,
 ) (*Service, error) {
 	if minimumCPULimit == "" {
@@ -36,82 +41,88 @@ func New(
 	}
 	minCPULim := resource.MustParse(minimumCPULimit)
 	return &Service{
-		resourceLimitMultiplier:       resourceLimitMultiplier,
-		minimumCPULimit:               minCPULim,
-		controllerFetcher:             cf,
-		goMemLimitModificationEnabled: features.Contains(featureFlags, features.GoMemLimitModificationEnabled),
+		resourceLimitMultiplier: resourceLimitMultiplier,
+		minimumCPULimit:         minCPULim,
+		controllerFetcher:       cf,
+		runtimeTuners:           runtimeTuners(featureFlags),
+		sidecarInjectors:        sidecarInjectors,
 	}, nil
 }
 
 func (s *Service) ModifyPodTemplateResource(podTemplate *v1.PodTemplateSpec, t *v1beta3.Tortoise, opts ...ModifyPodSpecResourceOption) {
 	s.ModifyPodSpecResource(&podTemplate.Spec, t, opts...)
 
-	// Update istio sidecar resource requests based on the tortoise.Status.Conditions.ContainerResourceRequests
-	// since ModifyPodSpecResource doesn't update the istio annotations.
+	// Update the resource requests of the injected sidecars (e.g., istio-proxy) based on the tortoise.Status.Conditions.ContainerResourceRequests
+	// since ModifyPodSpecResource doesn't update the annotations of the sidecar injectors.
 	if podTemplate.Annotations == nil {
 		return
 	}
-	if podTemplate.Annotations[annotation.IstioSidecarInjectionAnnotation] != "true" {
-		return
+	for name, injector := range s.sidecarInjectors {
+		if !injector.Injected(podTemplate.Annotations) {
+			continue
+		}
+		if utils.HasNativeSidecar(&podTemplate.Spec, name) {
+			// The sidecar is declared as a native sidecar in the template (e.g., Istio's native sidecar mode with the custom injection),
+			// and ModifyPodSpecResource has already updated its resources in place of the annotations.
+			continue
+		}
+		s.modifySidecarAnnotations(podTemplate, t, name, injector, opts...)
 	}
+}
 
+// modifySidecarAnnotations updates the annotations for the resources of the injected sidecar,
+// keeping the limit proportional to the request.
+func (s *Service) modifySidecarAnnotations(podTemplate *v1.PodTemplateSpec, t *v1beta3.Tortoise, name string, injector config.SidecarInjector, opts ...ModifyPodSpecResourceOption) {
 	// Update resource requests based on the tortoise.Status.Conditions.ContainerResourceRequests
 	for _, k := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
-		newReq, ok := utils.GetRequestFromTortoise(t, "istio-proxy", k)
+		newReq, ok := utils.GetRequestFromTortoise(t, name, k)
 		if !ok {
 			continue
 		}
 
-		if k == v1.ResourceCPU {
-			oldCPUReq, ok := podTemplate.Annotations[annotation.IstioSidecarProxyCPUAnnotation]
-			oldCPULim, ok2 := podTemplate.Annotations[annotation.IstioSidecarProxyCPULimitAnnotation]
-			if ok && ok2 {
-				oldCPUReqQuantity, err := resource.ParseQuantity(oldCPUReq)
-				if err != nil {
-					continue
-				}
-
-				oldCPULimQuantity, err := resource.ParseQuantity(oldCPULim)
-				if err != nil {
-					continue
-				}
-
-				if containsOption(opts, NoScaleDown) && newReq.Cmp(oldCPUReqQuantity) < 0 {
-					// If NoScaleDown option is specified, don't scale down the resource request.
-					continue
-				}
+		reqAnnotation, limAnnotation := injector.ResourceAnnotations(k)
+		if reqAnnotation == "" || limAnnotation == "" {
+			continue
+		}
+		oldReq, ok := podTemplate.Annotations[reqAnnotation]
+		oldLim, ok2 := podTemplate.Annotations[limAnnotation]
+		if !ok || !ok2 {
+			continue
+		}
 
-				ratio := float64(newReq.MilliValue()) / float64(oldCPUReqQuantity.MilliValue())
-				podTemplate.Annotations[annotation.IstioSidecarProxyCPUAnnotation] = newReq.String()
-				podTemplate.Annotations[annotation.IstioSidecarProxyCPULimitAnnotation] = resource.NewMilliQuantity(int64(float64(oldCPULimQuantity.MilliValue())*ratio), oldCPULimQuantity.Format).String()
-			}
+		oldReqQuantity, err := resource.ParseQuantity(oldReq)
+		if err != nil {
+			continue
 		}
 
-		if k == v1.ResourceMemory {
-			oldMemReq, ok := podTemplate.Annotations[annotation.IstioSidecarProxyMemoryAnnotation]
-			oldMemLim, ok2 := podTemplate.Annotations[annotation.IstioSidecarProxyMemoryLimitAnnotation]
-			if ok && ok2 {
-				oldMemReqQuantity, err := resource.ParseQuantity(oldMemReq)
-				if err != nil {
-					continue
-				}
+		oldLimQuantity, err := resource.ParseQuantity(oldLim)
+		if err != nil {
+			continue
+		}
 
-				oldMemLimQuantity, err := resource.ParseQuantity(oldMemLim)
-				if err != nil {
-					continue
-				}
+		if containsOption(opts, NoScaleDown) && newReq.Cmp(oldReqQuantity) < 0 {
+			// If NoScaleDown option is specified, don't scale down the resource request.
+			continue
+		}
 
-				if containsOption(opts, NoScaleDown) && newReq.Cmp(oldMemReqQuantity) < 0 {
-					// If NoScaleDown option is specified, don't scale down the resource request.
-					continue
-				}
+		ratio := float64(newReq.MilliValue()) / float64(oldReqQuantity.MilliValue())
+		podTemplate.Annotations[reqAnnotation] = newReq.String()
+		podTemplate.Annotations[limAnnotation] = resource.NewMilliQuantity(int64(float64(oldLimQuantity.MilliValue())*ratio), oldLimQuantity.Format).String()
+	}
+}
 
-				ratio := float64(newReq.MilliValue()) / float64(oldMemReqQuantity.MilliValue())
-				podTemplate.Annotations[annotation.IstioSidecarProxyMemoryAnnotation] = newReq.String()
-				podTemplate.Annotations[annotation.IstioSidecarProxyMemoryLimitAnnotation] = resource.NewMilliQuantity(int64(float64(oldMemLimQuantity.MilliValue())*ratio), oldMemLimQuantity.Format).String()
+// SidecarResourceAnnotations returns all the annotations which configure the resources of the injected sidecars.
+func (s *Service) SidecarResourceAnnotations() []string {
+	annotations := []string{}
+	for _, injector := range s.sidecarInjectors {
+		for _, a := range []string{injector.CPURequestAnnotation, injector.CPULimitAnnotation, injector.MemoryRequestAnnotation, injector.MemoryLimitAnnotation} {
+			if a != "" {
+				annotations = append(annotations, a)
 			}
 		}
 	}
+	sort.Strings(annotations)
+	return annotations
 }
 
 type ModifyPodSpecResourceOption string
@@ -133,8 +144,11 @@ func (s *Service) ModifyPodSpecResource(podSpec *v1.PodSpec, t *v1beta3.Tortoise
 	requestChangeRatio := map[containerNameAndResource]float64{}
 	newRequestsMap := map[containerNameAndResource]resource.Quantity{}
 
+	// The native sidecars in the init containers are scaled in the same way as the containers.
+	containers := utils.ScalableContainers(podSpec)
+
 	// Update resource requests based on the tortoise.Status.Conditions.ContainerResourceRequests
-	for i, container := range podSpec.Containers {
+	for _, container := range containers {
 		for k, oldReq := range container.Resources.Requests {
 			newReq, ok := utils.GetRequestFromTortoise(t, container.Name, k)
 			if !ok {
@@ -147,17 +161,13 @@ func (s *Service) ModifyPodSpecResource(podSpec *v1.PodSpec, t *v1beta3.Tortoise
 			}
 			oldRequestsMap[containerNameAndResource{containerName: container.Name, resourceName: k}] = oldReq
 			newRequestsMap[containerNameAndResource{containerName: container.Name, resourceName: k}] = newReq
-			podSpec.Containers[i].Resources.Requests[k] = newReq
+			container.Resources.Requests[k] = newReq
 			requestChangeRatio[containerNameAndResource{containerName: container.Name, resourceName: k}] = float64(newReq.MilliValue()) / float64(oldReq.MilliValue())
 		}
 	}
 
 	// Update resource limits
-	for i, container := range podSpec.Containers {
-		if container.Resources.Limits == nil {
-			container.Resources.Limits = make(v1.ResourceList)
-		}
-
+	for _, container := range containers {
 		for k, oldLimit := range container.Resources.Limits {
 			// Keeping limit proportional to request.
 
@@ -180,81 +190,27 @@ func (s *Service) ModifyPodSpecResource(podSpec *v1.PodSpec, t *v1beta3.Tortoise
 			if k == v1.ResourceCPU && newLim.Cmp(s.minimumCPULimit) < 0 {
 				newLim = ptr.To(s.minimumCPULimit.DeepCopy())
 			}
-			podSpec.Containers[i].Resources.Limits[k] = *newLim
+			container.Resources.Limits[k] = *newLim
 		}
 	}
 
-	// Update GOMEMLIMIT and GOMAXPROCS
-	for i, container := range podSpec.Containers {
-		for j, env := range container.Env {
-			if env.Name == "GOMAXPROCS" {
-				// e.g., If CPU is increased twice, GOMAXPROCS should be doubled.
-				changeRatio, ok := requestChangeRatio[containerNameAndResource{
-					containerName: container.Name,
-					resourceName:  v1.ResourceCPU,
-				}]
-				if !ok {
-					continue
-				}
-				if len(env.Value) == 0 {
-					// Probably it's defined through the configmap.
-					continue
-				}
-				oldNum, err := strconv.Atoi(env.Value)
-				if err != nil {
-					// invalid GOMAXPROCS, skip
-					continue
-				}
-				newUncapedNum := float64(oldNum) * changeRatio
-				// GOMAXPROCS should be an integer.
-				newNum := int(math.Ceil(newUncapedNum))
-				podSpec.Containers[i].Env[j].Value = strconv.Itoa(newNum)
-
-			}
-
-			if !s.goMemLimitModificationEnabled {
-				// don't modify GOMEMLIMIT
-				continue
-			}
-
-			if env.Name == "GOMEMLIMIT" {
-				changeRatio, ok := requestChangeRatio[containerNameAndResource{
-					containerName: container.Name,
-					resourceName:  v1.ResourceMemory,
-				}]
-				if !ok {
-					continue
-				}
-				val := env.Value
-				if len(val) == 0 {
-					// Probably it's defined through the configmap.
-					continue
-				}
-				last := val[len(val)-1]
-				if last >= '0' && last <= '9' {
-					// OK
-				} else if last == 'B' {
-					// It should end with B.
-					val = val[:len(val)-1]
-				} else {
-					// invalid GOMEMLIMIT, skip
-					continue
-				}
-
-				oldNum, err := resource.ParseQuantity(val)
-				if err != nil {
-					// invalid GOMEMLIMIT, skip
-					continue
-				}
-				// See GOMEMLIMIT's format: https://pkg.go.dev/runtime#hdr-Environment_Variables
-				newNum := int(float64(oldNum.Value()) * changeRatio)
-				podSpec.Containers[i].Env[j].Value = strconv.Itoa(newNum)
+	// Update the runtime configuration in the environment variables (e.g., GOMAXPROCS) based on the request changes.
+	for _, container := range containers {
+		changeRatio := map[v1.ResourceName]float64{}
+		for _, k := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			if r, ok := requestChangeRatio[containerNameAndResource{containerName: container.Name, resourceName: k}]; ok {
+				changeRatio[k] = r
 			}
 		}
+		for _, tuner := range s.runtimeTuners {
+			tuner.Tune(container, changeRatio)
+		}
 	}
 }
 
-func (s *Service) GetDeploymentForPod(pod *v1.Pod) (string, error) {
+// GetScaleTargetForPod returns the reference to the workload (Deployment, StatefulSet or Rollout) which manages the Pod.
+// It returns nil if the Pod isn't managed by any workload that Tortoise supports.
+func (s *Service) GetScaleTargetForPod(pod *v1.Pod) (*autoscalingv2.CrossVersionObjectReference, error) {
 	var ownerRefrence *metav1.OwnerReference
 	for i := range pod.OwnerReferences {
 		r := pod.OwnerReferences[i]
@@ -264,12 +220,13 @@ func (s *Service) GetDeploymentForPod(pod *v1.Pod) (string, error) {
 	}
 	if ownerRefrence == nil {
 		// If the pod has no ownerReference, it cannot be under Tortoise.
-		return "", nil
+		return nil, nil
 	}
 
-	if ownerRefrence.Kind != "ReplicaSet" {
-		// Tortoise only supports Deployment for now, and ReplicaSet is the only controller that can own a pod in this case.
-		return "", nil
+	if ownerRefrence.Kind != "ReplicaSet" && ownerRefrence.Kind != "StatefulSet" {
+		// Tortoise only supports Deployment, StatefulSet and Rollout for now,
+		// and ReplicaSet (owned by Deployment or Rollout) or StatefulSet is the only controller that can own a pod in this case.
+		return nil, nil
 	}
 
 	k := &controllerfetcher.ControllerKeyWithAPIVersion{
@@ -283,15 +240,19 @@ func (s *Service) GetDeploymentForPod(pod *v1.Pod) (string, error) {
 
 	topController, err := s.controllerFetcher.FindTopMostWellKnownOrScalable(k)
 	if err != nil {
-		return "", fmt.Errorf("failed to find top most well known or scalable controller: %v", err)
+		return nil, fmt.Errorf("failed to find top most well known or scalable controller: %v", err)
 	}
 
-	if topController.Kind != "Deployment" {
-		// Tortoise only supports Deployment for now.
-		return "", nil
+	if topController.Kind != "Deployment" && topController.Kind != "StatefulSet" && topController.Kind != "Rollout" {
+		// Tortoise only supports Deployment, StatefulSet and Rollout for now.
+		return nil, nil
 	}
 
-	return topController.Name, nil
+	return &autoscalingv2.CrossVersionObjectReference{
+		Kind:       topController.Kind,
+		Name:       topController.Name,
+		APIVersion: topController.ApiVersion,
+	}, nil
 }
 
 type containerNameAndResource struct {
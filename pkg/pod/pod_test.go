@@ -5,16 +5,28 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	controllerfetcher "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/target/controller_fetcher"
 	"k8s.io/utils/ptr"
 
 	"github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/features"
 )
 
+var linkerdInjector = config.SidecarInjector{
+	InjectionAnnotation:      "linkerd.io/inject",
+	InjectionAnnotationValue: "enabled",
+	CPURequestAnnotation:     "config.linkerd.io/proxy-cpu-request",
+	CPULimitAnnotation:       "config.linkerd.io/proxy-cpu-limit",
+	MemoryRequestAnnotation:  "config.linkerd.io/proxy-memory-request",
+	MemoryLimitAnnotation:    "config.linkerd.io/proxy-memory-limit",
+}
+
 func TestService_ModifyPodTemplateResource(t *testing.T) {
 	type args struct {
 		podTemplate *v1.PodTemplateSpec
@@ -202,10 +214,136 @@ func TestService_ModifyPodTemplateResource(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Tortoise is Auto; the annotations of the linkerd proxy are changed",
+			args: args{
+				podTemplate: &v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							"linkerd.io/inject":                      "enabled",
+							"config.linkerd.io/proxy-cpu-request":    "100m",
+							"config.linkerd.io/proxy-cpu-limit":      "200m",
+							"config.linkerd.io/proxy-memory-request": "100Mi",
+							"config.linkerd.io/proxy-memory-limit":   "100Mi",
+						},
+					},
+				},
+				tortoise: &v1beta3.Tortoise{
+					Spec: v1beta3.TortoiseSpec{
+						UpdateMode: v1beta3.UpdateModeAuto,
+					},
+					Status: v1beta3.TortoiseStatus{
+						TortoisePhase: v1beta3.TortoisePhaseWorking,
+						Conditions: v1beta3.Conditions{
+							ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+								{
+									ContainerName: "linkerd-proxy",
+									Resource: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("200m"),
+										v1.ResourceMemory: resource.MustParse("200Mi"),
+									},
+								},
+								{
+									// istio-proxy isn't injected.
+									ContainerName: "istio-proxy",
+									Resource: v1.ResourceList{
+										v1.ResourceCPU: resource.MustParse("200m"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"linkerd.io/inject":                      "enabled",
+						"config.linkerd.io/proxy-cpu-request":    "200m",
+						"config.linkerd.io/proxy-cpu-limit":      "400m",
+						"config.linkerd.io/proxy-memory-request": "200Mi",
+						"config.linkerd.io/proxy-memory-limit":   "200Mi",
+					},
+				},
+			},
+		},
+		{
+			name: "istio-proxy is a native sidecar; its resources are modified instead of the istio annotations",
+			args: args{
+				podTemplate: &v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{
+							annotation.IstioSidecarInjectionAnnotation:     "true",
+							annotation.IstioSidecarProxyCPUAnnotation:      "100m",
+							annotation.IstioSidecarProxyCPULimitAnnotation: "200m",
+						},
+					},
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{
+							{
+								Name:          "istio-proxy",
+								RestartPolicy: ptr.To(v1.ContainerRestartPolicyAlways),
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("100m"),
+										v1.ResourceMemory: resource.MustParse("100Mi"),
+									},
+								},
+							},
+						},
+					},
+				},
+				tortoise: &v1beta3.Tortoise{
+					Spec: v1beta3.TortoiseSpec{
+						UpdateMode: v1beta3.UpdateModeAuto,
+					},
+					Status: v1beta3.TortoiseStatus{
+						TortoisePhase: v1beta3.TortoisePhaseWorking,
+						Conditions: v1beta3.Conditions{
+							ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+								{
+									ContainerName: "istio-proxy",
+									Resource: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("300m"),
+										v1.ResourceMemory: resource.MustParse("200Mi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						annotation.IstioSidecarInjectionAnnotation:     "true",
+						annotation.IstioSidecarProxyCPUAnnotation:      "100m",
+						annotation.IstioSidecarProxyCPULimitAnnotation: "200m",
+					},
+				},
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{
+						{
+							Name:          "istio-proxy",
+							RestartPolicy: ptr.To(v1.ContainerRestartPolicyAlways),
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									v1.ResourceCPU:    resource.MustParse("300m"),
+									v1.ResourceMemory: resource.MustParse("200Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s, err := New(nil, "", nil, nil)
+			s, err := New(nil, "", nil, map[string]config.SidecarInjector{
+				config.IstioProxyContainerName: config.IstioSidecarInjector("", ""),
+				"linkerd-proxy":                linkerdInjector,
+			}, nil)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
@@ -1157,10 +1295,125 @@ func TestService_ModifyPodSpecResource(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "native sidecars are modified as well as containers, but the other init containers aren't",
+			args: args{
+				pod: &v1.Pod{
+					Spec: v1.PodSpec{
+						InitContainers: []v1.Container{
+							{
+								Name: "init",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU: resource.MustParse("100m"),
+									},
+								},
+							},
+							{
+								Name:          "sidecar",
+								RestartPolicy: ptr.To(v1.ContainerRestartPolicyAlways),
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("100m"),
+										v1.ResourceMemory: resource.MustParse("100Mi"),
+									},
+									Limits: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("200m"),
+										v1.ResourceMemory: resource.MustParse("200Mi"),
+									},
+								},
+							},
+						},
+						Containers: []v1.Container{
+							{
+								Name: "container",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("100m"),
+										v1.ResourceMemory: resource.MustParse("100Mi"),
+									},
+								},
+							},
+						},
+					},
+				},
+				tortoise: &v1beta3.Tortoise{
+					Spec: v1beta3.TortoiseSpec{
+						UpdateMode: v1beta3.UpdateModeAuto,
+					},
+					Status: v1beta3.TortoiseStatus{
+						TortoisePhase: v1beta3.TortoisePhaseWorking,
+						Conditions: v1beta3.Conditions{
+							ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+								{
+									ContainerName: "init",
+									Resource: v1.ResourceList{
+										v1.ResourceCPU: resource.MustParse("300m"),
+									},
+								},
+								{
+									ContainerName: "sidecar",
+									Resource: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("200m"),
+										v1.ResourceMemory: resource.MustParse("300Mi"),
+									},
+								},
+								{
+									ContainerName: "container",
+									Resource: v1.ResourceList{
+										v1.ResourceCPU:    resource.MustParse("300m"),
+										v1.ResourceMemory: resource.MustParse("200Mi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &v1.Pod{
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{
+						{
+							Name: "init",
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									v1.ResourceCPU: resource.MustParse("100m"),
+								},
+							},
+						},
+						{
+							Name:          "sidecar",
+							RestartPolicy: ptr.To(v1.ContainerRestartPolicyAlways),
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									v1.ResourceCPU:    resource.MustParse("200m"),
+									v1.ResourceMemory: resource.MustParse("300Mi"),
+								},
+								Limits: v1.ResourceList{
+									v1.ResourceCPU:    resource.MustParse("400m"),
+									v1.ResourceMemory: resource.MustParse("600Mi"),
+								},
+							},
+						},
+					},
+					Containers: []v1.Container{
+						{
+							Name: "container",
+							Resources: v1.ResourceRequirements{
+								Requests: v1.ResourceList{
+									v1.ResourceCPU:    resource.MustParse("300m"),
+									v1.ResourceMemory: resource.MustParse("200Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s, err := New(tt.fields.resourceLimitMultiplier, tt.fields.minimumCPULimit, nil, tt.fields.featureFlags)
+			s, err := New(tt.fields.resourceLimitMultiplier, tt.fields.minimumCPULimit, nil, map[string]config.SidecarInjector{config.IstioProxyContainerName: config.IstioSidecarInjector("", "")}, tt.fields.featureFlags)
 			if err != nil {
 				t.Fatalf("New() error = %v", err)
 			}
@@ -1172,3 +1425,77 @@ func TestService_ModifyPodSpecResource(t *testing.T) {
 		})
 	}
 }
+
+type fakeControllerFetcher struct {
+	// parents maps the name of the controller to its parent.
+	parents map[string]*controllerfetcher.ControllerKeyWithAPIVersion
+}
+
+func (f *fakeControllerFetcher) FindTopMostWellKnownOrScalable(key *controllerfetcher.ControllerKeyWithAPIVersion) (*controllerfetcher.ControllerKeyWithAPIVersion, error) {
+	if p, ok := f.parents[key.Name]; ok {
+		return p, nil
+	}
+	return key, nil
+}
+
+func TestService_GetScaleTargetForPod(t *testing.T) {
+	cf := &fakeControllerFetcher{
+		parents: map[string]*controllerfetcher.ControllerKeyWithAPIVersion{
+			"app-12345": {
+				ControllerKey: controllerfetcher.ControllerKey{Namespace: "default", Kind: "Deployment", Name: "app"},
+				ApiVersion:    "apps/v1",
+			},
+			"canary-12345": {
+				ControllerKey: controllerfetcher.ControllerKey{Namespace: "default", Kind: "Rollout", Name: "canary"},
+				ApiVersion:    "argoproj.io/v1alpha1",
+			},
+		},
+	}
+	tests := []struct {
+		name   string
+		owners []metav1.OwnerReference
+		want   *autoscalingv2.CrossVersionObjectReference
+	}{
+		{
+			name:   "Pod managed by Deployment",
+			owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "app-12345", Controller: ptr.To(true)}},
+			want:   &autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "app", APIVersion: "apps/v1"},
+		},
+		{
+			name:   "Pod managed by StatefulSet",
+			owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "app", Controller: ptr.To(true)}},
+			want:   &autoscalingv2.CrossVersionObjectReference{Kind: "StatefulSet", Name: "app", APIVersion: "apps/v1"},
+		},
+		{
+			name:   "Pod managed by Rollout",
+			owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "canary-12345", Controller: ptr.To(true)}},
+			want:   &autoscalingv2.CrossVersionObjectReference{Kind: "Rollout", Name: "canary", APIVersion: "argoproj.io/v1alpha1"},
+		},
+		{
+			name:   "Pod managed by DaemonSet",
+			owners: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "app", Controller: ptr.To(true)}},
+			want:   nil,
+		},
+		{
+			name:   "Pod without controller",
+			owners: nil,
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(map[string]int64{}, "0", cf, nil, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-0", OwnerReferences: tt.owners}}
+			got, err := s.GetScaleTargetForPod(pod)
+			if err != nil {
+				t.Fatalf("GetScaleTargetForPod() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("GetScaleTargetForPod() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
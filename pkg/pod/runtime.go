@@ -0,0 +1,249 @@
+package pod
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/mercari/tortoise/pkg/features"
+)
+
+// RuntimeTuner keeps the runtime configuration given through the environment variables (e.g., GOMAXPROCS)
+// proportional to the resource requests of the container.
+type RuntimeTuner interface {
+	// Tune updates the environment variables of the container.
+	// changeRatio is the ratio of the new request to the old one per resource,
+	// e.g., if the CPU request is changed 100m → 200m, changeRatio[cpu] is 2.
+	// The resource which has no request isn't in changeRatio.
+	Tune(container *v1.Container, changeRatio map[v1.ResourceName]float64)
+}
+
+// runtimeTuners returns the RuntimeTuners enabled by the feature flags.
+func runtimeTuners(featureFlags []features.FeatureFlag) []RuntimeTuner {
+	tuners := []RuntimeTuner{
+		// GOMAXPROCS is always modified, and GOMEMLIMIT is only modified with the feature flag.
+		&goRuntimeTuner{memLimitModificationEnabled: features.Contains(featureFlags, features.GoMemLimitModificationEnabled)},
+	}
+	if features.Contains(featureFlags, features.JVMOptionsModificationEnabled) {
+		tuners = append(tuners, &jvmRuntimeTuner{})
+	}
+	if features.Contains(featureFlags, features.NodeOptionsModificationEnabled) {
+		tuners = append(tuners, &nodeRuntimeTuner{})
+	}
+	return tuners
+}
+
+// goRuntimeTuner modifies GOMAXPROCS and GOMEMLIMIT.
+type goRuntimeTuner struct {
+	memLimitModificationEnabled bool
+}
+
+func (g *goRuntimeTuner) Tune(container *v1.Container, changeRatio map[v1.ResourceName]float64) {
+	for j, env := range container.Env {
+		if env.Name == "GOMAXPROCS" {
+			// e.g., If CPU is increased twice, GOMAXPROCS should be doubled.
+			ratio, ok := changeRatio[v1.ResourceCPU]
+			if !ok {
+				continue
+			}
+			if len(env.Value) == 0 {
+				// Probably it's defined through the configmap.
+				continue
+			}
+			oldNum, err := strconv.Atoi(env.Value)
+			if err != nil {
+				// invalid GOMAXPROCS, skip
+				continue
+			}
+			container.Env[j].Value = strconv.Itoa(scaleProcessorCount(oldNum, ratio))
+		}
+
+		if !g.memLimitModificationEnabled {
+			// don't modify GOMEMLIMIT
+			continue
+		}
+
+		if env.Name == "GOMEMLIMIT" {
+			ratio, ok := changeRatio[v1.ResourceMemory]
+			if !ok {
+				continue
+			}
+			val := env.Value
+			if len(val) == 0 {
+				// Probably it's defined through the configmap.
+				continue
+			}
+			last := val[len(val)-1]
+			if last >= '0' && last <= '9' {
+				// OK
+			} else if last == 'B' {
+				// It should end with B.
+				val = val[:len(val)-1]
+			} else {
+				// invalid GOMEMLIMIT, skip
+				continue
+			}
+
+			oldNum, err := resource.ParseQuantity(val)
+			if err != nil {
+				// invalid GOMEMLIMIT, skip
+				continue
+			}
+			// See GOMEMLIMIT's format: https://pkg.go.dev/runtime#hdr-Environment_Variables
+			newNum := int(float64(oldNum.Value()) * ratio)
+			container.Env[j].Value = strconv.Itoa(newNum)
+		}
+	}
+}
+
+// jvmOptionsEnvs are the environment variables which the JVM or the launcher scripts read the options from.
+var jvmOptionsEnvs = []string{"JAVA_TOOL_OPTIONS", "JAVA_OPTS"}
+
+// jvmRuntimeTuner modifies -Xmx, -Xms and -XX:ActiveProcessorCount in JAVA_TOOL_OPTIONS and JAVA_OPTS.
+// https://docs.oracle.com/en/java/javase/21/docs/specs/man/java.html
+type jvmRuntimeTuner struct{}
+
+func (j *jvmRuntimeTuner) Tune(container *v1.Container, changeRatio map[v1.ResourceName]float64) {
+	for i, env := range container.Env {
+		if !containsString(jvmOptionsEnvs, env.Name) || len(env.Value) == 0 {
+			// Probably it's defined through the configmap if it's empty.
+			continue
+		}
+
+		options := strings.Split(env.Value, " ")
+		for k, o := range options {
+			// -Xms is also modified so that it doesn't get larger than -Xmx, which prevents the JVM from starting.
+			for _, prefix := range []string{"-Xmx", "-Xms"} {
+				if !strings.HasPrefix(o, prefix) {
+					continue
+				}
+				ratio, ok := changeRatio[v1.ResourceMemory]
+				if !ok {
+					continue
+				}
+				oldSize, ok := parseJVMSize(strings.TrimPrefix(o, prefix))
+				if !ok {
+					// invalid heap size, skip
+					continue
+				}
+				options[k] = prefix + formatJVMSize(int64(float64(oldSize)*ratio))
+			}
+
+			if strings.HasPrefix(o, "-XX:ActiveProcessorCount=") {
+				ratio, ok := changeRatio[v1.ResourceCPU]
+				if !ok {
+					continue
+				}
+				oldNum, err := strconv.Atoi(strings.TrimPrefix(o, "-XX:ActiveProcessorCount="))
+				if err != nil {
+					// invalid -XX:ActiveProcessorCount, skip
+					continue
+				}
+				options[k] = "-XX:ActiveProcessorCount=" + strconv.Itoa(scaleProcessorCount(oldNum, ratio))
+			}
+		}
+		container.Env[i].Value = strings.Join(options, " ")
+	}
+}
+
+var jvmSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{suffix: "t", bytes: 1 << 40},
+	{suffix: "g", bytes: 1 << 30},
+	{suffix: "m", bytes: 1 << 20},
+	{suffix: "k", bytes: 1 << 10},
+}
+
+// parseJVMSize parses the size in the JVM option (e.g., 512m) into bytes.
+func parseJVMSize(val string) (int64, bool) {
+	if len(val) == 0 {
+		return 0, false
+	}
+	unit := int64(1)
+	for _, u := range jvmSizeUnits {
+		if strings.HasSuffix(strings.ToLower(val), u.suffix) {
+			unit = u.bytes
+			val = val[:len(val)-1]
+			break
+		}
+	}
+	num, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || num <= 0 {
+		return 0, false
+	}
+	return num * unit, true
+}
+
+// formatJVMSize formats bytes into the size in the JVM option with the largest unit which can represent it exactly.
+// The size is rounded down to a multiple of 1024 because the JVM requires the heap size to be so.
+func formatJVMSize(bytes int64) string {
+	bytes = bytes / 1024 * 1024
+	if bytes < 1024 {
+		bytes = 1024
+	}
+	for _, u := range jvmSizeUnits {
+		if bytes%u.bytes == 0 {
+			return strconv.FormatInt(bytes/u.bytes, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// nodeRuntimeTuner modifies --max-old-space-size in NODE_OPTIONS.
+// https://nodejs.org/api/cli.html#--max-old-space-sizesize-in-megabytes
+type nodeRuntimeTuner struct{}
+
+func (n *nodeRuntimeTuner) Tune(container *v1.Container, changeRatio map[v1.ResourceName]float64) {
+	ratio, ok := changeRatio[v1.ResourceMemory]
+	if !ok {
+		return
+	}
+	for i, env := range container.Env {
+		if env.Name != "NODE_OPTIONS" || len(env.Value) == 0 {
+			// Probably it's defined through the configmap if it's empty.
+			continue
+		}
+
+		options := strings.Split(env.Value, " ")
+		for k, o := range options {
+			// V8 accepts both the hyphens and the underscores in the flag name.
+			for _, prefix := range []string{"--max-old-space-size=", "--max_old_space_size="} {
+				if !strings.HasPrefix(o, prefix) {
+					continue
+				}
+				oldNum, err := strconv.Atoi(strings.TrimPrefix(o, prefix))
+				if err != nil {
+					// invalid --max-old-space-size, skip
+					continue
+				}
+				// The size is in megabytes.
+				newNum := int(float64(oldNum) * ratio)
+				if newNum < 1 {
+					newNum = 1
+				}
+				options[k] = prefix + strconv.Itoa(newNum)
+			}
+		}
+		container.Env[i].Value = strings.Join(options, " ")
+	}
+}
+
+// scaleProcessorCount scales the number of processors (e.g., GOMAXPROCS) by the CPU request change ratio.
+func scaleProcessorCount(oldNum int, ratio float64) int {
+	// The number of processors should be an integer.
+	return int(math.Ceil(float64(oldNum) * ratio))
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
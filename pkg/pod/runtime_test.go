@@ -0,0 +1,117 @@
+package pod
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/mercari/tortoise/pkg/features"
+)
+
+func TestRuntimeTuners(t *testing.T) {
+	tests := []struct {
+		name         string
+		featureFlags []features.FeatureFlag
+		env          []v1.EnvVar
+		changeRatio  map[v1.ResourceName]float64
+		want         []v1.EnvVar
+	}{
+		{
+			name: "only GOMAXPROCS is modified without feature flags",
+			env: []v1.EnvVar{
+				{Name: "GOMAXPROCS", Value: "2"},
+				{Name: "GOMEMLIMIT", Value: "100MiB"},
+				{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmx512m -XX:ActiveProcessorCount=2"},
+				{Name: "NODE_OPTIONS", Value: "--max-old-space-size=512"},
+			},
+			changeRatio: map[v1.ResourceName]float64{v1.ResourceCPU: 1.5, v1.ResourceMemory: 2},
+			want: []v1.EnvVar{
+				{Name: "GOMAXPROCS", Value: "3"},
+				{Name: "GOMEMLIMIT", Value: "100MiB"},
+				{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmx512m -XX:ActiveProcessorCount=2"},
+				{Name: "NODE_OPTIONS", Value: "--max-old-space-size=512"},
+			},
+		},
+		{
+			name:         "JVM options are modified",
+			featureFlags: []features.FeatureFlag{features.JVMOptionsModificationEnabled},
+			env: []v1.EnvVar{
+				{Name: "JAVA_TOOL_OPTIONS", Value: "-XX:+UseG1GC -Xms256m -Xmx1G -XX:ActiveProcessorCount=2"},
+				{Name: "JAVA_OPTS", Value: "-Xmx1024k"},
+				{Name: "OTHER_OPTS", Value: "-Xmx512m"},
+			},
+			changeRatio: map[v1.ResourceName]float64{v1.ResourceCPU: 0.7, v1.ResourceMemory: 1.5},
+			want: []v1.EnvVar{
+				{Name: "JAVA_TOOL_OPTIONS", Value: "-XX:+UseG1GC -Xms384m -Xmx1536m -XX:ActiveProcessorCount=2"},
+				{Name: "JAVA_OPTS", Value: "-Xmx1536k"},
+				{Name: "OTHER_OPTS", Value: "-Xmx512m"},
+			},
+		},
+		{
+			name:         "JVM options are not modified if the request is unknown or the option is invalid",
+			featureFlags: []features.FeatureFlag{features.JVMOptionsModificationEnabled},
+			env: []v1.EnvVar{
+				{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmxinvalid -XX:ActiveProcessorCount=2"},
+				{Name: "JAVA_OPTS", ValueFrom: &v1.EnvVarSource{}},
+			},
+			changeRatio: map[v1.ResourceName]float64{v1.ResourceMemory: 2},
+			want: []v1.EnvVar{
+				{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmxinvalid -XX:ActiveProcessorCount=2"},
+				{Name: "JAVA_OPTS", ValueFrom: &v1.EnvVarSource{}},
+			},
+		},
+		{
+			name:         "Node.js options are modified",
+			featureFlags: []features.FeatureFlag{features.NodeOptionsModificationEnabled},
+			env: []v1.EnvVar{
+				{Name: "NODE_OPTIONS", Value: "--enable-source-maps --max-old-space-size=512"},
+				{Name: "OTHER_OPTIONS", Value: "--max-old-space-size=512"},
+			},
+			changeRatio: map[v1.ResourceName]float64{v1.ResourceCPU: 2, v1.ResourceMemory: 0.5},
+			want: []v1.EnvVar{
+				{Name: "NODE_OPTIONS", Value: "--enable-source-maps --max-old-space-size=256"},
+				{Name: "OTHER_OPTIONS", Value: "--max-old-space-size=512"},
+			},
+		},
+		{
+			name:         "Node.js options with the underscores are modified",
+			featureFlags: []features.FeatureFlag{features.NodeOptionsModificationEnabled},
+			env: []v1.EnvVar{
+				{Name: "NODE_OPTIONS", Value: "--max_old_space_size=1000"},
+			},
+			changeRatio: map[v1.ResourceName]float64{v1.ResourceMemory: 1.5},
+			want: []v1.EnvVar{
+				{Name: "NODE_OPTIONS", Value: "--max_old_space_size=1500"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &v1.Container{Name: "app", Env: tt.env}
+			for _, tuner := range runtimeTuners(tt.featureFlags) {
+				tuner.Tune(c, tt.changeRatio)
+			}
+			if d := cmp.Diff(tt.want, c.Env); d != "" {
+				t.Errorf("Tune() mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestFormatJVMSize(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{bytes: 2 << 30, want: "2g"},
+		{bytes: 1536 << 20, want: "1536m"},
+		{bytes: 1536<<10 + 100, want: "1536k"},
+		{bytes: 100, want: "1k"},
+	}
+	for _, tt := range tests {
+		if got := formatJVMSize(tt.bytes); got != tt.want {
+			t.Errorf("formatJVMSize(%d) = %s, want %s", tt.bytes, got, tt.want)
+		}
+	}
+}
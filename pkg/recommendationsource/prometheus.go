@@ -0,0 +1,173 @@
+package recommendationsource
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	promapi "github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+)
+
+const (
+	// targetQuantile is the quantile of the usage used as the target recommendation.
+	// It's the same as the VPA recommender's default (--target-cpu-percentile and --target-memory-percentile).
+	targetQuantile = 0.9
+	// cpuUpperBoundQuantile is the quantile of the CPU usage used as the upper bound recommendation.
+	cpuUpperBoundQuantile = 0.99
+)
+
+// PrometheusSource gets the recommendation by querying the container usage metrics (cAdvisor metrics) from Prometheus.
+// It doesn't require the VPA recommender in the cluster.
+//
+// - Target: the 90th percentile of the usage during the window.
+// - UpperBound: the 99th percentile of the CPU usage, and the maximum memory usage during the window.
+type PrometheusSource struct {
+	api promv1.API
+	// window is the time range of the usage to calculate the recommendation.
+	window time.Duration
+}
+
+var _ Source = &PrometheusSource{}
+
+func NewPrometheusSource(address string, window time.Duration) (*PrometheusSource, error) {
+	cli, err := promapi.NewClient(promapi.Config{Address: address})
+	if err != nil {
+		return nil, fmt.Errorf("create prometheus client: %w", err)
+	}
+	return &PrometheusSource{api: promv1.NewAPI(cli), window: window}, nil
+}
+
+// Initialize does nothing because Prometheus doesn't need any object per tortoise.
+func (s *PrometheusSource) Initialize(_ context.Context, tortoise *autoscalingv1beta3.Tortoise) (*autoscalingv1beta3.Tortoise, error) {
+	return tortoise, nil
+}
+
+// Delete does nothing because Prometheus doesn't need any object per tortoise.
+func (s *PrometheusSource) Delete(_ context.Context, _ *autoscalingv1beta3.Tortoise) error {
+	return nil
+}
+
+func (s *PrometheusSource) GetRecommendation(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, now time.Time) ([]vpav1.RecommendedContainerResources, bool, error) {
+	selector := podSelector(tortoise)
+	window := model.Duration(s.window).String()
+
+	queries := []struct {
+		resourceName corev1.ResourceName
+		upperBound   bool
+		query        string
+	}{
+		{
+			resourceName: corev1.ResourceCPU,
+			query:        fmt.Sprintf(`max by (container) (quantile_over_time(%v, rate(container_cpu_usage_seconds_total{%s}[5m])[%s:5m]))`, targetQuantile, selector, window),
+		},
+		{
+			resourceName: corev1.ResourceCPU,
+			upperBound:   true,
+			query:        fmt.Sprintf(`max by (container) (quantile_over_time(%v, rate(container_cpu_usage_seconds_total{%s}[5m])[%s:5m]))`, cpuUpperBoundQuantile, selector, window),
+		},
+		{
+			resourceName: corev1.ResourceMemory,
+			query:        fmt.Sprintf(`max by (container) (quantile_over_time(%v, container_memory_working_set_bytes{%s}[%s]))`, targetQuantile, selector, window),
+		},
+		{
+			resourceName: corev1.ResourceMemory,
+			upperBound:   true,
+			query:        fmt.Sprintf(`max by (container) (max_over_time(container_memory_working_set_bytes{%s}[%s]))`, selector, window),
+		},
+	}
+
+	// containerName → recommendation
+	recommendationMap := map[string]*vpav1.RecommendedContainerResources{}
+	var containerNames []string
+	for _, q := range queries {
+		values, err := s.query(ctx, q.query, now)
+		if err != nil {
+			return nil, false, fmt.Errorf("get %s recommendation from prometheus: %w", q.resourceName, err)
+		}
+		for containerName, v := range values {
+			r, ok := recommendationMap[containerName]
+			if !ok {
+				r = &vpav1.RecommendedContainerResources{
+					ContainerName: containerName,
+					Target:        corev1.ResourceList{},
+					UpperBound:    corev1.ResourceList{},
+				}
+				recommendationMap[containerName] = r
+				containerNames = append(containerNames, containerName)
+			}
+			if q.upperBound {
+				r.UpperBound[q.resourceName] = toQuantity(q.resourceName, v)
+			} else {
+				r.Target[q.resourceName] = toQuantity(q.resourceName, v)
+			}
+		}
+	}
+
+	recommendations := make([]vpav1.RecommendedContainerResources, 0, len(containerNames))
+	for _, n := range containerNames {
+		recommendations = append(recommendations, *recommendationMap[n])
+	}
+
+	return recommendations, hasRecommendationForAllContainers(recommendations, tortoise), nil
+}
+
+// query runs the instant query and returns the value per container.
+func (s *PrometheusSource) query(ctx context.Context, query string, now time.Time) (map[string]float64, error) {
+	result, warnings, err := s.api.Query(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 {
+		log.FromContext(ctx).Info("prometheus returns warnings", "query", query, "warnings", warnings)
+	}
+
+	vector, ok := result.(model.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result type: %s", result.Type())
+	}
+
+	values := make(map[string]float64, len(vector))
+	for _, sample := range vector {
+		v := float64(sample.Value)
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		values[string(sample.Metric["container"])] = v
+	}
+	return values, nil
+}
+
+// podSelector returns the label selector for the cAdvisor metrics of the Pods in the scale target.
+// The Pods are found by their name because cAdvisor metrics don't have the labels of the Pods.
+func podSelector(tortoise *autoscalingv1beta3.Tortoise) string {
+	name := regexp.QuoteMeta(tortoise.Spec.TargetRefs.ScaleTargetRef.Name)
+	var podRegex string
+	switch tortoise.Spec.TargetRefs.ScaleTargetRef.Kind {
+	case "StatefulSet":
+		// {statefulset name}-{ordinal}
+		podRegex = name + `-[0-9]+`
+	default:
+		// {deployment name}-{replicaset hash}-{random suffix}
+		podRegex = name + `-[a-z0-9]+-[a-z0-9]+`
+	}
+
+	return fmt.Sprintf(`namespace=%s, pod=~%s, container!="", container!="POD"`, strconv.Quote(tortoise.Namespace), strconv.Quote(podRegex))
+}
+
+func toQuantity(resourceName corev1.ResourceName, v float64) resource.Quantity {
+	if resourceName == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(math.Ceil(v*1000)), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(int64(math.Ceil(v)), resource.BinarySI)
+}
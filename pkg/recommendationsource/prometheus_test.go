@@ -0,0 +1,231 @@
+package recommendationsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+)
+
+// fakePrometheus returns the value per container for each query.
+// The key of results is a substring of the query to match.
+func fakePrometheus(t *testing.T, results map[string]map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/query" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query := r.Form.Get("query")
+		if !strings.Contains(query, `namespace="default"`) || !strings.Contains(query, `pod=~"app-[a-z0-9]+-[a-z0-9]+"`) {
+			t.Errorf("unexpected selector in the query: %s", query)
+		}
+
+		var samples []string
+		for substr, values := range results {
+			if !strings.Contains(query, substr) {
+				continue
+			}
+			for container, v := range values {
+				samples = append(samples, fmt.Sprintf(`{"metric":{"container":%q},"value":[1700000000,%q]}`, container, v))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, strings.Join(samples, ","))
+	}))
+}
+
+func TestPrometheusSource_GetRecommendation(t *testing.T) {
+	tortoise := &autoscalingv1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: autoscalingv1beta3.TortoiseSpec{
+			TargetRefs: autoscalingv1beta3.TargetRefs{
+				ScaleTargetRef: autoscalingv1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"},
+			},
+		},
+		Status: autoscalingv1beta3.TortoiseStatus{
+			AutoscalingPolicy: []autoscalingv1beta3.ContainerAutoscalingPolicy{
+				{ContainerName: "app"},
+				{ContainerName: "istio-proxy"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		results   map[string]map[string]string
+		want      []vpav1.RecommendedContainerResources
+		wantReady bool
+	}{
+		{
+			name: "all containers have the recommendation",
+			results: map[string]map[string]string{
+				"quantile_over_time(0.9, rate(container_cpu_usage_seconds_total":  {"app": "0.5", "istio-proxy": "0.1001"},
+				"quantile_over_time(0.99, rate(container_cpu_usage_seconds_total": {"app": "1", "istio-proxy": "0.2"},
+				"quantile_over_time(0.9, container_memory_working_set_bytes":      {"app": "1073741824", "istio-proxy": "104857600"},
+				"max_over_time(container_memory_working_set_bytes":                {"app": "2147483648", "istio-proxy": "209715200"},
+			},
+			want: []vpav1.RecommendedContainerResources{
+				{
+					ContainerName: "app",
+					Target: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					UpperBound: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+					},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Target: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("101m"), // rounded up
+						corev1.ResourceMemory: resource.MustParse("100Mi"),
+					},
+					UpperBound: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("200m"),
+						corev1.ResourceMemory: resource.MustParse("200Mi"),
+					},
+				},
+			},
+			wantReady: true,
+		},
+		{
+			name: "some container doesn't have the memory recommendation yet",
+			results: map[string]map[string]string{
+				"quantile_over_time(0.9, rate(container_cpu_usage_seconds_total":  {"app": "0.5", "istio-proxy": "0.1"},
+				"quantile_over_time(0.99, rate(container_cpu_usage_seconds_total": {"app": "1", "istio-proxy": "0.2"},
+				"quantile_over_time(0.9, container_memory_working_set_bytes":      {"app": "1073741824"},
+				"max_over_time(container_memory_working_set_bytes":                {"app": "2147483648"},
+			},
+			want: []vpav1.RecommendedContainerResources{
+				{
+					ContainerName: "app",
+					Target: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					UpperBound: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("2Gi"),
+					},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Target: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100m"),
+					},
+					UpperBound: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("200m"),
+					},
+				},
+			},
+			wantReady: false,
+		},
+		{
+			name:      "no data",
+			results:   map[string]map[string]string{},
+			want:      []vpav1.RecommendedContainerResources{},
+			wantReady: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := fakePrometheus(t, tt.results)
+			defer server.Close()
+
+			s, err := NewPrometheusSource(server.URL, 7*24*time.Hour)
+			if err != nil {
+				t.Fatalf("NewPrometheusSource() error = %v", err)
+			}
+			got, ready, err := s.GetRecommendation(context.Background(), tortoise, time.Now())
+			if err != nil {
+				t.Fatalf("GetRecommendation() error = %v", err)
+			}
+			if ready != tt.wantReady {
+				t.Errorf("GetRecommendation() ready = %v, want %v", ready, tt.wantReady)
+			}
+			// The order of containers depends on the response from Prometheus.
+			gotMap := map[string]vpav1.RecommendedContainerResources{}
+			for _, r := range got {
+				gotMap[r.ContainerName] = r
+			}
+			wantMap := map[string]vpav1.RecommendedContainerResources{}
+			for _, r := range tt.want {
+				wantMap[r.ContainerName] = r
+			}
+			if d := cmp.Diff(wantMap, gotMap, cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 })); d != "" {
+				t.Errorf("GetRecommendation() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestPrometheusSource_GetRecommendation_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"invalid query"}`)
+	}))
+	defer server.Close()
+
+	s, err := NewPrometheusSource(server.URL, time.Hour)
+	if err != nil {
+		t.Fatalf("NewPrometheusSource() error = %v", err)
+	}
+	if _, _, err := s.GetRecommendation(context.Background(), &autoscalingv1beta3.Tortoise{}, time.Now()); err == nil {
+		t.Errorf("GetRecommendation() should return an error when Prometheus returns an error")
+	}
+}
+
+func Test_podSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		tortoise *autoscalingv1beta3.Tortoise
+		want     string
+	}{
+		{
+			name: "Deployment",
+			tortoise: &autoscalingv1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+				Spec: autoscalingv1beta3.TortoiseSpec{TargetRefs: autoscalingv1beta3.TargetRefs{
+					ScaleTargetRef: autoscalingv1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app.v2"},
+				}},
+			},
+			want: `namespace="default", pod=~"app\\.v2-[a-z0-9]+-[a-z0-9]+", container!="", container!="POD"`,
+		},
+		{
+			name: "StatefulSet",
+			tortoise: &autoscalingv1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default"},
+				Spec: autoscalingv1beta3.TortoiseSpec{TargetRefs: autoscalingv1beta3.TargetRefs{
+					ScaleTargetRef: autoscalingv1beta3.CrossVersionObjectReference{Kind: "StatefulSet", Name: "db"},
+				}},
+			},
+			want: `namespace="default", pod=~"db-[0-9]+", container!="", container!="POD"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := podSelector(tt.tortoise); got != tt.want {
+				t.Errorf("podSelector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
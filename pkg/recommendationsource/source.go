@@ -0,0 +1,46 @@
+package recommendationsource
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+)
+
+// Source provides the resource recommendation for each container in the workload targeted by the tortoise.
+// The recommendation is stored in tortoise.Status.Conditions.ContainerRecommendationFromVPA,
+// and then used to calculate the resource requests and the HPA target utilization.
+//
+// The recommendation is represented with the VPA's RecommendedContainerResources
+// because the VPA recommender was the only source originally.
+// Only Target and UpperBound are used by tortoise.
+type Source interface {
+	// Initialize prepares whatever the source needs for the tortoise. It's called when the tortoise is initialized.
+	Initialize(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*autoscalingv1beta3.Tortoise, error)
+	// Delete removes whatever the source created for the tortoise. It's called when the tortoise is deleted.
+	Delete(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) error
+	// GetRecommendation returns the recommendation for each container.
+	// It returns false when the source cannot provide the recommendation for all the containers in the tortoise yet.
+	GetRecommendation(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, now time.Time) ([]vpav1.RecommendedContainerResources, bool, error)
+}
+
+// hasRecommendationForAllContainers checks if the recommendations have the non-zero CPU and memory target for all the containers in the tortoise.
+func hasRecommendationForAllContainers(recommendations []vpav1.RecommendedContainerResources, tortoise *autoscalingv1beta3.Tortoise) bool {
+	containerInTortoise := sets.New[string]()
+	for _, p := range tortoise.Status.AutoscalingPolicy {
+		containerInTortoise.Insert(p.ContainerName)
+	}
+
+	containerInRecommendation := sets.New[string]()
+	for _, r := range recommendations {
+		if r.Target.Cpu().IsZero() || r.Target.Memory().IsZero() {
+			continue
+		}
+		containerInRecommendation.Insert(r.ContainerName)
+	}
+
+	return containerInRecommendation.IsSuperset(containerInTortoise)
+}
@@ -0,0 +1,59 @@
+package recommendationsource
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/vpa"
+)
+
+// VPASource gets the recommendation from the monitor VPA that tortoise creates for each tortoise.
+// It requires the VPA recommender to be running in the cluster.
+type VPASource struct {
+	vpaService *vpa.Service
+}
+
+var _ Source = &VPASource{}
+
+func NewVPASource(vpaService *vpa.Service) *VPASource {
+	return &VPASource{vpaService: vpaService}
+}
+
+func (s *VPASource) Initialize(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*autoscalingv1beta3.Tortoise, error) {
+	_, tortoise, err := s.vpaService.CreateTortoiseMonitorVPA(ctx, tortoise)
+	if err != nil {
+		return tortoise, fmt.Errorf("create tortoise monitor VPA: %w", err)
+	}
+	return tortoise, nil
+}
+
+func (s *VPASource) Delete(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) error {
+	if err := s.vpaService.DeleteTortoiseMonitorVPA(ctx, tortoise); err != nil {
+		return fmt.Errorf("delete monitor VPA created by tortoise: %w", err)
+	}
+	return nil
+}
+
+func (s *VPASource) GetRecommendation(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise, _ time.Time) ([]vpav1.RecommendedContainerResources, bool, error) {
+	monitorvpa, ready, err := s.vpaService.GetTortoiseMonitorVPA(ctx, tortoise)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ready {
+		return nil, false, nil
+	}
+
+	if _, err := s.vpaService.UpdateVPAContainerResourcePolicy(ctx, tortoise, monitorvpa); err != nil {
+		return nil, false, fmt.Errorf("update VPA Container Resource Policy: %w", err)
+	}
+
+	var recommendations []vpav1.RecommendedContainerResources
+	if monitorvpa.Status.Recommendation != nil {
+		recommendations = monitorvpa.Status.Recommendation.ContainerRecommendations
+	}
+	return recommendations, true, nil
+}
@@ -17,6 +17,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/calendar"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/event"
 	"github.com/mercari/tortoise/pkg/features"
 	hpaservice "github.com/mercari/tortoise/pkg/hpa"
@@ -44,6 +46,22 @@ type Service struct {
 	maxAllowedScalingDownRatio float64
 
 	bufferRatioOnVerticalResource float64
+	// metricTargetBounds is the range of the target of the Pods and External metrics in HPA, which Tortoise tunes.
+	metricTargetBounds map[metricKey]metricTargetBounds
+	// replicaRecommendationStrategy calculates the minReplicas/maxReplicas recommendation for each time slot.
+	replicaRecommendationStrategy ReplicaRecommendationStrategy
+	// calendar is used to find the time slot for the special days, such as public holidays.
+	calendar *calendar.Calendar
+}
+
+type metricKey struct {
+	metricType v2.MetricSourceType
+	name       string
+}
+
+type metricTargetBounds struct {
+	min resource.Quantity
+	max resource.Quantity
 }
 
 func New(
@@ -62,6 +80,9 @@ func New(
 	maximumMaxReplica int32,
 	maxAllowedScalingDownRatio float64,
 	bufferRatioOnVerticalResourceRecommendation float64,
+	hpaMetricTargetBounds []config.MetricTargetBounds,
+	replicaRecommendationStrategy ReplicaRecommendationStrategy,
+	cal *calendar.Calendar,
 	featureFlags []features.FeatureFlag,
 	eventRecorder record.EventRecorder,
 ) *Service {
@@ -81,6 +102,18 @@ func New(
 		minResourceSizePerContainer[containerName][corev1.ResourceMemory] = resource.MustParse(v)
 	}
 
+	bounds := map[metricKey]metricTargetBounds{}
+	for _, b := range hpaMetricTargetBounds {
+		bounds[metricKey{metricType: v2.MetricSourceType(b.Type), name: b.Name}] = metricTargetBounds{
+			min: resource.MustParse(b.MinTarget),
+			max: resource.MustParse(b.MaxTarget),
+		}
+	}
+
+	if replicaRecommendationStrategy == nil {
+		replicaRecommendationStrategy = maxDecayStrategy{}
+	}
+
 	return &Service{
 		eventRecorder:                       eventRecorder,
 		MaxReplicasRecommendationMultiplier: maxReplicasRecommendationMultiplier,
@@ -98,9 +131,42 @@ func New(
 		featureFlags:                  featureFlags,
 		maxAllowedScalingDownRatio:    maxAllowedScalingDownRatio,
 		bufferRatioOnVerticalResource: bufferRatioOnVerticalResourceRecommendation,
+		metricTargetBounds:            bounds,
+		replicaRecommendationStrategy: replicaRecommendationStrategy,
+		calendar:                      cal,
 	}
 }
 
+// withOverrides returns the copy of the service, whose configurations are overridden by .spec.recommenderOverrides in the tortoise.
+// The values are validated by the webhook based on the range that the cluster admin allows.
+func (s *Service) withOverrides(tortoise *v1beta3.Tortoise) *Service {
+	o := tortoise.Spec.RecommenderOverrides
+	if o == nil {
+		return s
+	}
+
+	overridden := *s
+	if o.PreferredMaxReplicas != nil {
+		overridden.preferredMaxReplicas = *o.PreferredMaxReplicas
+	}
+	if o.MinimumMinReplicas != nil {
+		overridden.minimumMinReplicas = *o.MinimumMinReplicas
+	}
+	if o.MaxReplicasRecommendationMultiplier != nil {
+		overridden.MaxReplicasRecommendationMultiplier = o.MaxReplicasRecommendationMultiplier.AsApproximateFloat64()
+	}
+	if o.BufferRatioOnVerticalResource != nil {
+		overridden.bufferRatioOnVerticalResource = o.BufferRatioOnVerticalResource.AsApproximateFloat64()
+	}
+	if o.MaxAllowedScalingDownRatio != nil {
+		overridden.maxAllowedScalingDownRatio = o.MaxAllowedScalingDownRatio.AsApproximateFloat64()
+	}
+	if o.MinimumTargetResourceUtilization != nil {
+		overridden.minimumTargetResourceUtilization = *o.MinimumTargetResourceUtilization
+	}
+	return &overridden
+}
+
 func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler, replicaNum int32, now time.Time) (*v1beta3.Tortoise, error) {
 	scaledUpBasedOnPreferredMaxReplicas := false
 	closeToPreferredMaxReplicas := false
@@ -157,6 +223,21 @@ func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3
 	for _, r := range tortoise.Spec.ResourcePolicy {
 		minAllocatedResourcesMap[r.ContainerName] = r.MinAllocatedResources
 	}
+	if tortoise.Status.Recommendations.Constraints != nil {
+		// The constraints (e.g., from ScheduledScaling) can only raise MinAllocatedResources.
+		for _, r := range tortoise.Status.Recommendations.Constraints.MinAllocatedResources {
+			merged := minAllocatedResourcesMap[r.ContainerName].DeepCopy()
+			if merged == nil {
+				merged = v1.ResourceList{}
+			}
+			for name, q := range r.MinAllocatedResources {
+				if existing, ok := merged[name]; !ok || q.Cmp(existing) > 0 {
+					merged[name] = q
+				}
+			}
+			minAllocatedResourcesMap[r.ContainerName] = merged
+		}
+	}
 
 	// containerName → MaxAllocatedResources
 	maxAllocatedResourcesMap := map[string]v1.ResourceList{}
@@ -202,6 +283,7 @@ func (s *Service) updateVPARecommendation(ctx context.Context, tortoise *v1beta3
 			if err != nil {
 				return tortoise, err
 			}
+			newSize, reason = s.applyOOMBump(tortoise, p, r.ContainerName, k, req, newSize, reason, minAllocatedResourcesMap[r.ContainerName], maxAllocatedResourcesMap[r.ContainerName], now)
 
 			if newSize != req.MilliValue() {
 				logger.Info("The recommendation of resource request in Tortoise is updated", "container name", r.ContainerName, "resource name", k, "reason", reason)
@@ -364,6 +446,46 @@ func (s *Service) calculateBestNewSize(
 	return s.justifyNewSize(resourceRequest.MilliValue(), resourceRequest.MilliValue(), k, minAllocatedResources, maxAllocatedResources, containerName), "nothing to do", nil
 }
 
+// applyOOMBump applies the bump of the memory recommendation if the container was OOMKilled recently.
+// During the cooldown period after the OOMKill, the memory recommendation is at least the bumped memory,
+// and it's never lower than the current memory request.
+func (s *Service) applyOOMBump(
+	tortoise *v1beta3.Tortoise,
+	p v1beta3.AutoscalingType,
+	containerName string,
+	k corev1.ResourceName,
+	resourceRequest resource.Quantity,
+	newSize int64,
+	reason string,
+	minAllocatedResources, maxAllocatedResources corev1.ResourceList,
+	now time.Time,
+) (int64, string) {
+	if k != corev1.ResourceMemory || p == v1beta3.AutoscalingTypeOff {
+		return newSize, reason
+	}
+
+	var bump *v1beta3.ContainerOOMBump
+	for i, b := range tortoise.Status.Recommendations.Vertical.OOMBumps {
+		if b.ContainerName == containerName && b.ScaleDownStoppedUntil.Time.After(now) {
+			bump = &tortoise.Status.Recommendations.Vertical.OOMBumps[i]
+		}
+	}
+	if bump == nil {
+		return newSize, reason
+	}
+
+	if bump.BumpedMemory.MilliValue() > newSize {
+		jastified := s.justifyNewSize(resourceRequest.MilliValue(), bump.BumpedMemory.MilliValue(), k, minAllocatedResources, maxAllocatedResources, containerName)
+		if jastified > newSize {
+			return jastified, fmt.Sprintf("the container (%v) was OOMKilled at %v, so bump up %v request (%v → %v)", containerName, bump.LastOOMKilledTime.Format(time.RFC3339), k, resourceRequest.MilliValue(), jastified)
+		}
+	}
+	if newSize < resourceRequest.MilliValue() {
+		return resourceRequest.MilliValue(), fmt.Sprintf("the container (%v) was OOMKilled at %v, so %v request isn't scaled down until %v", containerName, bump.LastOOMKilledTime.Format(time.RFC3339), k, bump.ScaleDownStoppedUntil.Format(time.RFC3339))
+	}
+	return newSize, reason
+}
+
 func hasHorizontal(tortoise *v1beta3.Tortoise) bool {
 	for _, r := range tortoise.Status.AutoscalingPolicy {
 		for _, p := range r.Policy {
@@ -445,6 +567,8 @@ func (s *Service) updateHPARecommendation(ctx context.Context, tortoise *v1beta3
 		return tortoise, err
 	}
 
+	tortoise = s.updateHPAMetricTargetRecommendations(ctx, tortoise, hpa, replicaNum, now)
+
 	return tortoise, nil
 }
 
@@ -459,6 +583,8 @@ func (s *Service) UpdateRecommendations(ctx context.Context, tortoise *v1beta3.T
 		return tortoise, nil
 	}
 
+	s = s.withOverrides(tortoise)
+
 	var err error
 	tortoise, err = s.updateHPARecommendation(ctx, tortoise, hpa, replicaNum, now)
 	if err != nil {
@@ -489,7 +615,10 @@ func (s *Service) updateHPAMinMaxReplicasRecommendations(tortoise *v1beta3.Torto
 	return tortoise, nil
 }
 
-func findSlotInReplicasRecommendation(recommendations []v1beta3.ReplicasRecommendation, now time.Time) (int, error) {
+// findSlotInReplicasRecommendation finds the time slot for now.
+// On the special days in the calendar, the time slot of the day of the week specified in the calendar is used,
+// so that the special days don't pollute the recommendation of the actual day of the week.
+func findSlotInReplicasRecommendation(recommendations []v1beta3.ReplicasRecommendation, now time.Time, cal *calendar.Calendar) (int, error) {
 	index := -1
 	for i, r := range recommendations {
 		tz, err := time.LoadLocation(r.TimeZone)
@@ -497,7 +626,7 @@ func findSlotInReplicasRecommendation(recommendations []v1beta3.ReplicasRecommen
 			// if the timezone is invalid, just ignore it.
 			now = now.In(tz)
 		}
-		if now.Hour() < r.To && now.Hour() >= r.From && (r.WeekDay == nil || now.Weekday().String() == *r.WeekDay) {
+		if utils.InReplicasRecommendationSlot(r, now, cal.Weekday(now)) {
 			index = i
 			break
 		}
@@ -510,10 +639,10 @@ func findSlotInReplicasRecommendation(recommendations []v1beta3.ReplicasRecommen
 	return index, nil
 }
 
-// updateMinReplicasRecommendation replaces value if the value is higher than the current value.
+// updateReplicasRecommendation updates the recommendation of the current time slot with the strategy.
 func (s *Service) updateReplicasRecommendation(value int32, recommendations []v1beta3.ReplicasRecommendation, now time.Time, min int32) ([]v1beta3.ReplicasRecommendation, error) {
 	// find the corresponding recommendations.
-	index, err := findSlotInReplicasRecommendation(recommendations, now)
+	index, err := findSlotInReplicasRecommendation(recommendations, now, s.calendar)
 	if err != nil {
 		return recommendations, err
 	}
@@ -522,19 +651,7 @@ func (s *Service) updateReplicasRecommendation(value int32, recommendations []v1
 		value = min
 	}
 
-	timeBiasedRecommendation := recommendations[index].Value
-	if now.Sub(recommendations[index].UpdatedAt.Time).Hours() >= 23 {
-		// only if the recommendation is not updated within 24 hours, we give the time bias
-		// so that the past recommendation is decreased a bit and the current recommendation likely replaces it.
-		timeBiasedRecommendation = int32(math.Trunc(float64(recommendations[index].Value) * 0.95))
-	}
-
-	if value > timeBiasedRecommendation {
-		recommendations[index].Value = value
-	} else {
-		recommendations[index].Value = timeBiasedRecommendation
-	}
-
+	recommendations[index] = s.replicaRecommendationStrategy.Recommend(recommendations[index], value, now)
 	recommendations[index].UpdatedAt = metav1.NewTime(now)
 
 	return recommendations, nil
@@ -664,3 +781,120 @@ func updateRecommendedContainerBasedMetric(upperUsage, currentTarget int32) int3
 	additionalResource := upperUsage - currentTarget
 	return 100 - additionalResource
 }
+
+// updateHPAMetricTargetRecommendations updates the recommendation of the target average value of the Pods and External metrics in the HPA,
+// whose bounds are configured by the cluster admin.
+//
+// The recommendation is calculated in the same way as the target utilization of the container resource metrics:
+// MaxTarget is regarded as the capacity of each replica (like 100% utilization),
+// and the target leaves the room for the peak per replica observed recently, which exceeds the current target.
+func (s *Service) updateHPAMetricTargetRecommendations(ctx context.Context, tortoise *v1beta3.Tortoise, hpa *v2.HorizontalPodAutoscaler, replicaNum int32, now time.Time) *v1beta3.Tortoise {
+	if len(s.metricTargetBounds) == 0 || hpa == nil {
+		return tortoise
+	}
+	logger := log.FromContext(ctx)
+
+	previous := map[metricKey]v1beta3.HPAMetricTargetRecommendation{}
+	for _, r := range tortoise.Status.Recommendations.Horizontal.MetricTargets {
+		previous[metricKey{metricType: r.Type, name: r.MetricName}] = r
+	}
+
+	var recommendations []v1beta3.HPAMetricTargetRecommendation
+	for _, m := range hpa.Spec.Metrics {
+		key, ok := metricKeyOf(m)
+		if !ok {
+			continue
+		}
+		bounds, ok := s.metricTargetBounds[key]
+		if !ok {
+			continue
+		}
+		currentTarget, ok := hpaservice.GetHPAMetricTarget(hpa, key.metricType, key.name)
+		if !ok {
+			logger.V(4).Info("the metric doesn't have the AverageValue target, skip tuning it", "metric type", key.metricType, "metric name", key.name)
+			continue
+		}
+
+		prev, hasPrev := previous[key]
+		observed, ok := observedAverageValue(hpa, key, replicaNum)
+		if !ok {
+			// The metric might be missing because it's not yet synced to the HPA status.
+			if hasPrev {
+				recommendations = append(recommendations, prev)
+			}
+			continue
+		}
+
+		peak, peakUpdatedAt := observed, now
+		if hasPrev {
+			peak, peakUpdatedAt = prev.ObservedPeak, prev.ObservedPeakUpdatedAt.Time
+			if now.Sub(peakUpdatedAt) >= 24*time.Hour {
+				// Decay the old peak a bit every day so that the current value likely replaces it.
+				peak = *resource.NewMilliQuantity(int64(float64(peak.MilliValue())*0.95), peak.Format)
+				peakUpdatedAt = now
+			}
+			if observed.Cmp(peak) > 0 {
+				peak, peakUpdatedAt = observed, now
+			}
+		}
+
+		target := recommendMetricTarget(currentTarget, peak, bounds)
+		if target.Cmp(currentTarget) != 0 {
+			s.eventRecorder.Event(tortoise, corev1.EventTypeNormal, event.RecommendationUpdated, fmt.Sprintf("The recommendation of HPA %s metric %s target in Tortoise status is updated (%s → %s)", key.metricType, key.name, currentTarget.String(), target.String()))
+		}
+		logger.Info("HPA metric target recommendation is generated", "metric type", key.metricType, "metric name", key.name, "current target", currentTarget.String(), "recommended target", target.String(), "observed peak", peak.String())
+
+		recommendations = append(recommendations, v1beta3.HPAMetricTargetRecommendation{
+			Type:                  key.metricType,
+			MetricName:            key.name,
+			Target:                target,
+			ObservedPeak:          peak,
+			ObservedPeakUpdatedAt: metav1.NewTime(peakUpdatedAt),
+		})
+	}
+
+	tortoise.Status.Recommendations.Horizontal.MetricTargets = recommendations
+	return tortoise
+}
+
+// recommendMetricTarget returns MaxTarget - (peak - current target) within the bounds.
+// If the peak doesn't exceed the current target (e.g., hitting minReplicas), the current target is kept.
+func recommendMetricTarget(currentTarget, peak resource.Quantity, bounds metricTargetBounds) resource.Quantity {
+	target := currentTarget.MilliValue()
+	if peak.Cmp(currentTarget) > 0 {
+		target = bounds.max.MilliValue() - (peak.MilliValue() - currentTarget.MilliValue())
+	}
+	target = max(target, bounds.min.MilliValue())
+	target = min(target, bounds.max.MilliValue())
+	return *resource.NewMilliQuantity(target, currentTarget.Format)
+}
+
+func metricKeyOf(m v2.MetricSpec) (metricKey, bool) {
+	switch {
+	case m.Type == v2.PodsMetricSourceType && m.Pods != nil:
+		return metricKey{metricType: m.Type, name: m.Pods.Metric.Name}, true
+	case m.Type == v2.ExternalMetricSourceType && m.External != nil:
+		return metricKey{metricType: m.Type, name: m.External.Metric.Name}, true
+	}
+	return metricKey{}, false
+}
+
+// observedAverageValue returns the current average value of the metric per replica in the HPA status.
+func observedAverageValue(hpa *v2.HorizontalPodAutoscaler, key metricKey, replicaNum int32) (resource.Quantity, bool) {
+	for _, m := range hpa.Status.CurrentMetrics {
+		switch {
+		case key.metricType == v2.PodsMetricSourceType && m.Type == v2.PodsMetricSourceType && m.Pods != nil && m.Pods.Metric.Name == key.name:
+			if m.Pods.Current.AverageValue != nil {
+				return m.Pods.Current.AverageValue.DeepCopy(), true
+			}
+		case key.metricType == v2.ExternalMetricSourceType && m.Type == v2.ExternalMetricSourceType && m.External != nil && m.External.Metric.Name == key.name:
+			if m.External.Current.AverageValue != nil {
+				return m.External.Current.AverageValue.DeepCopy(), true
+			}
+			if m.External.Current.Value != nil && replicaNum > 0 {
+				return *resource.NewMilliQuantity(m.External.Current.Value.MilliValue()/int64(replicaNum), m.External.Current.Value.Format), true
+			}
+		}
+	}
+	return resource.Quantity{}, false
+}
@@ -15,6 +15,8 @@ import (
 	"k8s.io/utils/ptr"
 
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/calendar"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/features"
 	"github.com/mercari/tortoise/pkg/utils"
 )
@@ -951,7 +953,7 @@ func TestUpdateRecommendation(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, nil, record.NewFakeRecorder(10))
+			s := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, nil, nil, nil, nil, record.NewFakeRecorder(10))
 			got, err := s.updateHPATargetUtilizationRecommendations(context.Background(), tt.args.tortoise, tt.args.hpa, tt.args.currentReplicaNum)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("updateHPATargetUtilizationRecommendations() error = %v, wantErr %v", err, tt.wantErr)
@@ -1631,7 +1633,7 @@ func Test_updateHPAMinMaxReplicasRecommendations(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			s := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, nil, record.NewFakeRecorder(10))
+			s := New(2.0, 0.5, 90, 40, 3, 30, "50m", "50Mi", map[string]string{"istio-proxy": "100m"}, map[string]string{"istio-proxy": "100m"}, "10", "10Gi", 1000, 0.5, 0, nil, nil, nil, nil, record.NewFakeRecorder(10))
 			got, err := s.updateHPAMinMaxReplicasRecommendations(tt.args.tortoise, tt.args.replicaNum, tt.args.now)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("updateHPAMinMaxReplicasRecommendations() error = %v, wantErr %v", err, tt.wantErr)
@@ -3048,6 +3050,100 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 			}).Build(),
 			wantErr: false,
 		},
+		{
+			name: "all vertical: MinAllocatedResources is raised by the constraints",
+			fields: fields{
+				preferredMaxReplicas: 6,
+				maxCPU:               "1000m",
+				maxMemory:            "1Gi",
+			},
+			args: args{
+				hpa: &v2.HorizontalPodAutoscaler{
+					Spec: v2.HorizontalPodAutoscalerSpec{
+						MinReplicas: ptr.To[int32](1),
+						Metrics:     []v2.MetricSpec{},
+					},
+				},
+				tortoise: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+					ContainerName: "test-container",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+					ContainerName:         "test-container",
+					MinAllocatedResources: createResourceList("100m", "100Mi"),
+				}).AddContainerRecommendationFromVPA(
+					v1beta3.ContainerRecommendationFromVPA{
+						ContainerName: "test-container",
+						MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+							corev1.ResourceCPU: {
+								Quantity: resource.MustParse("10m"), // too small
+							},
+							corev1.ResourceMemory: {
+								Quantity: resource.MustParse("10Mi"), // too small
+							},
+						},
+					},
+				).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+					ContainerName: "test-container",
+					Resource:      createResourceList("130m", "130Mi"),
+				}).SetRecommendations(v1beta3.Recommendations{
+					Constraints: &v1beta3.Constraints{
+						MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+							{
+								ContainerName:         "test-container",
+								MinAllocatedResources: createResourceList("200m", "50Mi"),
+							},
+						},
+					},
+				}).Build(),
+				replicaNum: 3,
+			},
+			want: utils.NewTortoiseBuilder().AddAutoscalingPolicy(v1beta3.ContainerAutoscalingPolicy{
+				ContainerName: "test-container",
+				Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+					corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+					corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+				},
+			}).AddResourcePolicy(v1beta3.ContainerResourcePolicy{
+				ContainerName:         "test-container",
+				MinAllocatedResources: createResourceList("100m", "100Mi"),
+			}).AddContainerRecommendationFromVPA(
+				v1beta3.ContainerRecommendationFromVPA{
+					ContainerName: "test-container",
+					MaxRecommendation: map[corev1.ResourceName]v1beta3.ResourceQuantity{
+						corev1.ResourceCPU: {
+							Quantity: resource.MustParse("10m"),
+						},
+						corev1.ResourceMemory: {
+							Quantity: resource.MustParse("10Mi"),
+						},
+					},
+				},
+			).AddContainerResourceRequests(v1beta3.ContainerResourceRequests{
+				ContainerName: "test-container",
+				Resource:      createResourceList("130m", "130Mi"),
+			}).SetRecommendations(v1beta3.Recommendations{
+				Constraints: &v1beta3.Constraints{
+					MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+						{
+							ContainerName:         "test-container",
+							MinAllocatedResources: createResourceList("200m", "50Mi"),
+						},
+					},
+				},
+				Vertical: v1beta3.VerticalRecommendations{
+					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+						{
+							ContainerName:       "test-container",
+							RecommendedResource: createResourceList("200m", "100Mi"), // the larger of MinAllocatedResources and the constraints
+						},
+					},
+				},
+			}).Build(),
+			wantErr: false,
+		},
 		{
 			name: "all vertical: use minResourceSize when VPA recommendation is smaller than minResourceSize",
 			fields: fields{
@@ -3634,7 +3730,7 @@ func TestService_UpdateVPARecommendation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 
-			s := New(0, 0, 0, 0, int(tt.fields.minimumMinReplicas), int(tt.fields.preferredMaxReplicas), "5m", "5Mi", map[string]string{"istio-proxy": "7m"}, map[string]string{"istio-proxy": "7Mi"}, tt.fields.maxCPU, tt.fields.maxMemory, 10000, tt.fields.maxAllowedScalingDownRatio, tt.fields.bufferRatioOnVerticalResource, tt.fields.features, record.NewFakeRecorder(10))
+			s := New(0, 0, 0, 0, int(tt.fields.minimumMinReplicas), int(tt.fields.preferredMaxReplicas), "5m", "5Mi", map[string]string{"istio-proxy": "7m"}, map[string]string{"istio-proxy": "7Mi"}, tt.fields.maxCPU, tt.fields.maxMemory, 10000, tt.fields.maxAllowedScalingDownRatio, tt.fields.bufferRatioOnVerticalResource, nil, nil, nil, tt.fields.features, record.NewFakeRecorder(10))
 			got, err := s.updateVPARecommendation(context.Background(), tt.args.tortoise, tt.args.hpa, tt.args.replicaNum, now)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("updateVPARecommendation() error = %v, wantErr %v", err, tt.wantErr)
@@ -3654,3 +3750,364 @@ func createResourceList(cpu, memory string) corev1.ResourceList {
 		corev1.ResourceMemory: resource.MustParse(memory),
 	}
 }
+
+func TestService_withOverrides(t *testing.T) {
+	s := New(2.0, 0.5, 90, 65, 3, 30, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 100, 0.8, 0.1, nil, nil, nil, nil, record.NewFakeRecorder(10))
+
+	tests := []struct {
+		name      string
+		overrides *v1beta3.RecommenderOverrides
+		want      *Service
+	}{
+		{
+			name:      "no overrides",
+			overrides: nil,
+			want:      s,
+		},
+		{
+			name: "some fields are overridden",
+			overrides: &v1beta3.RecommenderOverrides{
+				PreferredMaxReplicas:                ptr.To[int32](50),
+				MinimumMinReplicas:                  ptr.To[int32](5),
+				MaxReplicasRecommendationMultiplier: ptr.To(resource.MustParse("2.5")),
+				BufferRatioOnVerticalResource:       ptr.To(resource.MustParse("0.2")),
+				MaxAllowedScalingDownRatio:          ptr.To(resource.MustParse("0.5")),
+				MinimumTargetResourceUtilization:    ptr.To[int32](50),
+			},
+			want: func() *Service {
+				want := *s
+				want.preferredMaxReplicas = 50
+				want.minimumMinReplicas = 5
+				want.MaxReplicasRecommendationMultiplier = 2.5
+				want.bufferRatioOnVerticalResource = 0.2
+				want.maxAllowedScalingDownRatio = 0.5
+				want.minimumTargetResourceUtilization = 50
+				return &want
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tortoise := &v1beta3.Tortoise{Spec: v1beta3.TortoiseSpec{RecommenderOverrides: tt.overrides}}
+			got := s.withOverrides(tortoise)
+			if d := cmp.Diff(tt.want, got, cmp.AllowUnexported(Service{}), cmpopts.IgnoreFields(Service{}, "eventRecorder")); d != "" {
+				t.Errorf("withOverrides() diff = %s", d)
+			}
+		})
+	}
+	if s.preferredMaxReplicas != 30 {
+		t.Errorf("withOverrides() shouldn't modify the original service")
+	}
+}
+
+func TestService_applyOOMBump(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	bump := v1beta3.ContainerOOMBump{
+		ContainerName:         "app",
+		LastOOMKilledTime:     metav1.NewTime(now.Add(-10 * time.Minute)),
+		BumpedMemory:          resource.MustParse("1200Mi"),
+		ScaleDownStoppedUntil: metav1.NewTime(now.Add(50 * time.Minute)),
+	}
+	tests := []struct {
+		name     string
+		p        v1beta3.AutoscalingType
+		k        corev1.ResourceName
+		bumps    []v1beta3.ContainerOOMBump
+		request  string
+		newSize  string
+		maxAlloc corev1.ResourceList
+		want     string
+	}{
+		{
+			name:    "no bump",
+			p:       v1beta3.AutoscalingTypeVertical,
+			k:       corev1.ResourceMemory,
+			request: "1Gi",
+			newSize: "800Mi",
+			want:    "800Mi",
+		},
+		{
+			name:    "bumped up to the bumped memory",
+			p:       v1beta3.AutoscalingTypeVertical,
+			k:       corev1.ResourceMemory,
+			bumps:   []v1beta3.ContainerOOMBump{bump},
+			request: "1Gi",
+			newSize: "1100Mi",
+			want:    "1200Mi",
+		},
+		{
+			name:    "the recommendation bigger than the bumped memory is used",
+			p:       v1beta3.AutoscalingTypeVertical,
+			k:       corev1.ResourceMemory,
+			bumps:   []v1beta3.ContainerOOMBump{bump},
+			request: "1Gi",
+			newSize: "2Gi",
+			want:    "2Gi",
+		},
+		{
+			name:    "no scale down during the cooldown",
+			p:       v1beta3.AutoscalingTypeVertical,
+			k:       corev1.ResourceMemory,
+			bumps:   []v1beta3.ContainerOOMBump{bump},
+			request: "2Gi",
+			newSize: "1500Mi",
+			want:    "2Gi",
+		},
+		{
+			name:     "the bumped memory is limited by MaxAllocatedResources",
+			p:        v1beta3.AutoscalingTypeVertical,
+			k:        corev1.ResourceMemory,
+			bumps:    []v1beta3.ContainerOOMBump{bump},
+			request:  "1Gi",
+			newSize:  "1Gi",
+			maxAlloc: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1100Mi")},
+			want:     "1100Mi",
+		},
+		{
+			name: "the cooldown is over",
+			p:    v1beta3.AutoscalingTypeVertical,
+			k:    corev1.ResourceMemory,
+			bumps: []v1beta3.ContainerOOMBump{
+				{
+					ContainerName:         "app",
+					LastOOMKilledTime:     metav1.NewTime(now.Add(-2 * time.Hour)),
+					BumpedMemory:          resource.MustParse("1200Mi"),
+					ScaleDownStoppedUntil: metav1.NewTime(now.Add(-time.Hour)),
+				},
+			},
+			request: "1Gi",
+			newSize: "800Mi",
+			want:    "800Mi",
+		},
+		{
+			name:    "cpu isn't affected",
+			p:       v1beta3.AutoscalingTypeVertical,
+			k:       corev1.ResourceCPU,
+			bumps:   []v1beta3.ContainerOOMBump{bump},
+			request: "1",
+			newSize: "500m",
+			want:    "500m",
+		},
+		{
+			name:    "Off isn't affected",
+			p:       v1beta3.AutoscalingTypeOff,
+			k:       corev1.ResourceMemory,
+			bumps:   []v1beta3.ContainerOOMBump{bump},
+			request: "1Gi",
+			newSize: "1Gi",
+			want:    "1Gi",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(0, 0, 0, 0, 0, 0, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 10000, 0, 0, nil, nil, nil, nil, record.NewFakeRecorder(10))
+			tortoise := &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Recommendations: v1beta3.Recommendations{Vertical: v1beta3.VerticalRecommendations{OOMBumps: tt.bumps}}}}
+			newSize := resource.MustParse(tt.newSize)
+			got, _ := s.applyOOMBump(tortoise, tt.p, "app", tt.k, resource.MustParse(tt.request), newSize.MilliValue(), "", nil, tt.maxAlloc, now)
+			want := resource.MustParse(tt.want)
+			if got != want.MilliValue() {
+				t.Errorf("applyOOMBump() = %v, want %v", got, want.MilliValue())
+			}
+		})
+	}
+}
+
+func TestService_updateHPAMetricTargetRecommendations(t *testing.T) {
+	now := time.Date(2023, 3, 19, 0, 0, 0, 0, time.UTC)
+	externalHPA := func(target string, current v2.MetricValueStatus) *v2.HorizontalPodAutoscaler {
+		return &v2.HorizontalPodAutoscaler{
+			Spec: v2.HorizontalPodAutoscalerSpec{
+				Metrics: []v2.MetricSpec{
+					{
+						Type: v2.ExternalMetricSourceType,
+						External: &v2.ExternalMetricSource{
+							Metric: v2.MetricIdentifier{Name: "queue"},
+							Target: v2.MetricTarget{Type: v2.AverageValueMetricType, AverageValue: ptr.To(resource.MustParse(target))},
+						},
+					},
+				},
+			},
+			Status: v2.HorizontalPodAutoscalerStatus{
+				CurrentMetrics: []v2.MetricStatus{
+					{
+						Type:     v2.ExternalMetricSourceType,
+						External: &v2.ExternalMetricStatus{Metric: v2.MetricIdentifier{Name: "queue"}, Current: current},
+					},
+				},
+			},
+		}
+	}
+	podsHPA := &v2.HorizontalPodAutoscaler{
+		Spec: v2.HorizontalPodAutoscalerSpec{
+			Metrics: []v2.MetricSpec{
+				{
+					Type: v2.PodsMetricSourceType,
+					Pods: &v2.PodsMetricSource{
+						Metric: v2.MetricIdentifier{Name: "rps"},
+						Target: v2.MetricTarget{Type: v2.AverageValueMetricType, AverageValue: ptr.To(resource.MustParse("50"))},
+					},
+				},
+			},
+		},
+		Status: v2.HorizontalPodAutoscalerStatus{
+			CurrentMetrics: []v2.MetricStatus{
+				{
+					Type: v2.PodsMetricSourceType,
+					Pods: &v2.PodsMetricStatus{Metric: v2.MetricIdentifier{Name: "rps"}, Current: v2.MetricValueStatus{AverageValue: ptr.To(resource.MustParse("150"))}},
+				},
+			},
+		},
+	}
+	averageValue := func(v string) v2.MetricValueStatus {
+		return v2.MetricValueStatus{AverageValue: ptr.To(resource.MustParse(v))}
+	}
+	previous := func(peak string, updatedAt time.Time) []v1beta3.HPAMetricTargetRecommendation {
+		return []v1beta3.HPAMetricTargetRecommendation{
+			{Type: v2.ExternalMetricSourceType, MetricName: "queue", Target: resource.MustParse("50"), ObservedPeak: resource.MustParse(peak), ObservedPeakUpdatedAt: metav1.NewTime(updatedAt)},
+		}
+	}
+	type want struct {
+		target        string
+		peak          string
+		peakUpdatedAt time.Time
+	}
+	tests := []struct {
+		name     string
+		bounds   []config.MetricTargetBounds
+		hpa      *v2.HorizontalPodAutoscaler
+		previous []v1beta3.HPAMetricTargetRecommendation
+		want     []want
+	}{
+		{
+			name:   "the target leaves the room for the peak exceeding the current target",
+			bounds: []config.MetricTargetBounds{{Type: "External", Name: "queue", MinTarget: "10", MaxTarget: "100"}},
+			hpa:    externalHPA("50", averageValue("70")),
+			want:   []want{{target: "80", peak: "70", peakUpdatedAt: now}},
+		},
+		{
+			name:   "the current target is kept when the peak doesn't exceed it",
+			bounds: []config.MetricTargetBounds{{Type: "External", Name: "queue", MinTarget: "10", MaxTarget: "100"}},
+			hpa:    externalHPA("50", averageValue("30")),
+			want:   []want{{target: "50", peak: "30", peakUpdatedAt: now}},
+		},
+		{
+			name:   "the value of the External metric is divided by the replicas",
+			bounds: []config.MetricTargetBounds{{Type: "External", Name: "queue", MinTarget: "10", MaxTarget: "100"}},
+			hpa:    externalHPA("50", v2.MetricValueStatus{Value: ptr.To(resource.MustParse("700"))}),
+			want:   []want{{target: "80", peak: "70", peakUpdatedAt: now}},
+		},
+		{
+			name:   "the target of the Pods metric is bounded by MinTarget",
+			bounds: []config.MetricTargetBounds{{Type: "Pods", Name: "rps", MinTarget: "10", MaxTarget: "100"}},
+			hpa:    podsHPA,
+			want:   []want{{target: "10", peak: "150", peakUpdatedAt: now}},
+		},
+		{
+			name:     "the previous peak is kept within a day",
+			bounds:   []config.MetricTargetBounds{{Type: "External", Name: "queue", MinTarget: "10", MaxTarget: "100"}},
+			hpa:      externalHPA("50", averageValue("60")),
+			previous: previous("90", now.Add(-time.Hour)),
+			want:     []want{{target: "60", peak: "90", peakUpdatedAt: now.Add(-time.Hour)}},
+		},
+		{
+			name:     "the previous peak is decayed after a day",
+			bounds:   []config.MetricTargetBounds{{Type: "External", Name: "queue", MinTarget: "10", MaxTarget: "100"}},
+			hpa:      externalHPA("50", averageValue("60")),
+			previous: previous("100", now.Add(-25*time.Hour)),
+			want:     []want{{target: "55", peak: "95", peakUpdatedAt: now}},
+		},
+		{
+			name:   "the metric without the bounds isn't tuned",
+			bounds: []config.MetricTargetBounds{{Type: "Pods", Name: "queue", MinTarget: "10", MaxTarget: "100"}},
+			hpa:    externalHPA("50", averageValue("70")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(0, 0, 0, 0, 0, 0, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 10000, 0, 0, tt.bounds, nil, nil, nil, record.NewFakeRecorder(10))
+			tortoise := &v1beta3.Tortoise{}
+			tortoise.Status.Recommendations.Horizontal.MetricTargets = tt.previous
+			got := s.updateHPAMetricTargetRecommendations(context.Background(), tortoise, tt.hpa, 10, now).Status.Recommendations.Horizontal.MetricTargets
+			if len(got) != len(tt.want) {
+				t.Fatalf("updateHPAMetricTargetRecommendations() = %v, want %v", got, tt.want)
+			}
+			for i, w := range tt.want {
+				if got[i].Target.Cmp(resource.MustParse(w.target)) != 0 || got[i].ObservedPeak.Cmp(resource.MustParse(w.peak)) != 0 || !got[i].ObservedPeakUpdatedAt.Time.Equal(w.peakUpdatedAt) {
+					t.Errorf("updateHPAMetricTargetRecommendations()[%d] = {target: %s, peak: %s, peakUpdatedAt: %v}, want %+v", i, got[i].Target.String(), got[i].ObservedPeak.String(), got[i].ObservedPeakUpdatedAt, w)
+				}
+			}
+		})
+	}
+}
+
+func TestService_updateReplicasRecommendation_calendar(t *testing.T) {
+	// 2024-01-08 is Monday, and a public holiday in Japan.
+	holiday := time.Date(2024, 1, 8, 10, 0, 0, 0, time.UTC)
+	cal, err := calendar.New([]config.Calendar{{SpecialDays: []config.SpecialDay{{Date: "2024-01-08", Weekday: "Sunday"}}}}, "UTC")
+	if err != nil {
+		t.Fatalf("calendar.New() error = %v", err)
+	}
+	recommendations := func(sunday, monday int32) []v1beta3.ReplicasRecommendation {
+		return []v1beta3.ReplicasRecommendation{
+			{From: 0, To: 24, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: sunday},
+			{From: 0, To: 24, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: monday},
+		}
+	}
+	tests := []struct {
+		name     string
+		calendar *calendar.Calendar
+		want     []v1beta3.ReplicasRecommendation
+	}{
+		{
+			name:     "the special day updates the recommendation of the day of the week in the calendar",
+			calendar: cal,
+			want: []v1beta3.ReplicasRecommendation{
+				{From: 0, To: 24, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 5, UpdatedAt: metav1.NewTime(holiday)},
+				{From: 0, To: 24, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: 20},
+			},
+		},
+		{
+			name: "the actual day of the week is updated without the calendar",
+			want: []v1beta3.ReplicasRecommendation{
+				{From: 0, To: 24, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+				{From: 0, To: 24, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: 19, UpdatedAt: metav1.NewTime(holiday)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(0, 0, 0, 0, 0, 0, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 10000, 0, 0, nil, nil, tt.calendar, nil, record.NewFakeRecorder(10))
+			got, err := s.updateReplicasRecommendation(5, recommendations(3, 20), holiday, 0)
+			if err != nil {
+				t.Fatalf("updateReplicasRecommendation() error = %v", err)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("updateReplicasRecommendation() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestService_updateReplicasRecommendation_minutes(t *testing.T) {
+	// 2023-01-01 is Sunday.
+	now := time.Date(2023, 1, 1, 12, 20, 0, 0, time.UTC)
+	recommendations := []v1beta3.ReplicasRecommendation{
+		{From: 12, FromMinute: 0, To: 12, ToMinute: 15, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+		{From: 12, FromMinute: 15, To: 12, ToMinute: 30, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+		{From: 12, FromMinute: 30, To: 12, ToMinute: 45, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+	}
+	want := []v1beta3.ReplicasRecommendation{
+		{From: 12, FromMinute: 0, To: 12, ToMinute: 15, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+		{From: 12, FromMinute: 15, To: 12, ToMinute: 30, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 8, UpdatedAt: metav1.NewTime(now)},
+		{From: 12, FromMinute: 30, To: 12, ToMinute: 45, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 3},
+	}
+
+	s := New(0, 0, 0, 0, 0, 0, "5m", "5Mi", map[string]string{}, map[string]string{}, "10", "10Gi", 10000, 0, 0, nil, nil, nil, nil, record.NewFakeRecorder(10))
+	got, err := s.updateReplicasRecommendation(8, recommendations, now, 0)
+	if err != nil {
+		t.Fatalf("updateReplicasRecommendation() error = %v", err)
+	}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("updateReplicasRecommendation() diff = %s", d)
+	}
+}
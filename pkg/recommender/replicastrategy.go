@@ -0,0 +1,110 @@
+package recommender
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+// slotRevisitThreshold is the elapsed time since the last update, after which the time slot is regarded as the next occurrence (e.g., next week).
+// Each time slot is at most 24 hours long, so the next occurrence starts 23 hours later at least.
+const slotRevisitThreshold = 23 * time.Hour
+
+// ReplicaRecommendationStrategy calculates the minReplicas/maxReplicas recommendation for a time slot.
+type ReplicaRecommendationStrategy interface {
+	// Recommend updates the recommendation of the time slot with the replica number observed now.
+	Recommend(recommendation v1beta3.ReplicasRecommendation, value int32, now time.Time) v1beta3.ReplicasRecommendation
+}
+
+// NewReplicaRecommendationStrategy returns the strategy configured by the cluster admin.
+func NewReplicaRecommendationStrategy(name string, periods int, alpha, percentile float64) (ReplicaRecommendationStrategy, error) {
+	switch name {
+	case "", config.ReplicaRecommendationStrategyMaxDecay:
+		return maxDecayStrategy{}, nil
+	case config.ReplicaRecommendationStrategyEWMA:
+		return ewmaStrategy{periods: periods, alpha: alpha}, nil
+	case config.ReplicaRecommendationStrategyPercentile:
+		return percentileStrategy{periods: periods, percentile: percentile}, nil
+	default:
+		return nil, fmt.Errorf("unknown replica recommendation strategy: %s", name)
+	}
+}
+
+// maxDecayStrategy recommends the maximum replica number observed in the time slot.
+// The maximum is decayed by 5% when the time slot comes again so that the past spike is gradually forgotten.
+type maxDecayStrategy struct{}
+
+func (maxDecayStrategy) Recommend(r v1beta3.ReplicasRecommendation, value int32, now time.Time) v1beta3.ReplicasRecommendation {
+	timeBiasedRecommendation := r.Value
+	if now.Sub(r.UpdatedAt.Time) >= slotRevisitThreshold {
+		// only if the recommendation is not updated within 24 hours, we give the time bias
+		// so that the past recommendation is decreased a bit and the current recommendation likely replaces it.
+		timeBiasedRecommendation = int32(math.Trunc(float64(r.Value) * 0.95))
+	}
+
+	r.Value = max(value, timeBiasedRecommendation)
+	// The samples are recorded only by the strategies which need them.
+	r.Samples = nil
+	return r
+}
+
+// ewmaStrategy recommends the exponentially weighted moving average of the maximum replica numbers in the past periods.
+type ewmaStrategy struct {
+	periods int
+	// alpha is the weight of the latest period.
+	alpha float64
+}
+
+func (s ewmaStrategy) Recommend(r v1beta3.ReplicasRecommendation, value int32, now time.Time) v1beta3.ReplicasRecommendation {
+	r.Samples = recordSample(r, value, now, s.periods)
+
+	avg := float64(r.Samples[0])
+	for _, v := range r.Samples[1:] {
+		avg = s.alpha*float64(v) + (1-s.alpha)*avg
+	}
+	r.Value = int32(math.Ceil(avg))
+	return r
+}
+
+// percentileStrategy recommends the percentile of the maximum replica numbers in the past periods.
+type percentileStrategy struct {
+	periods    int
+	percentile float64
+}
+
+func (s percentileStrategy) Recommend(r v1beta3.ReplicasRecommendation, value int32, now time.Time) v1beta3.ReplicasRecommendation {
+	r.Samples = recordSample(r, value, now, s.periods)
+
+	sorted := slices.Clone(r.Samples)
+	slices.Sort(sorted)
+	// nearest-rank method
+	rank := int(math.Ceil(s.percentile / 100 * float64(len(sorted))))
+	r.Value = sorted[max(rank, 1)-1]
+	return r
+}
+
+// recordSample records the replica number observed now as the maximum of the current period,
+// and returns the samples of the last periods.
+func recordSample(r v1beta3.ReplicasRecommendation, value int32, now time.Time, periods int) []int32 {
+	samples := slices.Clone(r.Samples)
+	if len(samples) == 0 && r.Value != 0 {
+		// The strategy is switched from MaxDecay.
+		// Regard the current recommendation as the sample of the previous period so that the recommendation doesn't drop suddenly.
+		samples = []int32{r.Value}
+	}
+
+	if len(samples) == 0 || now.Sub(r.UpdatedAt.Time) >= slotRevisitThreshold {
+		samples = append(samples, value)
+	} else {
+		samples[len(samples)-1] = max(samples[len(samples)-1], value)
+	}
+
+	if len(samples) > periods {
+		samples = samples[len(samples)-periods:]
+	}
+	return samples
+}
@@ -0,0 +1,129 @@
+package recommender
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+func TestReplicaRecommendationStrategy_Recommend(t *testing.T) {
+	now := time.Date(2023, 1, 8, 10, 0, 0, 0, time.UTC)
+	lastWeek := metav1.NewTime(now.Add(-7 * 24 * time.Hour))
+	tenMinutesAgo := metav1.NewTime(now.Add(-10 * time.Minute))
+	tests := []struct {
+		name           string
+		strategy       ReplicaRecommendationStrategy
+		recommendation v1beta3.ReplicasRecommendation
+		value          int32
+		want           v1beta3.ReplicasRecommendation
+	}{
+		{
+			name:           "MaxDecay: the higher value replaces the recommendation",
+			strategy:       maxDecayStrategy{},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 5, UpdatedAt: tenMinutesAgo},
+			value:          8,
+			want:           v1beta3.ReplicasRecommendation{Value: 8, UpdatedAt: tenMinutesAgo},
+		},
+		{
+			name:           "MaxDecay: the recommendation of last week is decayed",
+			strategy:       maxDecayStrategy{},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 20, UpdatedAt: lastWeek},
+			value:          5,
+			want:           v1beta3.ReplicasRecommendation{Value: 19, UpdatedAt: lastWeek},
+		},
+		{
+			name:           "MaxDecay: the samples are removed",
+			strategy:       maxDecayStrategy{},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 5, UpdatedAt: tenMinutesAgo, Samples: []int32{5}},
+			value:          3,
+			want:           v1beta3.ReplicasRecommendation{Value: 5, UpdatedAt: tenMinutesAgo},
+		},
+		{
+			name:           "Percentile: the sample of this week is added",
+			strategy:       percentileStrategy{periods: 4, percentile: 50},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 10, UpdatedAt: lastWeek, Samples: []int32{4, 10, 6}},
+			value:          5,
+			want:           v1beta3.ReplicasRecommendation{Value: 5, UpdatedAt: lastWeek, Samples: []int32{4, 10, 6, 5}},
+		},
+		{
+			name:           "Percentile: the sample of this week is updated with the maximum",
+			strategy:       percentileStrategy{periods: 4, percentile: 50},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 5, UpdatedAt: tenMinutesAgo, Samples: []int32{4, 10, 6, 5}},
+			value:          7,
+			want:           v1beta3.ReplicasRecommendation{Value: 6, UpdatedAt: tenMinutesAgo, Samples: []int32{4, 10, 6, 7}},
+		},
+		{
+			name:           "Percentile: the spike is forgotten after the periods",
+			strategy:       percentileStrategy{periods: 3, percentile: 90},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 30, UpdatedAt: lastWeek, Samples: []int32{30, 6, 5}},
+			value:          4,
+			want:           v1beta3.ReplicasRecommendation{Value: 6, UpdatedAt: lastWeek, Samples: []int32{6, 5, 4}},
+		},
+		{
+			name:           "Percentile: the recommendation from MaxDecay is regarded as the sample of last week",
+			strategy:       percentileStrategy{periods: 4, percentile: 90},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 10, UpdatedAt: lastWeek},
+			value:          4,
+			want:           v1beta3.ReplicasRecommendation{Value: 10, UpdatedAt: lastWeek, Samples: []int32{10, 4}},
+		},
+		{
+			name:     "Percentile: the first sample",
+			strategy: percentileStrategy{periods: 4, percentile: 90},
+			value:    4,
+			want:     v1beta3.ReplicasRecommendation{Value: 4, Samples: []int32{4}},
+		},
+		{
+			name:           "EWMA: the latest sample has the weight of alpha",
+			strategy:       ewmaStrategy{periods: 4, alpha: 0.5},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 10, UpdatedAt: lastWeek, Samples: []int32{8, 12}},
+			value:          4,
+			// ((8 * 0.5 + 12 * 0.5) * 0.5 + 4 * 0.5) = 7
+			want: v1beta3.ReplicasRecommendation{Value: 7, UpdatedAt: lastWeek, Samples: []int32{8, 12, 4}},
+		},
+		{
+			name:           "EWMA: the average is rounded up",
+			strategy:       ewmaStrategy{periods: 2, alpha: 0.3},
+			recommendation: v1beta3.ReplicasRecommendation{Value: 10, UpdatedAt: lastWeek, Samples: []int32{9, 10}},
+			value:          5,
+			// 10 * 0.7 + 5 * 0.3 = 8.5
+			want: v1beta3.ReplicasRecommendation{Value: 9, UpdatedAt: lastWeek, Samples: []int32{10, 5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.strategy.Recommend(tt.recommendation, tt.value, now)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("Recommend() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestNewReplicaRecommendationStrategy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    ReplicaRecommendationStrategy
+		wantErr bool
+	}{
+		{name: "", want: maxDecayStrategy{}},
+		{name: "MaxDecay", want: maxDecayStrategy{}},
+		{name: "EWMA", want: ewmaStrategy{periods: 4, alpha: 0.5}},
+		{name: "Percentile", want: percentileStrategy{periods: 4, percentile: 90}},
+		{name: "Average", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewReplicaRecommendationStrategy(tt.name, 4, 0.5, 90)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewReplicaRecommendationStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d := cmp.Diff(tt.want, got, cmp.AllowUnexported(ewmaStrategy{}, percentileStrategy{})); d != "" {
+				t.Errorf("NewReplicaRecommendationStrategy() diff = %s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/hpa"
+)
+
+// Reporter is the struct for summarizing the status of tortoises in a human-readable way.
+type Reporter struct {
+	c client.Client
+}
+
+func New(c client.Client) *Reporter {
+	return &Reporter{c: c}
+}
+
+// OutputFormat is the format of the report.
+type OutputFormat string
+
+const (
+	OutputFormatTable OutputFormat = "table"
+	OutputFormatJSON  OutputFormat = "json"
+	OutputFormatYAML  OutputFormat = "yaml"
+)
+
+// TortoiseReport is the summary of the status of one tortoise.
+type TortoiseReport struct {
+	Name       string                `json:"name"`
+	Namespace  string                `json:"namespace"`
+	UpdateMode v1beta3.UpdateMode    `json:"updateMode"`
+	Phase      v1beta3.TortoisePhase `json:"phase"`
+	// MinReplicas and MaxReplicas are the recommendations for the current time slot.
+	// They're nil when tortoise doesn't have the recommendation for the current time slot yet.
+	MinReplicas *int32            `json:"minReplicas,omitempty"`
+	MaxReplicas *int32            `json:"maxReplicas,omitempty"`
+	Containers  []ContainerReport `json:"containers,omitempty"`
+	// ActiveConditions are the conditions with the status True.
+	ActiveConditions []v1beta3.TortoiseCondition `json:"activeConditions,omitempty"`
+}
+
+type ContainerReport struct {
+	ContainerName string           `json:"containerName"`
+	Resources     []ResourceReport `json:"resources"`
+}
+
+type ResourceReport struct {
+	ResourceName corev1.ResourceName            `json:"resourceName"`
+	Policy       v1beta3.AutoscalingType        `json:"policy"`
+	Phase        v1beta3.ContainerResourcePhase `json:"phase,omitempty"`
+	// CurrentRequest is the resource request of the Pods at the last reconciliation.
+	CurrentRequest *resource.Quantity `json:"currentRequest,omitempty"`
+	// RecommendedRequest is the resource request recommended by tortoise.
+	RecommendedRequest *resource.Quantity `json:"recommendedRequest,omitempty"`
+	// TargetUtilization is the HPA target utilization recommended by tortoise.
+	// It's only set for the resources with the Horizontal policy.
+	TargetUtilization *int32 `json:"targetUtilization,omitempty"`
+}
+
+// Report builds the reports of the specified tortoises.
+// When all is true, it reports all tortoises in the namespace, or in all namespaces if namespace is empty.
+func (r *Reporter) Report(ctx context.Context, tortoiseNames []string, namespace string, all bool, now time.Time) ([]TortoiseReport, error) {
+	// It assumes the validation is already done in the CLI layer.
+
+	var tortoises []v1beta3.Tortoise
+	if all {
+		list := &v1beta3.TortoiseList{}
+		opt := &client.ListOptions{}
+		if namespace != "" {
+			opt.Namespace = namespace
+		}
+		if err := r.c.List(ctx, list, opt); err != nil {
+			return nil, fmt.Errorf("failed to list tortoises: %w", err)
+		}
+		tortoises = list.Items
+	} else {
+		for _, name := range tortoiseNames {
+			t := &v1beta3.Tortoise{}
+			if err := r.c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, t); err != nil {
+				return nil, fmt.Errorf("failed to get tortoise %s/%s: %w", namespace, name, err)
+			}
+			tortoises = append(tortoises, *t)
+		}
+	}
+
+	reports := make([]TortoiseReport, 0, len(tortoises))
+	for i := range tortoises {
+		reports = append(reports, NewTortoiseReport(&tortoises[i], now))
+	}
+	return reports, nil
+}
+
+// NewTortoiseReport summarizes the status of the tortoise at the time.
+func NewTortoiseReport(t *v1beta3.Tortoise, now time.Time) TortoiseReport {
+	report := TortoiseReport{
+		Name:       t.Name,
+		Namespace:  t.Namespace,
+		UpdateMode: t.Spec.UpdateMode,
+		Phase:      t.Status.TortoisePhase,
+	}
+	if report.UpdateMode == "" {
+		report.UpdateMode = v1beta3.UpdateModeOff
+	}
+
+	// The value 0 means the slot doesn't have the recommendation yet.
+	// The calendar in the controller config isn't available here, so the special days are regarded as the normal days.
+	if v, err := hpa.GetReplicasRecommendation(t.Status.Recommendations.Horizontal.MinReplicas, now, nil); err == nil && v != 0 {
+		report.MinReplicas = &v
+	}
+	if v, err := hpa.GetReplicasRecommendation(t.Status.Recommendations.Horizontal.MaxReplicas, now, nil); err == nil && v != 0 {
+		report.MaxReplicas = &v
+	}
+
+	policies := t.Status.AutoscalingPolicy
+	if len(policies) == 0 {
+		policies = t.Spec.AutoscalingPolicy
+	}
+	for _, p := range policies {
+		c := ContainerReport{ContainerName: p.ContainerName}
+		for _, rn := range sortedResourceNames(p.Policy) {
+			rr := ResourceReport{
+				ResourceName:       rn,
+				Policy:             p.Policy[rn],
+				Phase:              resourcePhase(t, p.ContainerName, rn),
+				CurrentRequest:     currentRequest(t, p.ContainerName, rn),
+				RecommendedRequest: recommendedRequest(t, p.ContainerName, rn),
+			}
+			if rr.Policy == v1beta3.AutoscalingTypeHorizontal {
+				rr.TargetUtilization = targetUtilization(t, p.ContainerName, rn)
+			}
+			c.Resources = append(c.Resources, rr)
+		}
+		report.Containers = append(report.Containers, c)
+	}
+
+	for _, c := range t.Status.Conditions.TortoiseConditions {
+		if c.Status == corev1.ConditionTrue {
+			report.ActiveConditions = append(report.ActiveConditions, c)
+		}
+	}
+
+	return report
+}
+
+// Write writes the reports in the format.
+func Write(w io.Writer, reports []TortoiseReport, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		b, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal the reports to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case OutputFormatYAML:
+		b, err := yaml.Marshal(reports)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the reports to YAML: %w", err)
+		}
+		_, err = w.Write(b)
+		return err
+	case OutputFormatTable, "":
+		for i, r := range reports {
+			if i != 0 {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			if err := writeTable(w, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func writeTable(w io.Writer, r TortoiseReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tortoise:\t%s/%s\n", r.Namespace, r.Name)
+	fmt.Fprintf(&b, "UpdateMode:\t%s\n", r.UpdateMode)
+	fmt.Fprintf(&b, "Phase:\t%s\n", orDash(string(r.Phase)))
+	fmt.Fprintf(&b, "MinReplicas (current slot):\t%s\n", int32OrDash(r.MinReplicas))
+	fmt.Fprintf(&b, "MaxReplicas (current slot):\t%s\n", int32OrDash(r.MaxReplicas))
+	if len(r.ActiveConditions) == 0 {
+		fmt.Fprintf(&b, "Conditions:\t-\n")
+	} else {
+		for i, c := range r.ActiveConditions {
+			header := ""
+			if i == 0 {
+				header = "Conditions:"
+			}
+			fmt.Fprintf(&b, "%s\t%s", header, c.Type)
+			if c.Reason != "" {
+				fmt.Fprintf(&b, " (%s)", c.Reason)
+			}
+			if c.Message != "" {
+				fmt.Fprintf(&b, ": %s", c.Message)
+			}
+			fmt.Fprintln(&b)
+		}
+	}
+	fmt.Fprintln(&b)
+
+	fmt.Fprintln(&b, "CONTAINER\tRESOURCE\tPOLICY\tPHASE\tCURRENT\tRECOMMENDED\tTARGET UTILIZATION")
+	for _, c := range r.Containers {
+		for _, rr := range c.Resources {
+			target := "-"
+			if rr.TargetUtilization != nil {
+				target = fmt.Sprintf("%d%%", *rr.TargetUtilization)
+			}
+			fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				c.ContainerName, rr.ResourceName, rr.Policy, orDash(string(rr.Phase)),
+				quantityOrDash(rr.CurrentRequest), quantityOrDash(rr.RecommendedRequest), target)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if _, err := tw.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+func sortedResourceNames(m map[corev1.ResourceName]v1beta3.AutoscalingType) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func resourcePhase(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) v1beta3.ContainerResourcePhase {
+	for _, p := range t.Status.ContainerResourcePhases {
+		if p.ContainerName == containerName {
+			return p.ResourcePhases[rn].Phase
+		}
+	}
+	return ""
+}
+
+func currentRequest(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) *resource.Quantity {
+	for _, r := range t.Status.Conditions.ContainerResourceRequests {
+		if r.ContainerName != containerName {
+			continue
+		}
+		if q, ok := r.Resource[rn]; ok {
+			return &q
+		}
+	}
+	return nil
+}
+
+func recommendedRequest(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) *resource.Quantity {
+	for _, r := range t.Status.Recommendations.Vertical.ContainerResourceRecommendation {
+		if r.ContainerName != containerName {
+			continue
+		}
+		if q, ok := r.RecommendedResource[rn]; ok {
+			return &q
+		}
+	}
+	return nil
+}
+
+func targetUtilization(t *v1beta3.Tortoise, containerName string, rn corev1.ResourceName) *int32 {
+	for _, r := range t.Status.Recommendations.Horizontal.TargetUtilizations {
+		if r.ContainerName != containerName {
+			continue
+		}
+		if v, ok := r.TargetUtilization[rn]; ok {
+			return &v
+		}
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func int32OrDash(v *int32) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *v)
+}
+
+func quantityOrDash(q *resource.Quantity) string {
+	if q == nil {
+		return "-"
+	}
+	return q.String()
+}
@@ -0,0 +1,231 @@
+package reporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+var now = time.Date(2023, 1, 2, 10, 30, 0, 0, time.UTC) // Monday
+
+func testTortoise() *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode: v1beta3.UpdateModeAuto,
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: v1beta3.TortoisePhaseWorking,
+			AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{
+					ContainerName: "app",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+					},
+				},
+			},
+			ContainerResourcePhases: []v1beta3.ContainerResourcePhases{
+				{
+					ContainerName: "app",
+					ResourcePhases: map[corev1.ResourceName]v1beta3.ResourcePhase{
+						corev1.ResourceCPU:    {Phase: v1beta3.ContainerResourcePhaseWorking},
+						corev1.ResourceMemory: {Phase: v1beta3.ContainerResourcePhaseGatheringData},
+					},
+				},
+			},
+			Recommendations: v1beta3.Recommendations{
+				Horizontal: v1beta3.HorizontalRecommendations{
+					TargetUtilizations: []v1beta3.HPATargetUtilizationRecommendationPerContainer{
+						{ContainerName: "app", TargetUtilization: map[corev1.ResourceName]int32{corev1.ResourceCPU: 70}},
+					},
+					MinReplicas: []v1beta3.ReplicasRecommendation{
+						{From: 9, To: 10, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: 2},
+						{From: 10, To: 11, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: 3},
+					},
+					MaxReplicas: []v1beta3.ReplicasRecommendation{
+						{From: 10, To: 11, WeekDay: ptr.To("Monday"), TimeZone: "UTC", Value: 0},
+					},
+				},
+				Vertical: v1beta3.VerticalRecommendations{
+					ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+						{
+							ContainerName: "app",
+							RecommendedResource: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("500m"),
+								corev1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				},
+			},
+			Conditions: v1beta3.Conditions{
+				TortoiseConditions: []v1beta3.TortoiseCondition{
+					{
+						Type:    v1beta3.TortoiseConditionTypeEffectiveModeOverridden,
+						Status:  corev1.ConditionTrue,
+						Reason:  "GlobalDisableMode",
+						Message: "tortoise is disabled globally",
+					},
+					{
+						Type:   v1beta3.TortoiseConditionTypeFailedToReconcile,
+						Status: corev1.ConditionFalse,
+					},
+				},
+				ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+					{
+						ContainerName: "app",
+						Resource: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestNewTortoiseReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		tortoise *v1beta3.Tortoise
+		want     TortoiseReport
+	}{
+		{
+			name:     "report all the information",
+			tortoise: testTortoise(),
+			want: TortoiseReport{
+				Name:        "tortoise",
+				Namespace:   "default",
+				UpdateMode:  v1beta3.UpdateModeAuto,
+				Phase:       v1beta3.TortoisePhaseWorking,
+				MinReplicas: ptr.To[int32](3),
+				// The slot value 0 means no recommendation yet.
+				MaxReplicas: nil,
+				Containers: []ContainerReport{
+					{
+						ContainerName: "app",
+						Resources: []ResourceReport{
+							{
+								ResourceName:       corev1.ResourceCPU,
+								Policy:             v1beta3.AutoscalingTypeHorizontal,
+								Phase:              v1beta3.ContainerResourcePhaseWorking,
+								CurrentRequest:     ptr.To(resource.MustParse("1")),
+								RecommendedRequest: ptr.To(resource.MustParse("500m")),
+								TargetUtilization:  ptr.To[int32](70),
+							},
+							{
+								ResourceName:       corev1.ResourceMemory,
+								Policy:             v1beta3.AutoscalingTypeVertical,
+								Phase:              v1beta3.ContainerResourcePhaseGatheringData,
+								CurrentRequest:     ptr.To(resource.MustParse("2Gi")),
+								RecommendedRequest: ptr.To(resource.MustParse("1Gi")),
+							},
+						},
+					},
+				},
+				ActiveConditions: []v1beta3.TortoiseCondition{
+					{
+						Type:    v1beta3.TortoiseConditionTypeEffectiveModeOverridden,
+						Status:  corev1.ConditionTrue,
+						Reason:  "GlobalDisableMode",
+						Message: "tortoise is disabled globally",
+					},
+				},
+			},
+		},
+		{
+			name: "newly created tortoise",
+			tortoise: &v1beta3.Tortoise{
+				ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+				Spec: v1beta3.TortoiseSpec{
+					AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+						{ContainerName: "app", Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{corev1.ResourceCPU: v1beta3.AutoscalingTypeHorizontal}},
+					},
+				},
+			},
+			want: TortoiseReport{
+				Name:       "tortoise",
+				Namespace:  "default",
+				UpdateMode: v1beta3.UpdateModeOff,
+				Containers: []ContainerReport{
+					{
+						ContainerName: "app",
+						Resources: []ResourceReport{
+							{ResourceName: corev1.ResourceCPU, Policy: v1beta3.AutoscalingTypeHorizontal},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTortoiseReport(tt.tortoise, now)
+			if d := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 })); d != "" {
+				t.Errorf("NewTortoiseReport() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	reports := []TortoiseReport{NewTortoiseReport(testTortoise(), now)}
+
+	t.Run("table", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := Write(buf, reports, OutputFormatTable); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		want := `Tortoise:                    default/tortoise
+UpdateMode:                  Auto
+Phase:                       Working
+MinReplicas (current slot):  3
+MaxReplicas (current slot):  -
+Conditions:                  EffectiveModeOverridden (GlobalDisableMode): tortoise is disabled globally
+
+CONTAINER  RESOURCE  POLICY      PHASE          CURRENT  RECOMMENDED  TARGET UTILIZATION
+app        cpu       Horizontal  Working        1        500m         70%
+app        memory    Vertical    GatheringData  2Gi      1Gi          -
+`
+		if d := cmp.Diff(want, buf.String()); d != "" {
+			t.Errorf("Write() diff = %s", d)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := Write(buf, reports, OutputFormatJSON); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if !strings.Contains(buf.String(), `"targetUtilization": 70`) {
+			t.Errorf("Write() should output the target utilization in JSON, got %s", buf.String())
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := Write(buf, reports, OutputFormatYAML); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if !strings.Contains(buf.String(), "recommendedRequest: 500m") {
+			t.Errorf("Write() should output the recommended request in YAML, got %s", buf.String())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := Write(&bytes.Buffer{}, reports, "xml"); err == nil {
+			t.Errorf("Write() should return an error for the unknown format")
+		}
+	})
+}
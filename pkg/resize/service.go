@@ -0,0 +1,221 @@
+package resize
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+const (
+	// podConditionResizePending and podConditionResizeInProgress are the Pod conditions to represent the resize status
+	// in the newer Kubernetes versions, which replace .status.resize.
+	podConditionResizePending    corev1.PodConditionType = "PodResizePending"
+	podConditionResizeInProgress corev1.PodConditionType = "PodResizeInProgress"
+
+	// resizeSubresource is the subresource of Pods to resize the resources of the containers in place.
+	resizeSubresource = "resize"
+)
+
+// Service resizes the resources of the running Pods in place,
+// instead of restarting the scale target.
+type Service struct {
+	// c is used to patch the resize subresource of the Pods.
+	c client.Client
+	// reader is used to list the Pods.
+	// It's expected to be the uncached reader so that the controller doesn't have to cache all the Pods in the cluster.
+	reader     client.Reader
+	podService *pod.Service
+	recorder   record.EventRecorder
+}
+
+func New(c client.Client, reader client.Reader, podService *pod.Service, recorder record.EventRecorder) *Service {
+	return &Service{c: c, reader: reader, podService: podService, recorder: recorder}
+}
+
+// Enabled returns true if the tortoise resizes the Pods in place.
+func Enabled(tortoise *v1beta3.Tortoise) bool {
+	return tortoise.Spec.ResourceUpdateStrategy == v1beta3.ResourceUpdateStrategyInPlace
+}
+
+// ResizePods resizes the running Pods matching the selector in place, based on .status.conditions.containerResourceRequests in the tortoise.
+// It returns true when the Pods cannot be resized in place and the scale target has to be restarted instead.
+func (s *Service) ResizePods(ctx context.Context, tortoise *v1beta3.Tortoise, selector *metav1.LabelSelector, now time.Time) (*v1beta3.Tortoise, bool, error) {
+	pods, err := s.listPods(ctx, tortoise.Namespace, selector)
+	if err != nil {
+		return tortoise, false, err
+	}
+
+	resized := 0
+	for i := range pods {
+		p := &pods[i]
+		if !p.DeletionTimestamp.IsZero() || p.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		desired := p.DeepCopy()
+		s.podService.ModifyPodSpecResource(&desired.Spec, tortoise)
+		if reason, ok := resizableInPlace(p, desired); !ok {
+			return s.fallback(ctx, tortoise, fmt.Sprintf("the Pod %s cannot be resized in place: %s", p.Name, reason), now), true, nil
+		}
+		if apiequality.Semantic.DeepEqual(p.Spec.Containers, desired.Spec.Containers) && apiequality.Semantic.DeepEqual(p.Spec.InitContainers, desired.Spec.InitContainers) {
+			// Already resized.
+			continue
+		}
+
+		if err := s.c.SubResource(resizeSubresource).Patch(ctx, desired, client.StrategicMergeFrom(p)); err != nil {
+			// e.g., the cluster doesn't support the in-place resize, or the resize changes the QoS class of the Pod.
+			log.FromContext(ctx).Error(err, "failed to resize the Pod in place", "tortoise", klog.KObj(tortoise), "pod", klog.KObj(p))
+			return s.fallback(ctx, tortoise, fmt.Sprintf("failed to resize the Pod %s in place: %v", p.Name, err), now), true, nil
+		}
+		resized++
+	}
+	if resized == 0 {
+		return tortoise, false, nil
+	}
+
+	message := fmt.Sprintf("%d Pods are being resized in place to apply the recommendation from Tortoise", resized)
+	tortoise = utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeResizingInPlace, corev1.ConditionTrue, "Resizing", message, now)
+	s.recorder.Event(tortoise, corev1.EventTypeNormal, event.PodsResizedInPlace, message)
+	log.FromContext(ctx).Info(message, "tortoise", klog.KObj(tortoise))
+
+	return tortoise, false, nil
+}
+
+// CheckResize checks the resize status of the Pods being resized in place.
+// It returns true when the resize of any Pod is infeasible or deferred, and the scale target has to be restarted instead.
+func (s *Service) CheckResize(ctx context.Context, tortoise *v1beta3.Tortoise, selector *metav1.LabelSelector, now time.Time) (*v1beta3.Tortoise, bool, error) {
+	cond := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeResizingInPlace)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return tortoise, false, nil
+	}
+
+	pods, err := s.listPods(ctx, tortoise.Namespace, selector)
+	if err != nil {
+		return tortoise, false, err
+	}
+
+	inProgress := false
+	for i := range pods {
+		p := &pods[i]
+		if !p.DeletionTimestamp.IsZero() || p.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		switch status, message := resizeStatus(p); status {
+		case corev1.PodResizeStatusInfeasible, corev1.PodResizeStatusDeferred:
+			return s.fallback(ctx, tortoise, fmt.Sprintf("the resize of the Pod %s is %s: %s", p.Name, status, message), now), true, nil
+		case corev1.PodResizeStatusProposed, corev1.PodResizeStatusInProgress:
+			inProgress = true
+		default:
+			// The resize status may not be reported yet right after the resize subresource is patched,
+			// so the Pod is regarded as resized only when the kubelet reports the new resources.
+			if !resourcesApplied(p) {
+				inProgress = true
+			}
+		}
+	}
+	if inProgress {
+		return tortoise, false, nil
+	}
+
+	tortoise = utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeResizingInPlace, corev1.ConditionFalse, "Resized", "The Pods are resized in place", now)
+	log.FromContext(ctx).Info("the Pods are resized in place", "tortoise", klog.KObj(tortoise))
+	return tortoise, false, nil
+}
+
+// fallback marks the in-place resize as given up, so that the scale target is restarted instead.
+func (s *Service) fallback(ctx context.Context, tortoise *v1beta3.Tortoise, reason string, now time.Time) *v1beta3.Tortoise {
+	message := fmt.Sprintf("The Pods are restarted instead of the in-place resize because %s", reason)
+	tortoise = utils.ChangeTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeResizingInPlace, corev1.ConditionFalse, "FellBackToRolloutRestart", message, now)
+	s.recorder.Event(tortoise, corev1.EventTypeWarning, event.InPlaceResizeFailed, message)
+	log.FromContext(ctx).Info(message, "tortoise", klog.KObj(tortoise))
+	return tortoise
+}
+
+func (s *Service) listPods(ctx context.Context, namespace string, selector *metav1.LabelSelector) ([]corev1.Pod, error) {
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert the label selector: %w", err)
+	}
+	pods := &corev1.PodList{}
+	if err := s.reader.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: sel}); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// resizableInPlace returns false with the reason if the change from current to desired cannot be done by the in-place resize,
+// which can change only the resources of the containers (and the native sidecars).
+// For example, GOMAXPROCS or GOMEMLIMIT environment variables modified together with the resources require the restart.
+func resizableInPlace(current, desired *corev1.Pod) (string, bool) {
+	currentContainers, desiredContainers := utils.ScalableContainers(&current.Spec), utils.ScalableContainers(&desired.Spec)
+	for i := range currentContainers {
+		c, d := currentContainers[i], desiredContainers[i]
+		if !reflect.DeepEqual(c.Env, d.Env) {
+			return fmt.Sprintf("the environment variables of the container %s have to be changed", c.Name), false
+		}
+	}
+	return "", true
+}
+
+// resourcesApplied returns true if the resource requests of all the containers (and the native sidecars) in the Pod spec
+// are reported in the container statuses, that is, the kubelet has applied them.
+// .status.containerStatuses[].resources is used, or .status.containerStatuses[].allocatedResources if it's not reported.
+func resourcesApplied(p *corev1.Pod) bool {
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, cs := range p.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+	for _, cs := range p.Status.InitContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+
+	for _, c := range utils.ScalableContainers(&p.Spec) {
+		cs, ok := statuses[c.Name]
+		if !ok {
+			return false
+		}
+		applied := cs.AllocatedResources
+		if cs.Resources != nil {
+			applied = cs.Resources.Requests
+		}
+		for k, want := range c.Resources.Requests {
+			got, ok := applied[k]
+			if !ok || got.Cmp(want) != 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// resizeStatus returns the resize status of the Pod.
+// It supports both .status.resize and the Pod conditions, which replace .status.resize in the newer Kubernetes versions.
+func resizeStatus(p *corev1.Pod) (corev1.PodResizeStatus, string) {
+	for _, c := range p.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case podConditionResizePending:
+			// The reason is either Deferred or Infeasible.
+			return corev1.PodResizeStatus(c.Reason), c.Message
+		case podConditionResizeInProgress:
+			return corev1.PodResizeStatusInProgress, c.Message
+		}
+	}
+	return p.Status.Resize, ""
+}
@@ -0,0 +1,256 @@
+package resize
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/pod"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+var (
+	now      = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "mercari"}}
+)
+
+func testTortoise(conditions ...v1beta3.TortoiseCondition) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode:             v1beta3.UpdateModeAuto,
+			ResourceUpdateStrategy: v1beta3.ResourceUpdateStrategyInPlace,
+		},
+		Status: v1beta3.TortoiseStatus{
+			TortoisePhase: v1beta3.TortoisePhaseWorking,
+			Conditions: v1beta3.Conditions{
+				TortoiseConditions: conditions,
+				ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+					{
+						ContainerName: "app",
+						Resource: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func testPod(name string, env []corev1.EnvVar) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "mercari"}},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "app",
+					Env:  env,
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func resizing() v1beta3.TortoiseCondition {
+	return v1beta3.TortoiseCondition{
+		Type:               v1beta3.TortoiseConditionTypeResizingInPlace,
+		Status:             corev1.ConditionTrue,
+		Reason:             "Resizing",
+		LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+		LastUpdateTime:     metav1.NewTime(now.Add(-time.Minute)),
+	}
+}
+
+func newService(t *testing.T, pods ...client.Object) (*Service, client.Client) {
+	t.Helper()
+	podService, err := pod.New(map[string]int64{}, "", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("pod.New() error = %v", err)
+	}
+	c := fake.NewClientBuilder().WithObjects(pods...).Build()
+	return New(c, c, podService, record.NewFakeRecorder(10)), c
+}
+
+func TestService_ResizePods(t *testing.T) {
+	tests := []struct {
+		name          string
+		pods          []client.Object
+		wantRestart   bool
+		wantResized   bool
+		wantCondition *corev1.ConditionStatus
+	}{
+		{
+			name:          "the running Pods are resized in place",
+			pods:          []client.Object{testPod("pod", nil)},
+			wantResized:   true,
+			wantCondition: ptr.To(corev1.ConditionTrue),
+		},
+		{
+			name:          "fall back to the restart when GOMAXPROCS has to be changed",
+			pods:          []client.Object{testPod("pod", []corev1.EnvVar{{Name: "GOMAXPROCS", Value: "1"}})},
+			wantRestart:   true,
+			wantCondition: ptr.To(corev1.ConditionFalse),
+		},
+		{
+			name: "no running Pods",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, c := newService(t, tt.pods...)
+			got, restart, err := s.ResizePods(context.Background(), testTortoise(), selector, now)
+			if err != nil {
+				t.Fatalf("ResizePods() error = %v", err)
+			}
+			if restart != tt.wantRestart {
+				t.Errorf("ResizePods() restart = %v, want %v", restart, tt.wantRestart)
+			}
+			cond := utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeResizingInPlace)
+			if tt.wantCondition == nil {
+				if cond != nil {
+					t.Errorf("ResizingInPlace condition should not be set: %v", cond)
+				}
+			} else if cond == nil || cond.Status != *tt.wantCondition {
+				t.Errorf("ResizingInPlace condition = %v, want %v", cond, *tt.wantCondition)
+			}
+
+			if len(tt.pods) == 0 {
+				return
+			}
+			p := &corev1.Pod{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "pod"}, p); err != nil {
+				t.Fatalf("failed to get pod: %v", err)
+			}
+			want := resource.MustParse("1Gi")
+			if tt.wantResized {
+				want = resource.MustParse("2Gi")
+			}
+			if d := cmp.Diff(want, p.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory]); d != "" {
+				t.Errorf("memory request diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestService_CheckResize(t *testing.T) {
+	withStatus := func(status corev1.PodResizeStatus) *corev1.Pod {
+		p := testPod("pod", nil)
+		p.Status.Resize = status
+		return p
+	}
+	withCondition := func(reason string) *corev1.Pod {
+		p := testPod("pod", nil)
+		p.Status.Conditions = []corev1.PodCondition{{Type: podConditionResizePending, Status: corev1.ConditionTrue, Reason: reason}}
+		return p
+	}
+	// withApplied returns the Pod whose container status reports the resources.
+	withApplied := func(cs corev1.ContainerStatus) *corev1.Pod {
+		p := testPod("pod", nil)
+		cs.Name = "app"
+		p.Status.ContainerStatuses = []corev1.ContainerStatus{cs}
+		return p
+	}
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}
+	tests := []struct {
+		name          string
+		tortoise      *v1beta3.Tortoise
+		pods          []client.Object
+		wantRestart   bool
+		wantCondition corev1.ConditionStatus
+		wantReason    string
+	}{
+		{
+			name:          "the resize is completed",
+			tortoise:      testTortoise(resizing()),
+			pods:          []client.Object{withApplied(corev1.ContainerStatus{Resources: &corev1.ResourceRequirements{Requests: requests}})},
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    "Resized",
+		},
+		{
+			name:          "the resize is completed (allocatedResources)",
+			tortoise:      testTortoise(resizing()),
+			pods:          []client.Object{withApplied(corev1.ContainerStatus{AllocatedResources: requests})},
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    "Resized",
+		},
+		{
+			name:          "the resize status isn't reported yet",
+			tortoise:      testTortoise(resizing()),
+			pods:          []client.Object{testPod("pod", nil)},
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "Resizing",
+		},
+		{
+			name:     "the new resources aren't applied yet",
+			tortoise: testTortoise(resizing()),
+			pods: []client.Object{withApplied(corev1.ContainerStatus{Resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("500m"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			}}})},
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "Resizing",
+		},
+		{
+			name:          "the resize is in progress",
+			tortoise:      testTortoise(resizing()),
+			pods:          []client.Object{withStatus(corev1.PodResizeStatusInProgress)},
+			wantCondition: corev1.ConditionTrue,
+			wantReason:    "Resizing",
+		},
+		{
+			name:          "the resize is infeasible",
+			tortoise:      testTortoise(resizing()),
+			pods:          []client.Object{withStatus(corev1.PodResizeStatusInfeasible)},
+			wantRestart:   true,
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    "FellBackToRolloutRestart",
+		},
+		{
+			name:          "the resize is deferred (PodResizePending condition)",
+			tortoise:      testTortoise(resizing()),
+			pods:          []client.Object{withCondition("Deferred")},
+			wantRestart:   true,
+			wantCondition: corev1.ConditionFalse,
+			wantReason:    "FellBackToRolloutRestart",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newService(t, tt.pods...)
+			got, restart, err := s.CheckResize(context.Background(), tt.tortoise, selector, now)
+			if err != nil {
+				t.Fatalf("CheckResize() error = %v", err)
+			}
+			if restart != tt.wantRestart {
+				t.Errorf("CheckResize() restart = %v, want %v", restart, tt.wantRestart)
+			}
+			cond := utils.GetTortoiseCondition(got, v1beta3.TortoiseConditionTypeResizingInPlace)
+			if cond == nil || cond.Status != tt.wantCondition || cond.Reason != tt.wantReason {
+				t.Errorf("ResizingInPlace condition = %v, want status %v and reason %v", cond, tt.wantCondition, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+const (
+	// reasonOOMKilled is the reason of the container termination when the container is killed by OOM.
+	reasonOOMKilled = "OOMKilled"
+	// reasonCrashLoopBackOff is the reason of the container waiting when the container keeps crashing.
+	reasonCrashLoopBackOff = "CrashLoopBackOff"
+)
+
+// Service rolls the resource requests back to the previous ones
+// when the Pods crash (OOMKilled or CrashLoopBackOff) after Tortoise lowered the resource requests.
+type Service struct {
+	// c is used only to list the Pods.
+	// It's expected to be the uncached reader so that the controller doesn't have to cache all the Pods in the cluster.
+	c        client.Reader
+	recorder record.EventRecorder
+
+	// window is the period after the change of the resource requests, during which the restarted Pods are watched.
+	// 0 means the rollback is disabled.
+	window time.Duration
+	// historyService records the rollback in the recommendation history.
+	historyService HistoryService
+}
+
+// HistoryService records the changes applied by tortoise.
+type HistoryService interface {
+	Append(ctx context.Context, tortoise *v1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) error
+}
+
+func New(c client.Reader, recorder record.EventRecorder, window time.Duration, historyService HistoryService) *Service {
+	return &Service{c: c, recorder: recorder, window: window, historyService: historyService}
+}
+
+// RecordPreviousRequests records the resource requests before the change in .status.conditions.previousContainerResourceRequests,
+// if the resource requests are changed from beforeUpdate to tortoise.
+func (s *Service) RecordPreviousRequests(beforeUpdate, tortoise *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	if s.window == 0 {
+		return tortoise
+	}
+	if reflect.DeepEqual(beforeUpdate.Status.Conditions.ContainerResourceRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
+		return tortoise
+	}
+
+	tortoise.Status.Conditions.PreviousContainerResourceRequests = copyRequests(beforeUpdate.Status.Conditions.ContainerResourceRequests)
+	if cond := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack); cond != nil && cond.Status == corev1.ConditionTrue {
+		tortoise = utils.ChangeTortoiseCondition(tortoise,
+			v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack,
+			corev1.ConditionFalse,
+			"",
+			"The new resource requests are applied after the rollback",
+			now,
+		)
+	}
+	return tortoise
+}
+
+// RollbackIfPodsCrash checks the Pods which are created after the last change of the resource requests,
+// and rolls the resource requests back to the previous ones if any of them is OOMKilled or in CrashLoopBackOff
+// with the container whose resource request was lowered by the change.
+// It also raises the floor of the resource request of the crashed container to the previous value.
+func (s *Service) RollbackIfPodsCrash(ctx context.Context, tortoise *v1beta3.Tortoise, selector *metav1.LabelSelector, now time.Time) (*v1beta3.Tortoise, error) {
+	if s.window == 0 || len(tortoise.Status.Conditions.PreviousContainerResourceRequests) == 0 {
+		return tortoise, nil
+	}
+
+	cond := utils.GetTortoiseCondition(tortoise, v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated)
+	if cond == nil || cond.Status != corev1.ConditionTrue {
+		return tortoise, nil
+	}
+	changedAt := cond.LastTransitionTime.Time
+	if now.After(changedAt.Add(s.window)) {
+		// The Pods have been fine long enough after the change.
+		return tortoise, nil
+	}
+
+	lowered := loweredResources(tortoise.Status.Conditions.PreviousContainerResourceRequests, tortoise.Status.Conditions.ContainerResourceRequests)
+	if len(lowered) == 0 {
+		// The crash (if any) isn't caused by lowering the resource requests.
+		return tortoise, nil
+	}
+
+	sel, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return tortoise, fmt.Errorf("failed to convert the label selector: %w", err)
+	}
+	pods := &corev1.PodList{}
+	if err := s.c.List(ctx, pods, client.InNamespace(tortoise.Namespace), client.MatchingLabelsSelector{Selector: sel}); err != nil {
+		return tortoise, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	crashed := crashedResources(pods.Items, lowered, changedAt)
+	if len(crashed) == 0 {
+		return tortoise, nil
+	}
+
+	return s.rollback(ctx, tortoise, crashed, now), nil
+}
+
+func (s *Service) rollback(ctx context.Context, tortoise *v1beta3.Tortoise, crashed map[string]map[corev1.ResourceName]string, now time.Time) *v1beta3.Tortoise {
+	previous := tortoise.Status.Conditions.PreviousContainerResourceRequests
+	current := tortoise.Status.Conditions.ContainerResourceRequests
+
+	var descriptions []string
+	for _, containerName := range sortedKeys(crashed) {
+		for _, rn := range sortedResourceNames(crashed[containerName]) {
+			descriptions = append(descriptions, fmt.Sprintf("%s/%s (%s)", containerName, rn, crashed[containerName][rn]))
+			if floor, ok := getRequest(previous, containerName, rn); ok {
+				tortoise = raiseFloor(tortoise, containerName, rn, floor)
+			}
+		}
+	}
+
+	tortoise.Status.Conditions.ContainerResourceRequests = copyRequests(previous)
+	// We don't want to roll back twice for the same change.
+	tortoise.Status.Conditions.PreviousContainerResourceRequests = nil
+
+	message := fmt.Sprintf("The resource requests are rolled back to the previous ones because the Pods crashed after the resource requests were lowered: %s", strings.Join(descriptions, ", "))
+	tortoise = utils.ChangeTortoiseCondition(tortoise,
+		v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack,
+		corev1.ConditionTrue,
+		"PodsCrashed",
+		message,
+		now,
+	)
+	// It also delays the next decrease of the resource requests.
+	tortoise = utils.ChangeTortoiseCondition(tortoise,
+		v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+		corev1.ConditionTrue,
+		"",
+		"The resource requests are rolled back",
+		now,
+	)
+	s.recorder.Event(tortoise, corev1.EventTypeWarning, event.VerticalRecommendationRolledBack, message)
+	log.FromContext(ctx).Info("the resource requests are rolled back", "tortoise", klog.KObj(tortoise), "crashed", descriptions)
+
+	s.appendHistory(ctx, tortoise, historyEntries(current, previous, now), now)
+
+	return tortoise
+}
+
+func (s *Service) appendHistory(ctx context.Context, tortoise *v1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) {
+	if s.historyService == nil || len(entries) == 0 {
+		return
+	}
+	if err := s.historyService.Append(ctx, tortoise, entries, now); err != nil {
+		log.FromContext(ctx).Error(err, "failed to record the recommendation history", "tortoise", klog.KObj(tortoise))
+	}
+}
+
+// loweredResources returns the resources whose request is lowered from previous to current.
+func loweredResources(previous, current []v1beta3.ContainerResourceRequests) map[string]map[corev1.ResourceName]bool {
+	lowered := map[string]map[corev1.ResourceName]bool{}
+	for _, c := range current {
+		for rn, q := range c.Resource {
+			prev, ok := getRequest(previous, c.ContainerName, rn)
+			if !ok || prev.Cmp(q) <= 0 {
+				continue
+			}
+			if lowered[c.ContainerName] == nil {
+				lowered[c.ContainerName] = map[corev1.ResourceName]bool{}
+			}
+			lowered[c.ContainerName][rn] = true
+		}
+	}
+	return lowered
+}
+
+// crashedResources returns the lowered resources which are likely to cause the crash of the Pods created after changedAt.
+// OOMKilled is regarded as caused by lowering memory, and CrashLoopBackOff is regarded as caused by lowering any resource.
+// The value of the returned map is the reason of the crash.
+func crashedResources(pods []corev1.Pod, lowered map[string]map[corev1.ResourceName]bool, changedAt time.Time) map[string]map[corev1.ResourceName]string {
+	crashed := map[string]map[corev1.ResourceName]string{}
+	add := func(containerName string, rn corev1.ResourceName, reason string) {
+		if crashed[containerName] == nil {
+			crashed[containerName] = map[corev1.ResourceName]string{}
+		}
+		if _, ok := crashed[containerName][rn]; !ok {
+			crashed[containerName][rn] = reason
+		}
+	}
+
+	for _, p := range pods {
+		if p.CreationTimestamp.Time.Before(changedAt) {
+			// This Pod isn't restarted with the new resource requests.
+			continue
+		}
+		for _, cs := range p.Status.ContainerStatuses {
+			l, ok := lowered[cs.Name]
+			if !ok {
+				continue
+			}
+			if isOOMKilled(cs) && l[corev1.ResourceMemory] {
+				add(cs.Name, corev1.ResourceMemory, reasonOOMKilled)
+			}
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason == reasonCrashLoopBackOff {
+				for rn := range l {
+					add(cs.Name, rn, reasonCrashLoopBackOff)
+				}
+			}
+		}
+	}
+	return crashed
+}
+
+func isOOMKilled(cs corev1.ContainerStatus) bool {
+	if cs.State.Terminated != nil && cs.State.Terminated.Reason == reasonOOMKilled {
+		return true
+	}
+	return cs.LastTerminationState.Terminated != nil && cs.LastTerminationState.Terminated.Reason == reasonOOMKilled
+}
+
+// raiseFloor raises the floor of the resource request of the container to the given value.
+// The floor is never lowered.
+func raiseFloor(tortoise *v1beta3.Tortoise, containerName string, rn corev1.ResourceName, floor resource.Quantity) *v1beta3.Tortoise {
+	for i, f := range tortoise.Status.Conditions.ResourceRequestFloors {
+		if f.ContainerName != containerName {
+			continue
+		}
+		if current, ok := f.Resource[rn]; ok && current.Cmp(floor) >= 0 {
+			return tortoise
+		}
+		if tortoise.Status.Conditions.ResourceRequestFloors[i].Resource == nil {
+			tortoise.Status.Conditions.ResourceRequestFloors[i].Resource = corev1.ResourceList{}
+		}
+		tortoise.Status.Conditions.ResourceRequestFloors[i].Resource[rn] = floor
+		return tortoise
+	}
+
+	tortoise.Status.Conditions.ResourceRequestFloors = append(tortoise.Status.Conditions.ResourceRequestFloors, v1beta3.ContainerResourceRequests{
+		ContainerName: containerName,
+		Resource:      corev1.ResourceList{rn: floor},
+	})
+	return tortoise
+}
+
+// historyEntries returns the history entries for the rollback from current to previous.
+func historyEntries(current, previous []v1beta3.ContainerResourceRequests, now time.Time) []v1alpha1.RecommendationHistoryEntry {
+	var entries []v1alpha1.RecommendationHistoryEntry
+	for _, p := range previous {
+		for _, rn := range sortedResourceNames(p.Resource) {
+			prev := p.Resource[rn]
+			cur, ok := getRequest(current, p.ContainerName, rn)
+			if ok && cur.Cmp(prev) == 0 {
+				continue
+			}
+			e := v1alpha1.RecommendationHistoryEntry{
+				Time:          metav1.NewTime(now),
+				Target:        v1alpha1.HistoryTargetResourceRequest,
+				ContainerName: p.ContainerName,
+				ResourceName:  rn,
+				NewValue:      prev.String(),
+				Reason:        "rolled back because the Pods crashed after the resource request was lowered",
+			}
+			if ok {
+				e.OldValue = cur.String()
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+func getRequest(requests []v1beta3.ContainerResourceRequests, containerName string, rn corev1.ResourceName) (resource.Quantity, bool) {
+	for _, r := range requests {
+		if r.ContainerName == containerName {
+			q, ok := r.Resource[rn]
+			return q, ok
+		}
+	}
+	return resource.Quantity{}, false
+}
+
+func copyRequests(requests []v1beta3.ContainerResourceRequests) []v1beta3.ContainerResourceRequests {
+	if requests == nil {
+		return nil
+	}
+	copied := make([]v1beta3.ContainerResourceRequests, 0, len(requests))
+	for _, r := range requests {
+		copied = append(copied, *r.DeepCopy())
+	}
+	return copied
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedResourceNames[T any](m map[corev1.ResourceName]T) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(m))
+	for rn := range m {
+		names = append(names, rn)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
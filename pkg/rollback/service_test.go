@@ -0,0 +1,252 @@
+package rollback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+var now = time.Date(2023, 1, 1, 0, 10, 0, 0, time.UTC)
+
+// changedAt is the time when the resource requests are lowered.
+var changedAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func requests(cpu, memory string) []v1beta3.ContainerResourceRequests {
+	return []v1beta3.ContainerResourceRequests{
+		{
+			ContainerName: "app",
+			Resource: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+func testTortoise(previous, current []v1beta3.ContainerResourceRequests) *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Status: v1beta3.TortoiseStatus{
+			Conditions: v1beta3.Conditions{
+				TortoiseConditions: []v1beta3.TortoiseCondition{
+					{
+						Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+						Status:             corev1.ConditionTrue,
+						Message:            "The recommendation is provided",
+						LastTransitionTime: metav1.NewTime(changedAt),
+						LastUpdateTime:     metav1.NewTime(changedAt),
+					},
+				},
+				ContainerResourceRequests:         current,
+				PreviousContainerResourceRequests: previous,
+			},
+		},
+	}
+}
+
+func testPod(name string, createdAt time.Time, status corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Labels:            map[string]string{"app": "mercari"},
+			CreationTimestamp: metav1.NewTime(createdAt),
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{status},
+		},
+	}
+}
+
+func oomKilled() corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name: "app",
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137},
+		},
+	}
+}
+
+func crashLoopBackOff() corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name: "app",
+		State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+		},
+	}
+}
+
+func TestService_RollbackIfPodsCrash(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   time.Duration
+		tortoise *v1beta3.Tortoise
+		pods     []client.Object
+		// wantRolledBack is true if the resource requests should be rolled back to the previous ones.
+		wantRolledBack bool
+		wantFloors     []v1beta3.ContainerResourceRequests
+	}{
+		{
+			name:           "OOMKilled after memory is lowered",
+			window:         15 * time.Minute,
+			tortoise:       testTortoise(requests("1", "1Gi"), requests("500m", "500Mi")),
+			pods:           []client.Object{testPod("pod", changedAt.Add(time.Minute), oomKilled())},
+			wantRolledBack: true,
+			wantFloors: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			},
+		},
+		{
+			name:           "CrashLoopBackOff after resources are lowered",
+			window:         15 * time.Minute,
+			tortoise:       testTortoise(requests("1", "1Gi"), requests("500m", "500Mi")),
+			pods:           []client.Object{testPod("pod", changedAt.Add(time.Minute), crashLoopBackOff())},
+			wantRolledBack: true,
+			wantFloors: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			},
+		},
+		{
+			name:     "OOMKilled after only cpu is lowered",
+			window:   15 * time.Minute,
+			tortoise: testTortoise(requests("1", "1Gi"), requests("500m", "1Gi")),
+			pods:     []client.Object{testPod("pod", changedAt.Add(time.Minute), oomKilled())},
+		},
+		{
+			name:     "the Pod created before the change is OOMKilled",
+			window:   15 * time.Minute,
+			tortoise: testTortoise(requests("1", "1Gi"), requests("500m", "500Mi")),
+			pods:     []client.Object{testPod("pod", changedAt.Add(-time.Minute), oomKilled())},
+		},
+		{
+			name:     "the window has passed",
+			window:   5 * time.Minute,
+			tortoise: testTortoise(requests("1", "1Gi"), requests("500m", "500Mi")),
+			pods:     []client.Object{testPod("pod", changedAt.Add(time.Minute), oomKilled())},
+		},
+		{
+			name:     "the resources are increased",
+			window:   15 * time.Minute,
+			tortoise: testTortoise(requests("500m", "500Mi"), requests("1", "1Gi")),
+			pods:     []client.Object{testPod("pod", changedAt.Add(time.Minute), crashLoopBackOff())},
+		},
+		{
+			name:     "the rollback is disabled",
+			window:   0,
+			tortoise: testTortoise(requests("1", "1Gi"), requests("500m", "500Mi")),
+			pods:     []client.Object{testPod("pod", changedAt.Add(time.Minute), oomKilled())},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.pods...).Build()
+			s := New(c, record.NewFakeRecorder(10), tt.window, nil)
+			previous := tt.tortoise.Status.Conditions.PreviousContainerResourceRequests
+			current := tt.tortoise.Status.Conditions.ContainerResourceRequests
+
+			got, err := s.RollbackIfPodsCrash(context.Background(), tt.tortoise.DeepCopy(), &metav1.LabelSelector{MatchLabels: map[string]string{"app": "mercari"}}, now)
+			if err != nil {
+				t.Fatalf("RollbackIfPodsCrash() error = %v", err)
+			}
+
+			wantRequests := current
+			if tt.wantRolledBack {
+				wantRequests = previous
+			}
+			if d := cmp.Diff(wantRequests, got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("ContainerResourceRequests diff = %s", d)
+			}
+			if d := cmp.Diff(tt.wantFloors, got.Status.Conditions.ResourceRequestFloors); d != "" {
+				t.Errorf("ResourceRequestFloors diff = %s", d)
+			}
+
+			var rolledBack bool
+			for _, c := range got.Status.Conditions.TortoiseConditions {
+				if c.Type == v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack && c.Status == corev1.ConditionTrue {
+					rolledBack = true
+				}
+			}
+			if rolledBack != tt.wantRolledBack {
+				t.Errorf("VerticalRecommendationRolledBack condition = %v, want %v", rolledBack, tt.wantRolledBack)
+			}
+			if tt.wantRolledBack && got.Status.Conditions.PreviousContainerResourceRequests != nil {
+				t.Errorf("PreviousContainerResourceRequests should be cleared after the rollback")
+			}
+		})
+	}
+}
+
+func TestService_RecordPreviousRequests(t *testing.T) {
+	rolledBack := v1beta3.TortoiseCondition{
+		Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack,
+		Status:             corev1.ConditionTrue,
+		Reason:             "PodsCrashed",
+		LastTransitionTime: metav1.NewTime(changedAt),
+		LastUpdateTime:     metav1.NewTime(changedAt),
+	}
+	tests := []struct {
+		name         string
+		beforeUpdate *v1beta3.Tortoise
+		tortoise     *v1beta3.Tortoise
+		want         *v1beta3.Tortoise
+	}{
+		{
+			name: "the resource requests are changed",
+			beforeUpdate: &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Conditions: v1beta3.Conditions{
+				ContainerResourceRequests: requests("1", "1Gi"),
+			}}},
+			tortoise: &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Conditions: v1beta3.Conditions{
+				TortoiseConditions:        []v1beta3.TortoiseCondition{rolledBack},
+				ContainerResourceRequests: requests("500m", "500Mi"),
+			}}},
+			want: &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Conditions: v1beta3.Conditions{
+				TortoiseConditions: []v1beta3.TortoiseCondition{
+					{
+						Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationRolledBack,
+						Status:             corev1.ConditionFalse,
+						Message:            "The new resource requests are applied after the rollback",
+						LastTransitionTime: metav1.NewTime(now),
+						LastUpdateTime:     metav1.NewTime(now),
+					},
+				},
+				ContainerResourceRequests:         requests("500m", "500Mi"),
+				PreviousContainerResourceRequests: requests("1", "1Gi"),
+			}}},
+		},
+		{
+			name: "the resource requests are not changed",
+			beforeUpdate: &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Conditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("1", "1Gi"),
+				PreviousContainerResourceRequests: requests("2", "2Gi"),
+			}}},
+			tortoise: &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Conditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("1", "1Gi"),
+				PreviousContainerResourceRequests: requests("2", "2Gi"),
+			}}},
+			want: &v1beta3.Tortoise{Status: v1beta3.TortoiseStatus{Conditions: v1beta3.Conditions{
+				ContainerResourceRequests:         requests("1", "1Gi"),
+				PreviousContainerResourceRequests: requests("2", "2Gi"),
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, record.NewFakeRecorder(10), 15*time.Minute, nil)
+			got := s.RecordPreviousRequests(tt.beforeUpdate, tt.tortoise, now)
+			if d := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); d != "" {
+				t.Errorf("RecordPreviousRequests() diff = %s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package rollout
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var _ workload.Adapter = &Service{}
+
+// Get implements workload.Adapter.
+func (c *Service) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (client.Object, error) {
+	ro, err := c.GetRolloutOnTortoise(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	return ro, nil
+}
+
+// PodTemplate implements workload.Adapter.
+func (c *Service) PodTemplate(w client.Object) (*corev1.PodTemplateSpec, error) {
+	ro, err := workload.As[*unstructured.Unstructured](w)
+	if err != nil {
+		return nil, err
+	}
+	return PodTemplate(ro)
+}
+
+// ResourceRequests implements workload.Adapter.
+func (c *Service) ResourceRequests(w client.Object) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
+	ro, err := workload.As[*unstructured.Unstructured](w)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetResourceRequests(ro)
+}
+
+// Restart implements workload.Adapter.
+func (c *Service) Restart(ctx context.Context, w client.Object, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	ro, err := workload.As[*unstructured.Unstructured](w)
+	if err != nil {
+		return err
+	}
+	return c.RolloutRestart(ctx, ro, tortoise, now)
+}
+
+// RolloutCompleted implements workload.Adapter.
+func (c *Service) RolloutCompleted(w client.Object) (bool, error) {
+	ro, err := workload.As[*unstructured.Unstructured](w)
+	if err != nil {
+		return false, err
+	}
+	return Completed(ro)
+}
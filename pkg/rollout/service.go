@@ -0,0 +1,137 @@
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+// Kind is the kind of Argo Rollouts' Rollout.
+const Kind = "Rollout"
+
+// GroupVersionKind is the GroupVersionKind of Argo Rollouts' Rollout.
+// Tortoise handles Rollout as unstructured.Unstructured so that it doesn't depend on the Argo Rollouts module.
+var GroupVersionKind = schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: Kind}
+
+type Service struct {
+	c        client.Client
+	recorder record.EventRecorder
+
+	// sidecarInjectors is the map from the name of the sidecar container injected by a mutating webhook (e.g., istio-proxy)
+	// to the annotations which configure its resources.
+	sidecarInjectors map[string]config.SidecarInjector
+}
+
+func New(c client.Client, sidecarInjectors map[string]config.SidecarInjector, recorder record.EventRecorder) *Service {
+	return &Service{c: c, sidecarInjectors: sidecarInjectors, recorder: recorder}
+}
+
+func (c *Service) GetRolloutOnTortoise(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*unstructured.Unstructured, error) {
+	ro := &unstructured.Unstructured{}
+	ro.SetGroupVersionKind(GroupVersionKind)
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, ro); err != nil {
+		return nil, fmt.Errorf("failed to get rollout on tortoise: %w", err)
+	}
+	return ro, nil
+}
+
+// RolloutRestart restarts the Pods in the Rollout by setting .spec.restartAt.
+// Unlike Deployment, Rollout doesn't create a new revision by the change of the Pod template annotation;
+// the Argo Rollouts controller evicts the Pods created before .spec.restartAt one by one, respecting .spec.strategy.canary.maxUnavailable.
+func (c *Service) RolloutRestart(ctx context.Context, ro *unstructured.Unstructured, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	patch := client.MergeFrom(ro.DeepCopy())
+	if err := unstructured.SetNestedField(ro.Object, now.UTC().Format(time.RFC3339), "spec", "restartAt"); err != nil {
+		return fmt.Errorf("failed to set restartAt on rollout: %w", err)
+	}
+
+	if err := c.c.Patch(ctx, ro, patch); err != nil {
+		return fmt.Errorf("failed to patch rollout: %w", err)
+	}
+
+	c.recorder.Event(tortoise, corev1.EventTypeNormal, event.RestartRollout, "Rollout is restarted to apply the recommendation from Tortoise")
+	log.FromContext(ctx).Info("Rollout is restarted to apply the recommendation from Tortoise", "tortoise", tortoise)
+
+	return nil
+}
+
+// GetResourceRequests returns the resource requests of the containers in the rollout.
+func (c *Service) GetResourceRequests(ro *unstructured.Unstructured) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
+	template, err := PodTemplate(ro)
+	if err != nil {
+		return nil, err
+	}
+	return utils.GetResourceRequestsFromPodTemplate(template, c.sidecarInjectors)
+}
+
+// PodTemplate returns the Pod template of the rollout.
+// The rollout referring to a Deployment via .spec.workloadRef isn't supported.
+func PodTemplate(ro *unstructured.Unstructured) (*corev1.PodTemplateSpec, error) {
+	if _, ok, _ := unstructured.NestedMap(ro.Object, "spec", "workloadRef"); ok {
+		return nil, fmt.Errorf("rollout %s/%s refers to the workload via .spec.workloadRef, which isn't supported", ro.GetNamespace(), ro.GetName())
+	}
+
+	m, ok, err := unstructured.NestedMap(ro.Object, "spec", "template")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the pod template of rollout: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("rollout %s/%s doesn't have the pod template", ro.GetNamespace(), ro.GetName())
+	}
+
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, template); err != nil {
+		return nil, fmt.Errorf("failed to convert the pod template of rollout: %w", err)
+	}
+	return template, nil
+}
+
+// Completed returns true if the last restart of the rollout is done and the rollout is healthy,
+// which is the same condition as `kubectl argo rollouts status`.
+func Completed(ro *unstructured.Unstructured) (bool, error) {
+	phase, _, err := unstructured.NestedString(ro.Object, "status", "phase")
+	if err != nil {
+		return false, fmt.Errorf("failed to get the phase of rollout: %w", err)
+	}
+	if phase != "Healthy" {
+		return false, nil
+	}
+
+	restartAt, ok, err := unstructured.NestedString(ro.Object, "spec", "restartAt")
+	if err != nil {
+		return false, fmt.Errorf("failed to get restartAt of rollout: %w", err)
+	}
+	if !ok {
+		return true, nil
+	}
+	restartedAt, ok, err := unstructured.NestedString(ro.Object, "status", "restartedAt")
+	if err != nil {
+		return false, fmt.Errorf("failed to get restartedAt of rollout: %w", err)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	want, err := time.Parse(time.RFC3339, restartAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse restartAt of rollout: %w", err)
+	}
+	got, err := time.Parse(time.RFC3339, restartedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse restartedAt of rollout: %w", err)
+	}
+	return !got.Before(want), nil
+}
@@ -0,0 +1,186 @@
+package rollout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+func testRollout(spec, status map[string]interface{}) *unstructured.Unstructured {
+	ro := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": "default", "name": "app"},
+		"spec":     spec,
+	}}
+	if status != nil {
+		ro.Object["status"] = status
+	}
+	ro.SetGroupVersionKind(GroupVersionKind)
+	return ro
+}
+
+func TestService_GetResourceRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		rollout *unstructured.Unstructured
+		want    []autoscalingv1beta3.ContainerResourceRequests
+		wantErr bool
+	}{
+		{
+			name: "the resource requests in the pod template",
+			rollout: testRollout(map[string]interface{}{
+				"template": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":      "app",
+								"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "500m", "memory": "1Gi"}},
+							},
+						},
+					},
+				},
+			}, nil),
+			want: []autoscalingv1beta3.ContainerResourceRequests{
+				{
+					ContainerName: "app",
+					Resource: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+				},
+			},
+		},
+		{
+			name: "the native sidecars are included, and istio-proxy as a native sidecar takes precedence over the istio annotations",
+			rollout: testRollout(map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"annotations": map[string]interface{}{"sidecar.istio.io/inject": "true", "sidecar.istio.io/proxyCPU": "1"},
+					},
+					"spec": map[string]interface{}{
+						"initContainers": []interface{}{
+							map[string]interface{}{
+								"name":      "init",
+								"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "1"}},
+							},
+							map[string]interface{}{
+								"name":          "istio-proxy",
+								"restartPolicy": "Always",
+								"resources":     map[string]interface{}{"requests": map[string]interface{}{"cpu": "200m"}},
+							},
+						},
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":      "app",
+								"resources": map[string]interface{}{"requests": map[string]interface{}{"cpu": "500m"}},
+							},
+						},
+					},
+				},
+			}, nil),
+			want: []autoscalingv1beta3.ContainerResourceRequests{
+				{
+					ContainerName: "app",
+					Resource:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Resource:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+				},
+			},
+		},
+		{
+			name:    "the rollout referring to the workload isn't supported",
+			rollout: testRollout(map[string]interface{}{"workloadRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "app"}}, nil),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(nil, map[string]config.SidecarInjector{config.IstioProxyContainerName: config.IstioSidecarInjector("100m", "100Mi")}, record.NewFakeRecorder(10))
+			got, err := s.GetResourceRequests(tt.rollout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetResourceRequests() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("GetResourceRequests() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestCompleted(t *testing.T) {
+	tests := []struct {
+		name    string
+		rollout *unstructured.Unstructured
+		want    bool
+	}{
+		{
+			name:    "healthy without restart",
+			rollout: testRollout(map[string]interface{}{}, map[string]interface{}{"phase": "Healthy"}),
+			want:    true,
+		},
+		{
+			name:    "progressing",
+			rollout: testRollout(map[string]interface{}{}, map[string]interface{}{"phase": "Progressing"}),
+			want:    false,
+		},
+		{
+			name:    "the restart isn't done yet",
+			rollout: testRollout(map[string]interface{}{"restartAt": "2023-01-01T10:00:00Z"}, map[string]interface{}{"phase": "Healthy", "restartedAt": "2022-12-01T10:00:00Z"}),
+			want:    false,
+		},
+		{
+			name:    "the restart isn't started yet",
+			rollout: testRollout(map[string]interface{}{"restartAt": "2023-01-01T10:00:00Z"}, map[string]interface{}{"phase": "Healthy"}),
+			want:    false,
+		},
+		{
+			name:    "the restart is done",
+			rollout: testRollout(map[string]interface{}{"restartAt": "2023-01-01T10:00:00Z"}, map[string]interface{}{"phase": "Healthy", "restartedAt": "2023-01-01T10:00:00Z"}),
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Completed(tt.rollout)
+			if err != nil {
+				t.Fatalf("Completed() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Completed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_RolloutRestart(t *testing.T) {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	ro := testRollout(map[string]interface{}{"replicas": int64(3)}, nil)
+	c := fake.NewClientBuilder().WithObjects(ro.DeepCopy()).Build()
+	s := New(c, nil, record.NewFakeRecorder(10))
+
+	if err := s.RolloutRestart(context.Background(), ro, &autoscalingv1beta3.Tortoise{}, now); err != nil {
+		t.Fatalf("RolloutRestart() error = %v", err)
+	}
+
+	got := &unstructured.Unstructured{}
+	got.SetGroupVersionKind(GroupVersionKind)
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(ro), got); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	restartAt, _, _ := unstructured.NestedString(got.Object, "spec", "restartAt")
+	if restartAt != "2023-01-01T10:00:00Z" {
+		t.Errorf("restartAt = %v, want 2023-01-01T10:00:00Z", restartAt)
+	}
+}
@@ -0,0 +1,285 @@
+package scheduledscaling
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+)
+
+// RecommenderName is the name of the recommender that ScheduledScaling registers in Tortoise's .spec.recommenders.
+const RecommenderName = "ScheduledScaling"
+
+const scheduledScalingFinalizer = "scheduledscaling.autoscaling.mercari.com/finalizer"
+
+type Service struct {
+	c        client.Client
+	recorder record.EventRecorder
+}
+
+func New(c client.Client, recorder record.EventRecorder) *Service {
+	return &Service{c: c, recorder: recorder}
+}
+
+func (s *Service) GetScheduledScaling(ctx context.Context, nn types.NamespacedName) (*v1alpha1.ScheduledScaling, error) {
+	ss := &v1alpha1.ScheduledScaling{}
+	if err := s.c.Get(ctx, nn, ss); err != nil {
+		return nil, fmt.Errorf("failed to get scheduled scaling: %w", err)
+	}
+	return ss, nil
+}
+
+// StartAt returns the time when the ScheduledScaling starts.
+// If .spec.schedule.startAt is empty, it starts right after the creation.
+func StartAt(s *v1alpha1.ScheduledScaling) time.Time {
+	if s.Spec.Schedule.StartAt != nil {
+		return s.Spec.Schedule.StartAt.Time
+	}
+	return s.CreationTimestamp.Time
+}
+
+// DesiredPhase returns the phase that the ScheduledScaling should be in at the given time.
+// It never returns Failed; Failed is decided by the controller when it fails to apply the constraints.
+func DesiredPhase(s *v1alpha1.ScheduledScaling, now time.Time) v1alpha1.ScheduledScalingPhase {
+	if now.Before(StartAt(s)) {
+		return v1alpha1.ScheduledScalingPhasePending
+	}
+	if !now.Before(s.Spec.Schedule.FinishAt.Time) {
+		return v1alpha1.ScheduledScalingPhaseExpired
+	}
+	return v1alpha1.ScheduledScalingPhaseApplied
+}
+
+// MergeConstraints merges the static strategies of the ScheduledScalings which are valid at the given time.
+// When multiple ScheduledScalings are valid at the same time, the highest value wins for each constraint.
+// It returns nil if no ScheduledScaling is valid.
+func MergeConstraints(scheduledScalings []v1alpha1.ScheduledScaling, now time.Time) *v1beta3.Constraints {
+	var constraints *v1beta3.Constraints
+	// containerName → index in constraints.MinAllocatedResources
+	containerIndex := map[string]int{}
+	for i := range scheduledScalings {
+		s := &scheduledScalings[i]
+		if DesiredPhase(s, now) != v1alpha1.ScheduledScalingPhaseApplied || s.Spec.Strategy.Static == nil {
+			continue
+		}
+		if constraints == nil {
+			constraints = &v1beta3.Constraints{}
+		}
+
+		static := s.Spec.Strategy.Static
+		if static.MinimumMinReplicas != nil && (constraints.MinimumMinReplicas == nil || *static.MinimumMinReplicas > *constraints.MinimumMinReplicas) {
+			constraints.MinimumMinReplicas = ptr.To(*static.MinimumMinReplicas)
+		}
+
+		for _, r := range static.MinAllocatedResources {
+			idx, ok := containerIndex[r.ContainerName]
+			if !ok {
+				constraints.MinAllocatedResources = append(constraints.MinAllocatedResources, v1beta3.ContainerResourcePolicy{
+					ContainerName:         r.ContainerName,
+					MinAllocatedResources: r.Resources.DeepCopy(),
+				})
+				containerIndex[r.ContainerName] = len(constraints.MinAllocatedResources) - 1
+				continue
+			}
+
+			current := constraints.MinAllocatedResources[idx].MinAllocatedResources
+			for name, q := range r.Resources {
+				if existing, ok := current[name]; !ok || q.Cmp(existing) > 0 {
+					current[name] = q.DeepCopy()
+				}
+			}
+		}
+	}
+
+	return constraints
+}
+
+// SyncTortoise reflects the ScheduledScalings targeting the Tortoise to the Tortoise.
+// While any ScheduledScaling is valid, it registers the ScheduledScaling recommender in .spec.recommenders
+// and sets the merged constraints in .status.recommendations.constraints.
+// Otherwise, it removes both of them so that Tortoise gets back to the normal recommendation.
+func (s *Service) SyncTortoise(ctx context.Context, namespace, tortoiseName string, now time.Time) (*v1beta3.Tortoise, error) {
+	ssList := &v1alpha1.ScheduledScalingList{}
+	if err := s.c.List(ctx, ssList, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("list scheduled scalings: %w", err)
+	}
+	targeting := []v1alpha1.ScheduledScaling{}
+	for _, ss := range ssList.Items {
+		if ss.Spec.TargetRefs.TortoiseName != tortoiseName || !ss.DeletionTimestamp.IsZero() {
+			continue
+		}
+		targeting = append(targeting, ss)
+	}
+	constraints := MergeConstraints(targeting, now)
+
+	key := types.NamespacedName{Namespace: namespace, Name: tortoiseName}
+	tortoise := &v1beta3.Tortoise{}
+	updateSpecFn := func() error {
+		tortoise = &v1beta3.Tortoise{}
+		if err := s.c.Get(ctx, key, tortoise); err != nil {
+			return err
+		}
+		recommenders := recommendersWithScheduledScaling(tortoise.Spec.Recommenders, constraints != nil)
+		if reflect.DeepEqual(recommenders, tortoise.Spec.Recommenders) {
+			return nil
+		}
+		tortoise.Spec.Recommenders = recommenders
+		return s.c.Update(ctx, tortoise)
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateSpecFn); err != nil {
+		return nil, fmt.Errorf("update recommenders in tortoise: %w", err)
+	}
+
+	updateStatusFn := func() error {
+		tortoise = &v1beta3.Tortoise{}
+		if err := s.c.Get(ctx, key, tortoise); err != nil {
+			return err
+		}
+		if constraintsEqual(tortoise.Status.Recommendations.Constraints, constraints) {
+			return nil
+		}
+		tortoise.Status.Recommendations.Constraints = constraints
+		return s.c.Status().Update(ctx, tortoise)
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateStatusFn); err != nil {
+		return nil, fmt.Errorf("update constraints in tortoise status: %w", err)
+	}
+
+	return tortoise, nil
+}
+
+func recommendersWithScheduledScaling(recommenders []v1beta3.Recommender, enabled bool) []v1beta3.Recommender {
+	var ret []v1beta3.Recommender
+	for _, r := range recommenders {
+		if r.Name == RecommenderName {
+			continue
+		}
+		ret = append(ret, r)
+	}
+	if enabled {
+		ret = append(ret, v1beta3.Recommender{
+			Name:           RecommenderName,
+			ResponsibleFor: []v1beta3.ResponsibleFor{v1beta3.ResponsibleForConstraints},
+		})
+	}
+	return ret
+}
+
+func constraintsEqual(a, b *v1beta3.Constraints) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if !reflect.DeepEqual(a.MinimumMinReplicas, b.MinimumMinReplicas) || len(a.MinAllocatedResources) != len(b.MinAllocatedResources) {
+		return false
+	}
+	for i := range a.MinAllocatedResources {
+		ra, rb := a.MinAllocatedResources[i], b.MinAllocatedResources[i]
+		if ra.ContainerName != rb.ContainerName || !resourceListEqual(ra.MinAllocatedResources, rb.MinAllocatedResources) {
+			return false
+		}
+	}
+	return true
+}
+
+// resourceListEqual compares the resource lists by their values, not by their formats.
+func resourceListEqual(a, b corev1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, qa := range a {
+		qb, ok := b[k]
+		if !ok || qa.Cmp(qb) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateScheduledScalingStatus updates the phase of the ScheduledScaling.
+// It records an event when the phase is changed to Applied, Expired or Failed.
+func (s *Service) UpdateScheduledScalingStatus(ctx context.Context, ss *v1alpha1.ScheduledScaling, phase v1alpha1.ScheduledScalingPhase, reason, message string, now time.Time) (*v1alpha1.ScheduledScaling, error) {
+	retSS := &v1alpha1.ScheduledScaling{}
+	updateFn := func() error {
+		retSS = &v1alpha1.ScheduledScaling{}
+		if err := s.c.Get(ctx, client.ObjectKeyFromObject(ss), retSS); err != nil {
+			return err
+		}
+		if retSS.Status.ScheduledScalingPhase == phase && retSS.Status.Reason == reason && retSS.Status.Message == message {
+			return nil
+		}
+		if retSS.Status.ScheduledScalingPhase != phase {
+			retSS.Status.LastTransitionTime.Time = now
+		}
+		retSS.Status.ScheduledScalingPhase = phase
+		retSS.Status.Reason = reason
+		retSS.Status.Message = message
+		return s.c.Status().Update(ctx, retSS)
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return ss, fmt.Errorf("update scheduled scaling status: %w", err)
+	}
+
+	if ss.Status.ScheduledScalingPhase != phase {
+		switch phase {
+		case v1alpha1.ScheduledScalingPhaseApplied:
+			s.recorder.Event(retSS, corev1.EventTypeNormal, event.ScheduledScalingUp, fmt.Sprintf("ScheduledScaling is applied to Tortoise %s", ss.Spec.TargetRefs.TortoiseName))
+		case v1alpha1.ScheduledScalingPhaseExpired:
+			s.recorder.Event(retSS, corev1.EventTypeNormal, event.ScheduledScalingExpired, fmt.Sprintf("ScheduledScaling is expired and removed from Tortoise %s", ss.Spec.TargetRefs.TortoiseName))
+		case v1alpha1.ScheduledScalingPhaseFailed:
+			s.recorder.Event(retSS, corev1.EventTypeWarning, event.ScheduledScalingFailed, fmt.Sprintf("ScheduledScaling failed to be applied to Tortoise %s: %s", ss.Spec.TargetRefs.TortoiseName, message))
+		}
+	}
+
+	return retSS, nil
+}
+
+func (s *Service) AddFinalizer(ctx context.Context, ss *v1alpha1.ScheduledScaling) (*v1alpha1.ScheduledScaling, error) {
+	if controllerutil.ContainsFinalizer(ss, scheduledScalingFinalizer) {
+		return ss, nil
+	}
+
+	retSS := &v1alpha1.ScheduledScaling{}
+	updateFn := func() error {
+		retSS = &v1alpha1.ScheduledScaling{}
+		if err := s.c.Get(ctx, client.ObjectKeyFromObject(ss), retSS); err != nil {
+			return err
+		}
+		controllerutil.AddFinalizer(retSS, scheduledScalingFinalizer)
+		return s.c.Update(ctx, retSS)
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return ss, fmt.Errorf("failed to add finalizer: %w", err)
+	}
+	return retSS, nil
+}
+
+func (s *Service) RemoveFinalizer(ctx context.Context, ss *v1alpha1.ScheduledScaling) error {
+	if !controllerutil.ContainsFinalizer(ss, scheduledScalingFinalizer) {
+		return nil
+	}
+
+	updateFn := func() error {
+		retSS := &v1alpha1.ScheduledScaling{}
+		if err := s.c.Get(ctx, client.ObjectKeyFromObject(ss), retSS); err != nil {
+			return err
+		}
+		controllerutil.RemoveFinalizer(retSS, scheduledScalingFinalizer)
+		return s.c.Update(ctx, retSS)
+	}
+	if err := retry.RetryOnConflict(retry.DefaultRetry, updateFn); err != nil {
+		return fmt.Errorf("failed to remove finalizer: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package scheduledscaling
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1alpha1"
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+var now = time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func scheduledScaling(name string, startAt, finishAt time.Time, static *v1alpha1.StaticStrategy) v1alpha1.ScheduledScaling {
+	return v1alpha1.ScheduledScaling{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: v1alpha1.ScheduledScalingSpec{
+			TargetRefs: v1alpha1.TargetRefs{TortoiseName: "tortoise"},
+			Schedule: v1alpha1.Schedule{
+				StartAt:  ptr.To(metav1.NewTime(startAt)),
+				FinishAt: metav1.NewTime(finishAt),
+			},
+			Strategy: v1alpha1.Strategy{Static: static},
+		},
+	}
+}
+
+func TestDesiredPhase(t *testing.T) {
+	tests := []struct {
+		name string
+		ss   v1alpha1.ScheduledScaling
+		want v1alpha1.ScheduledScalingPhase
+	}{
+		{
+			name: "before startAt",
+			ss:   scheduledScaling("ss", now.Add(time.Hour), now.Add(2*time.Hour), nil),
+			want: v1alpha1.ScheduledScalingPhasePending,
+		},
+		{
+			name: "exactly at startAt",
+			ss:   scheduledScaling("ss", now, now.Add(time.Hour), nil),
+			want: v1alpha1.ScheduledScalingPhaseApplied,
+		},
+		{
+			name: "exactly at finishAt",
+			ss:   scheduledScaling("ss", now.Add(-time.Hour), now, nil),
+			want: v1alpha1.ScheduledScalingPhaseExpired,
+		},
+		{
+			name: "startAt is not specified: starts at the creation",
+			ss: func() v1alpha1.ScheduledScaling {
+				ss := scheduledScaling("ss", now, now.Add(time.Hour), nil)
+				ss.Spec.Schedule.StartAt = nil
+				ss.CreationTimestamp = metav1.NewTime(now.Add(-time.Minute))
+				return ss
+			}(),
+			want: v1alpha1.ScheduledScalingPhaseApplied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DesiredPhase(&tt.ss, now); got != tt.want {
+				t.Errorf("DesiredPhase() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeConstraints(t *testing.T) {
+	tests := []struct {
+		name              string
+		scheduledScalings []v1alpha1.ScheduledScaling
+		want              *v1beta3.Constraints
+	}{
+		{
+			name: "no active ScheduledScaling",
+			scheduledScalings: []v1alpha1.ScheduledScaling{
+				scheduledScaling("pending", now.Add(time.Hour), now.Add(2*time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](10)}),
+				scheduledScaling("expired", now.Add(-2*time.Hour), now.Add(-time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](10)}),
+			},
+			want: nil,
+		},
+		{
+			name: "take the maximum among the active ScheduledScalings",
+			scheduledScalings: []v1alpha1.ScheduledScaling{
+				scheduledScaling("a", now.Add(-time.Hour), now.Add(time.Hour), &v1alpha1.StaticStrategy{
+					MinimumMinReplicas: ptr.To[int32](5),
+					MinAllocatedResources: []v1alpha1.ContainerResources{
+						{
+							ContainerName: "app",
+							Resources: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("2"),
+								corev1.ResourceMemory: resource.MustParse("1Gi"),
+							},
+						},
+					},
+				}),
+				scheduledScaling("b", now.Add(-time.Hour), now.Add(time.Hour), &v1alpha1.StaticStrategy{
+					MinimumMinReplicas: ptr.To[int32](10),
+					MinAllocatedResources: []v1alpha1.ContainerResources{
+						{
+							ContainerName: "app",
+							Resources: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("1"),
+								corev1.ResourceMemory: resource.MustParse("2Gi"),
+							},
+						},
+						{
+							ContainerName: "istio-proxy",
+							Resources: corev1.ResourceList{
+								corev1.ResourceCPU: resource.MustParse("500m"),
+							},
+						},
+					},
+				}),
+				scheduledScaling("pending", now.Add(time.Hour), now.Add(2*time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](100)}),
+			},
+			want: &v1beta3.Constraints{
+				MinimumMinReplicas: ptr.To[int32](10),
+				MinAllocatedResources: []v1beta3.ContainerResourcePolicy{
+					{
+						ContainerName: "app",
+						MinAllocatedResources: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						},
+					},
+					{
+						ContainerName: "istio-proxy",
+						MinAllocatedResources: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("500m"),
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeConstraints(tt.scheduledScalings, now)
+			if !constraintsEqual(got, tt.want) {
+				t.Errorf("MergeConstraints() diff = %s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestService_SyncTortoise(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1beta3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	tortoise := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"},
+			},
+		},
+	}
+	ss := scheduledScaling("ss", now.Add(-time.Hour), now.Add(time.Hour), &v1alpha1.StaticStrategy{MinimumMinReplicas: ptr.To[int32](10)})
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(tortoise, &ss).
+		WithStatusSubresource(&v1beta3.Tortoise{}).
+		Build()
+	s := New(c, record.NewFakeRecorder(10))
+
+	got, err := s.SyncTortoise(context.Background(), "default", "tortoise", now)
+	if err != nil {
+		t.Fatalf("SyncTortoise() unexpected error = %v", err)
+	}
+	wantRecommenders := []v1beta3.Recommender{{Name: RecommenderName, ResponsibleFor: []v1beta3.ResponsibleFor{v1beta3.ResponsibleForConstraints}}}
+	if d := cmp.Diff(wantRecommenders, got.Spec.Recommenders); d != "" {
+		t.Errorf("SyncTortoise() recommenders diff = %s", d)
+	}
+	if d := cmp.Diff(&v1beta3.Constraints{MinimumMinReplicas: ptr.To[int32](10)}, got.Status.Recommendations.Constraints); d != "" {
+		t.Errorf("SyncTortoise() constraints diff = %s", d)
+	}
+
+	// After the ScheduledScaling expires, Tortoise should get back to the normal recommendation.
+	got, err = s.SyncTortoise(context.Background(), "default", "tortoise", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("SyncTortoise() unexpected error = %v", err)
+	}
+	if len(got.Spec.Recommenders) != 0 {
+		t.Errorf("SyncTortoise() recommenders should be removed, got %v", got.Spec.Recommenders)
+	}
+	if got.Status.Recommendations.Constraints != nil {
+		t.Errorf("SyncTortoise() constraints should be removed, got %v", got.Status.Recommendations.Constraints)
+	}
+}
@@ -0,0 +1,139 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Record is the snapshot of the workload at the time, which is replayed in the simulation.
+type Record struct {
+	Time time.Time `json:"time"`
+	// Replicas is the number of replicas of the scale target.
+	Replicas   int32             `json:"replicas"`
+	Containers []ContainerRecord `json:"containers"`
+}
+
+type ContainerRecord struct {
+	ContainerName string `json:"containerName"`
+	// Requests are the resource requests in the scale target.
+	// They're used at the first step, and when the tortoise is Off, like the controller does.
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// Target and UpperBound are the recommendation from VPA.
+	Target     corev1.ResourceList `json:"target,omitempty"`
+	UpperBound corev1.ResourceList `json:"upperBound,omitempty"`
+}
+
+// InputFormat is the format of the records file.
+type InputFormat string
+
+const (
+	InputFormatJSON InputFormat = "json"
+	InputFormatCSV  InputFormat = "csv"
+)
+
+// csvHeader is the header of the CSV input.
+// Each row is for one container at the time, and rows with the same time are merged into one Record.
+// Resource columns can be empty.
+var csvHeader = []string{"time", "replicas", "container", "cpu_request", "memory_request", "cpu_target", "memory_target", "cpu_upper_bound", "memory_upper_bound"}
+
+// ReadRecords reads the records in the format.
+func ReadRecords(r io.Reader, format InputFormat) ([]Record, error) {
+	switch format {
+	case InputFormatJSON:
+		var records []Record
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("failed to decode the records: %w", err)
+		}
+		return records, nil
+	case InputFormatCSV:
+		return readCSV(r)
+	default:
+		return nil, fmt.Errorf("unknown input format: %s", format)
+	}
+}
+
+func readCSV(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(csvHeader)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CSV header: %w", err)
+	}
+	for i, h := range header {
+		if strings.TrimSpace(h) != csvHeader[i] {
+			return nil, fmt.Errorf("the CSV header should be %q", strings.Join(csvHeader, ","))
+		}
+	}
+
+	var records []Record
+	// time → index in records
+	index := map[time.Time]int{}
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CSV: %w", err)
+		}
+
+		t, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid time: %w", line, err)
+		}
+		replicas, err := strconv.ParseInt(row[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid replicas: %w", line, err)
+		}
+
+		c := ContainerRecord{ContainerName: row[2]}
+		for _, f := range []struct {
+			list         *corev1.ResourceList
+			resourceName corev1.ResourceName
+			value        string
+		}{
+			{&c.Requests, corev1.ResourceCPU, row[3]},
+			{&c.Requests, corev1.ResourceMemory, row[4]},
+			{&c.Target, corev1.ResourceCPU, row[5]},
+			{&c.Target, corev1.ResourceMemory, row[6]},
+			{&c.UpperBound, corev1.ResourceCPU, row[7]},
+			{&c.UpperBound, corev1.ResourceMemory, row[8]},
+		} {
+			if f.value == "" {
+				continue
+			}
+			q, err := resource.ParseQuantity(f.value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quantity %q: %w", line, f.value, err)
+			}
+			if *f.list == nil {
+				*f.list = corev1.ResourceList{}
+			}
+			(*f.list)[f.resourceName] = q
+		}
+
+		i, ok := index[t]
+		if !ok {
+			records = append(records, Record{Time: t, Replicas: int32(replicas)})
+			i = len(records) - 1
+			index[t] = i
+		}
+		if records[i].Replicas != int32(replicas) {
+			return nil, fmt.Errorf("line %d: replicas is different from the other rows at the same time", line)
+		}
+		records[i].Containers = append(records[i].Containers, c)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,111 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// OutputFormat is the format of the timeline.
+type OutputFormat string
+
+const (
+	OutputFormatTable OutputFormat = "table"
+	OutputFormatJSON  OutputFormat = "json"
+	OutputFormatCSV   OutputFormat = "csv"
+)
+
+var timelineHeader = []string{"TIME", "REPLICAS", "PHASE", "HPA MIN", "HPA MAX", "CONTAINER", "CPU REQUEST", "MEMORY REQUEST", "CPU RECOMMENDATION", "MEMORY RECOMMENDATION", "TARGET UTILIZATION"}
+
+// WriteTimeline writes the result of the simulation in the format.
+// The table and CSV have one row per container at each step.
+func WriteTimeline(w io.Writer, steps []Step, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		b, err := json.MarshalIndent(steps, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal the timeline to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case OutputFormatCSV:
+		cw := csv.NewWriter(w)
+		header := make([]string, 0, len(timelineHeader))
+		for _, h := range timelineHeader {
+			header = append(header, strings.ToLower(strings.ReplaceAll(h, " ", "_")))
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, row := range timelineRows(steps, "") {
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case OutputFormatTable, "":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(timelineHeader, "\t"))
+		for _, row := range timelineRows(steps, "-") {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
+func timelineRows(steps []Step, empty string) [][]string {
+	var rows [][]string
+	for _, s := range steps {
+		hpaMin, hpaMax := empty, empty
+		if s.HPA != nil {
+			hpaMin = fmt.Sprintf("%d", s.HPA.MinReplicas)
+			hpaMax = fmt.Sprintf("%d", s.HPA.MaxReplicas)
+		}
+		for _, c := range s.Containers {
+			rows = append(rows, []string{
+				s.Time.Format(time.RFC3339),
+				fmt.Sprintf("%d", s.Replicas),
+				string(s.Phase),
+				hpaMin,
+				hpaMax,
+				c.ContainerName,
+				quantityOr(c.Requests, corev1.ResourceCPU, empty),
+				quantityOr(c.Requests, corev1.ResourceMemory, empty),
+				quantityOr(c.Recommendation, corev1.ResourceCPU, empty),
+				quantityOr(c.Recommendation, corev1.ResourceMemory, empty),
+				targetUtilizationOr(c.TargetUtilization, empty),
+			})
+		}
+	}
+	return rows
+}
+
+func quantityOr(l corev1.ResourceList, rn corev1.ResourceName, empty string) string {
+	q, ok := l[rn]
+	if !ok {
+		return empty
+	}
+	return q.String()
+}
+
+func targetUtilizationOr(m map[corev1.ResourceName]int32, empty string) string {
+	if len(m) == 0 {
+		return empty
+	}
+	targets := make([]string, 0, len(m))
+	for rn, v := range m {
+		targets = append(targets, fmt.Sprintf("%s:%d%%", rn, v))
+	}
+	sort.Strings(targets)
+	return strings.Join(targets, " ")
+}
@@ -0,0 +1,303 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	vpav1 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/calendar"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/hpa"
+	"github.com/mercari/tortoise/pkg/recommender"
+	"github.com/mercari/tortoise/pkg/tortoise"
+	"github.com/mercari/tortoise/pkg/vpa"
+)
+
+// Simulator replays the historical metrics through the same services as the controller uses,
+// so that we can see how the changes in the recommendation logic or the configuration would behave before rolling them out.
+//
+// It doesn't talk to kube-apiserver at all; HPA and Tortoise are kept in memory and the clock is driven by the records.
+type Simulator struct {
+	tortoiseService    *tortoise.Service
+	recommenderService *recommender.Service
+	hpaService         *hpa.Service
+
+	maximumMaxReplicas int32
+}
+
+// New creates the services from the controller configuration in the same way as cmd/main.go does.
+func New(cfg *config.Config, recorder record.EventRecorder) (*Simulator, error) {
+	// The client is nil because none of the functions used in the simulation talks to kube-apiserver.
+	tortoiseService, err := tortoise.New(nil, recorder, cfg.RangeOfMinMaxReplicasRecommendationHours, cfg.RangeOfMinMaxReplicasRecommendationMinutes, cfg.TimeZone, cfg.TortoiseUpdateInterval, cfg.GatheringDataPeriodType, cfg.GlobalDisableMode, cfg.ExcludedNamespaces, cfg.DefaultApplyWindows, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create tortoise service: %w", err)
+	}
+	cal, err := calendar.New(cfg.Calendars, cfg.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("create calendar: %w", err)
+	}
+	hpaService, err := hpa.New(nil, recorder, cfg.ReplicaReductionFactor, cfg.MaximumTargetResourceUtilization, cfg.HPATargetUtilizationMaxIncrease, cfg.HPATargetUtilizationUpdateInterval, cfg.DefaultHPABehavior, cfg.MaximumMinReplicas, cfg.MaximumMaxReplicas, int32(cfg.MinimumMinReplicas), cfg.HPAExternalMetricExclusionRegex, cfg.EmergencyModeGracePeriod, cfg.MinReplicasLeadTime, cal, cfg.GlobalDisableMode, cfg.ExcludedNamespaces, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create hpa service: %w", err)
+	}
+	replicaRecommendationStrategy, err := recommender.NewReplicaRecommendationStrategy(cfg.ReplicaRecommendationStrategy, cfg.ReplicaRecommendationPeriods, cfg.ReplicaRecommendationEWMAAlpha, cfg.ReplicaRecommendationPercentile)
+	if err != nil {
+		return nil, fmt.Errorf("create replica recommendation strategy: %w", err)
+	}
+	recommenderService := recommender.New(
+		cfg.MaxReplicasRecommendationMultiplier,
+		cfg.MinReplicasRecommendationMultiplier,
+		cfg.MaximumTargetResourceUtilization,
+		cfg.MinimumTargetResourceUtilization,
+		cfg.MinimumMinReplicas,
+		cfg.PreferredMaxReplicas,
+		cfg.MinimumCPURequest,
+		cfg.MinimumMemoryRequest,
+		cfg.MinimumCPURequestPerContainer,
+		cfg.MinimumMemoryRequestPerContainer,
+		cfg.MaximumCPURequest,
+		cfg.MaximumMemoryRequest,
+		cfg.MaximumMaxReplicas,
+		cfg.MaxAllowedScalingDownRatio,
+		cfg.BufferRatioOnVerticalResource,
+		cfg.HPAMetricTargetBounds,
+		replicaRecommendationStrategy,
+		cal,
+		cfg.FeatureFlags,
+		recorder,
+	)
+
+	return &Simulator{
+		tortoiseService:    tortoiseService,
+		recommenderService: recommenderService,
+		hpaService:         hpaService,
+		maximumMaxReplicas: cfg.MaximumMaxReplicas,
+	}, nil
+}
+
+// Step is the result of one reconciliation in the simulation.
+type Step struct {
+	Time     time.Time             `json:"time"`
+	Replicas int32                 `json:"replicas"`
+	Phase    v1beta3.TortoisePhase `json:"phase"`
+	// Containers are the resource requests after the reconciliation and the recommendation.
+	Containers []ContainerStep `json:"containers"`
+	// HPA is the HPA after the reconciliation. It's nil when tortoise doesn't have any horizontal policy.
+	HPA *HPAStep `json:"hpa,omitempty"`
+}
+
+type ContainerStep struct {
+	ContainerName string `json:"containerName"`
+	// Requests are the resource requests that tortoise applies to the Pods.
+	Requests corev1.ResourceList `json:"requests,omitempty"`
+	// Recommendation is the vertical recommendation in the tortoise status.
+	Recommendation corev1.ResourceList `json:"recommendation,omitempty"`
+	// TargetUtilization is the HPA target utilization of each resource.
+	TargetUtilization map[corev1.ResourceName]int32 `json:"targetUtilization,omitempty"`
+}
+
+type HPAStep struct {
+	MinReplicas int32 `json:"minReplicas"`
+	MaxReplicas int32 `json:"maxReplicas"`
+}
+
+// Run replays the records in the chronological order, starting from the given tortoise and HPA.
+//
+// The tortoise can be a newly created one (no status) or the one exported from the cluster (with the status).
+// The HPA can be nil, then the simulator starts from the HPA that tortoise would create.
+func (s *Simulator) Run(ctx context.Context, t *v1beta3.Tortoise, currentHPA *v2.HorizontalPodAutoscaler, records []Record) ([]Step, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no records to simulate")
+	}
+	records = append([]Record{}, records...)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+
+	t = t.DeepCopy()
+	if currentHPA == nil {
+		currentHPA = s.initialHPA(t, records[0].Replicas)
+	} else {
+		currentHPA = currentHPA.DeepCopy()
+	}
+
+	steps := make([]Step, 0, len(records))
+	for _, r := range records {
+		var err error
+		t, currentHPA, err = s.reconcile(ctx, t, currentHPA, r)
+		if err != nil {
+			return steps, fmt.Errorf("simulate at %s: %w", r.Time.Format(time.RFC3339), err)
+		}
+		steps = append(steps, newStep(t, currentHPA, r))
+	}
+
+	return steps, nil
+}
+
+// reconcile does the same as the Tortoise controller's reconciliation, except talking to kube-apiserver.
+func (s *Simulator) reconcile(ctx context.Context, t *v1beta3.Tortoise, currentHPA *v2.HorizontalPodAutoscaler, r Record) (*v1beta3.Tortoise, *v2.HorizontalPodAutoscaler, error) {
+	now := r.Time
+
+	if t.Spec.UpdateMode == v1beta3.UpdateModeOff || t.Status.Conditions.ContainerResourceRequests == nil {
+		// Same as the controller: the requests in the scale target are used at the first reconciliation or when it's Off.
+		t.Status.Conditions.ContainerResourceRequests = r.requests()
+	}
+
+	t = tortoise.UpdateTortoiseAutoscalingPolicyInStatus(t, currentHPA, now)
+	t = s.tortoiseService.UpdateTortoisePhase(t, now)
+	if t.Status.TortoisePhase == v1beta3.TortoisePhaseInitializing {
+		// The controller initializes HPA and VPA here and finishes this reconciliation.
+		return t, currentHPA, nil
+	}
+
+	t = vpa.SetAllVerticalContainerResourcePhaseWorking(t, now)
+	t = s.tortoiseService.UpdateContainerRecommendationFromVPA(t, r.recommendations(), now)
+
+	var err error
+	t, err = s.recommenderService.UpdateRecommendations(ctx, t, currentHPA, r.Replicas, now)
+	if err != nil {
+		return t, currentHPA, fmt.Errorf("update recommendations: %w", err)
+	}
+
+	if t.Status.TortoisePhase == v1beta3.TortoisePhaseGatheringData {
+		return t, currentHPA, nil
+	}
+
+	if hpa.HasHorizontal(t) && t.Spec.UpdateMode != v1beta3.UpdateModeOff {
+		newHPA, newTortoise, err := s.hpaService.ChangeHPAFromTortoiseRecommendation(ctx, t, currentHPA.DeepCopy(), now, false)
+		if err != nil {
+			return t, currentHPA, fmt.Errorf("change HPA from tortoise recommendation: %w", err)
+		}
+		t = newTortoise
+		currentHPA = newHPA
+	}
+
+	t, err = s.tortoiseService.UpdateResourceRequest(ctx, t, r.Replicas, now)
+	if err != nil {
+		return t, currentHPA, fmt.Errorf("update resource request: %w", err)
+	}
+
+	return t, currentHPA, nil
+}
+
+// initialHPA returns the HPA which is the same as the one created by hpa.Service.CreateHPA.
+func (s *Simulator) initialHPA(t *v1beta3.Tortoise, replicas int32) *v2.HorizontalPodAutoscaler {
+	h := &v2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v1beta3.TortoiseDefaultHPAName(t.Name),
+			Namespace: t.Namespace,
+		},
+		Spec: v2.HorizontalPodAutoscalerSpec{
+			MinReplicas: ptr.To(replicas),
+			MaxReplicas: s.maximumMaxReplicas,
+		},
+	}
+	for _, p := range t.Spec.AutoscalingPolicy {
+		for _, rn := range sortedResourceNames(p.Policy) {
+			if p.Policy[rn] != v1beta3.AutoscalingTypeHorizontal {
+				continue
+			}
+			h.Spec.Metrics = append(h.Spec.Metrics, v2.MetricSpec{
+				Type: v2.ContainerResourceMetricSourceType,
+				ContainerResource: &v2.ContainerResourceMetricSource{
+					Name:      rn,
+					Container: p.ContainerName,
+					Target: v2.MetricTarget{
+						Type: v2.UtilizationMetricType,
+						// the same conservative value as tortoise starts from.
+						AverageUtilization: ptr.To[int32](70),
+					},
+				},
+			})
+		}
+	}
+	return h
+}
+
+func newStep(t *v1beta3.Tortoise, currentHPA *v2.HorizontalPodAutoscaler, r Record) Step {
+	step := Step{
+		Time:     r.Time,
+		Replicas: r.Replicas,
+		Phase:    t.Status.TortoisePhase,
+	}
+
+	for _, p := range t.Status.AutoscalingPolicy {
+		c := ContainerStep{ContainerName: p.ContainerName}
+		for _, req := range t.Status.Conditions.ContainerResourceRequests {
+			if req.ContainerName == p.ContainerName {
+				c.Requests = req.Resource.DeepCopy()
+			}
+		}
+		for _, rec := range t.Status.Recommendations.Vertical.ContainerResourceRecommendation {
+			if rec.ContainerName == p.ContainerName {
+				c.Recommendation = rec.RecommendedResource.DeepCopy()
+			}
+		}
+		for _, rn := range sortedResourceNames(p.Policy) {
+			if p.Policy[rn] != v1beta3.AutoscalingTypeHorizontal {
+				continue
+			}
+			v, err := hpa.GetHPATargetValue(context.Background(), currentHPA, p.ContainerName, rn)
+			if err != nil {
+				continue
+			}
+			if c.TargetUtilization == nil {
+				c.TargetUtilization = map[corev1.ResourceName]int32{}
+			}
+			c.TargetUtilization[rn] = v
+		}
+		step.Containers = append(step.Containers, c)
+	}
+
+	if hpa.HasHorizontal(t) {
+		step.HPA = &HPAStep{MaxReplicas: currentHPA.Spec.MaxReplicas}
+		if currentHPA.Spec.MinReplicas != nil {
+			step.HPA.MinReplicas = *currentHPA.Spec.MinReplicas
+		}
+	}
+
+	return step
+}
+
+func sortedResourceNames(m map[corev1.ResourceName]v1beta3.AutoscalingType) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// recommendations converts the record to the recommendations from the recommendation source.
+func (r Record) recommendations() []vpav1.RecommendedContainerResources {
+	recommendations := make([]vpav1.RecommendedContainerResources, 0, len(r.Containers))
+	for _, c := range r.Containers {
+		recommendations = append(recommendations, vpav1.RecommendedContainerResources{
+			ContainerName: c.ContainerName,
+			Target:        c.Target,
+			UpperBound:    c.UpperBound,
+		})
+	}
+	return recommendations
+}
+
+func (r Record) requests() []v1beta3.ContainerResourceRequests {
+	requests := make([]v1beta3.ContainerResourceRequests, 0, len(r.Containers))
+	for _, c := range r.Containers {
+		if len(c.Requests) == 0 {
+			continue
+		}
+		requests = append(requests, v1beta3.ContainerResourceRequests{
+			ContainerName: c.ContainerName,
+			Resource:      c.Requests.DeepCopy(),
+		})
+	}
+	return requests
+}
@@ -0,0 +1,243 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, err := config.ParseConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.TimeZone = "UTC"
+	cfg.GatheringDataPeriodType = "daily"
+	return cfg
+}
+
+func testTortoise() *v1beta3.Tortoise {
+	return &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec: v1beta3.TortoiseSpec{
+			UpdateMode: v1beta3.UpdateModeAuto,
+			TargetRefs: v1beta3.TargetRefs{
+				ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"},
+			},
+			AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+				{
+					ContainerName: "app",
+					Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+						corev1.ResourceCPU:    v1beta3.AutoscalingTypeHorizontal,
+						corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+					},
+				},
+			},
+		},
+	}
+}
+
+// hourlyRecords returns the records for every hour in the given days.
+func hourlyRecords(start time.Time, days int, replicas func(time.Time) int32) []Record {
+	var records []Record
+	for i := 0; i < days*24; i++ {
+		now := start.Add(time.Duration(i) * time.Hour)
+		records = append(records, Record{
+			Time:     now,
+			Replicas: replicas(now),
+			Containers: []ContainerRecord{
+				{
+					ContainerName: "app",
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("1"),
+						corev1.ResourceMemory: resource.MustParse("1Gi"),
+					},
+					Target: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("500m"),
+						corev1.ResourceMemory: resource.MustParse("500Mi"),
+					},
+					UpperBound: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("800m"),
+						corev1.ResourceMemory: resource.MustParse("800Mi"),
+					},
+				},
+			},
+		})
+	}
+	return records
+}
+
+func TestSimulator_Run(t *testing.T) {
+	s, err := New(testConfig(t), record.NewFakeRecorder(10000))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	// 10 replicas during the day, 4 replicas at night.
+	records := hourlyRecords(start, 3, func(now time.Time) int32 {
+		if now.Hour() >= 8 && now.Hour() < 20 {
+			return 10
+		}
+		return 4
+	})
+
+	steps, err := s.Run(context.Background(), testTortoise(), nil, records)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(steps) != len(records) {
+		t.Fatalf("Run() should return a step for each record, got %d steps", len(steps))
+	}
+
+	if steps[0].Phase != v1beta3.TortoisePhaseInitializing {
+		t.Errorf("the first step should be Initializing, got %s", steps[0].Phase)
+	}
+	// Vertical resources start working as soon as the recommendation is ready, but horizontal ones are still gathering data.
+	if steps[1].Phase != v1beta3.TortoisePhasePartlyWorking {
+		t.Errorf("the second step should be PartlyWorking, got %s", steps[1].Phase)
+	}
+	last := steps[len(steps)-1]
+	if last.Phase != v1beta3.TortoisePhaseWorking {
+		t.Fatalf("tortoise should be Working after gathering data for a day, got %s", last.Phase)
+	}
+
+	// At 23:00 (night), minReplicas is calculated from 4 replicas: ceil(4 * MinReplicasRecommendationMultiplier(0.5)) = 2,
+	// but it's raised to MinimumMinReplicas (3).
+	// maxReplicas is ceil(4 * MaxReplicasRecommendationMultiplier(2)) = 8.
+	if d := cmp.Diff(&HPAStep{MinReplicas: 3, MaxReplicas: 8}, last.HPA); d != "" {
+		t.Errorf("HPA at the last step diff = %s", d)
+	}
+	// At 12:00 (day) on the last day: minReplicas = ceil(10 * 0.5) = 5, maxReplicas = 10 * 2 = 20.
+	noon := steps[len(steps)-12]
+	if !noon.Time.Equal(time.Date(2023, 1, 3, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time %v", noon.Time)
+	}
+	if d := cmp.Diff(&HPAStep{MinReplicas: 5, MaxReplicas: 20}, noon.HPA); d != "" {
+		t.Errorf("HPA at noon diff = %s", d)
+	}
+
+	// memory is scaled vertically based on the recommendation from VPA.
+	gotMemory := last.Containers[0].Requests[corev1.ResourceMemory]
+	if gotMemory.Cmp(resource.MustParse("1Gi")) >= 0 {
+		t.Errorf("memory request should be reduced based on the recommendation, got %s", gotMemory.String())
+	}
+	if _, ok := last.Containers[0].TargetUtilization[corev1.ResourceCPU]; !ok {
+		t.Errorf("cpu target utilization should be reported, got %v", last.Containers[0].TargetUtilization)
+	}
+}
+
+func TestSimulator_Run_NoRecords(t *testing.T) {
+	s, err := New(testConfig(t), record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := s.Run(context.Background(), testTortoise(), nil, nil); err == nil {
+		t.Errorf("Run() should return an error when there is no record")
+	}
+}
+
+func TestReadRecords_CSV(t *testing.T) {
+	input := `time,replicas,container,cpu_request,memory_request,cpu_target,memory_target,cpu_upper_bound,memory_upper_bound
+2023-01-01T00:00:00Z,3,app,1,1Gi,500m,500Mi,800m,800Mi
+2023-01-01T00:00:00Z,3,istio-proxy,200m,,100m,,,
+2023-01-01T01:00:00Z,4,app,1,1Gi,600m,500Mi,900m,800Mi
+`
+	got, err := ReadRecords(strings.NewReader(input), InputFormatCSV)
+	if err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	want := []Record{
+		{
+			Time:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Replicas: 3,
+			Containers: []ContainerRecord{
+				{
+					ContainerName: "app",
+					Requests:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+					Target:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("500Mi")},
+					UpperBound:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("800m"), corev1.ResourceMemory: resource.MustParse("800Mi")},
+				},
+				{
+					ContainerName: "istio-proxy",
+					Requests:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("200m")},
+					Target:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+			},
+		},
+		{
+			Time:     time.Date(2023, 1, 1, 1, 0, 0, 0, time.UTC),
+			Replicas: 4,
+			Containers: []ContainerRecord{
+				{
+					ContainerName: "app",
+					Requests:      corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+					Target:        corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("600m"), corev1.ResourceMemory: resource.MustParse("500Mi")},
+					UpperBound:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("900m"), corev1.ResourceMemory: resource.MustParse("800Mi")},
+				},
+			},
+		},
+	}
+	if d := cmp.Diff(want, got, cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 })); d != "" {
+		t.Errorf("ReadRecords() diff = %s", d)
+	}
+
+	for name, input := range map[string]string{
+		"invalid header":   "time,replicas\n",
+		"invalid quantity": strings.Join(csvHeader, ",") + "\n2023-01-01T00:00:00Z,3,app,1x,,,,,\n",
+		"different replicas at the same time": strings.Join(csvHeader, ",") + "\n" +
+			"2023-01-01T00:00:00Z,3,app,1,,,,,\n2023-01-01T00:00:00Z,4,istio-proxy,1,,,,,\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ReadRecords(strings.NewReader(input), InputFormatCSV); err == nil {
+				t.Errorf("ReadRecords() should return an error")
+			}
+		})
+	}
+}
+
+func TestWriteTimeline(t *testing.T) {
+	steps := []Step{
+		{
+			Time:     time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			Replicas: 4,
+			Phase:    v1beta3.TortoisePhaseWorking,
+			HPA:      &HPAStep{MinReplicas: 3, MaxReplicas: 8},
+			Containers: []ContainerStep{
+				{
+					ContainerName:     "app",
+					Requests:          corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+					Recommendation:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("800Mi")},
+					TargetUtilization: map[corev1.ResourceName]int32{corev1.ResourceCPU: 75},
+				},
+			},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := WriteTimeline(buf, steps, OutputFormatCSV); err != nil {
+		t.Fatalf("WriteTimeline() error = %v", err)
+	}
+	want := `time,replicas,phase,hpa_min,hpa_max,container,cpu_request,memory_request,cpu_recommendation,memory_recommendation,target_utilization
+2023-01-01T00:00:00Z,4,Working,3,8,app,1,1Gi,1,800Mi,cpu:75%
+`
+	if d := cmp.Diff(want, buf.String()); d != "" {
+		t.Errorf("WriteTimeline() diff = %s", d)
+	}
+
+	if err := WriteTimeline(&bytes.Buffer{}, steps, "xml"); err == nil {
+		t.Errorf("WriteTimeline() should return an error for the unknown format")
+	}
+}
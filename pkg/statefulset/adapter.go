@@ -0,0 +1,68 @@
+package statefulset
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/workload"
+)
+
+var _ workload.Adapter = &Service{}
+
+// Get implements workload.Adapter.
+func (c *Service) Get(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (client.Object, error) {
+	sts, err := c.GetStatefulSetOnTortoise(ctx, tortoise)
+	if err != nil {
+		return nil, err
+	}
+	return sts, nil
+}
+
+// PodTemplate implements workload.Adapter.
+func (c *Service) PodTemplate(w client.Object) (*corev1.PodTemplateSpec, error) {
+	sts, err := workload.As[*v1.StatefulSet](w)
+	if err != nil {
+		return nil, err
+	}
+	return &sts.Spec.Template, nil
+}
+
+// ResourceRequests implements workload.Adapter.
+func (c *Service) ResourceRequests(w client.Object) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
+	sts, err := workload.As[*v1.StatefulSet](w)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetResourceRequests(sts)
+}
+
+// Restart implements workload.Adapter.
+func (c *Service) Restart(ctx context.Context, w client.Object, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	sts, err := workload.As[*v1.StatefulSet](w)
+	if err != nil {
+		return err
+	}
+	return c.RolloutRestart(ctx, sts, tortoise, now)
+}
+
+// RolloutCompleted returns true if all the Pods of the statefulset are updated and ready,
+// which is the same condition as `kubectl rollout status`.
+func (c *Service) RolloutCompleted(w client.Object) (bool, error) {
+	sts, err := workload.As[*v1.StatefulSet](w)
+	if err != nil {
+		return false, err
+	}
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	return sts.Status.ObservedGeneration >= sts.Generation &&
+		sts.Status.UpdatedReplicas >= replicas &&
+		sts.Status.ReadyReplicas >= replicas &&
+		sts.Status.CurrentRevision == sts.Status.UpdateRevision, nil
+}
@@ -0,0 +1,79 @@
+package statefulset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+type Service struct {
+	c        client.Client
+	recorder record.EventRecorder
+
+	// sidecarInjectors is the map from the name of the sidecar container injected by a mutating webhook (e.g., istio-proxy)
+	// to the annotations which configure its resources.
+	sidecarInjectors map[string]config.SidecarInjector
+}
+
+func New(c client.Client, sidecarInjectors map[string]config.SidecarInjector, recorder record.EventRecorder) *Service {
+	return &Service{c: c, sidecarInjectors: sidecarInjectors, recorder: recorder}
+}
+
+func (c *Service) GetStatefulSetOnTortoise(ctx context.Context, tortoise *autoscalingv1beta3.Tortoise) (*v1.StatefulSet, error) {
+	s := &v1.StatefulSet{}
+	if err := c.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: tortoise.Spec.TargetRefs.ScaleTargetRef.Name}, s); err != nil {
+		return nil, fmt.Errorf("failed to get statefulset on tortoise: %w", err)
+	}
+	return s, nil
+}
+
+// RolloutRestart restarts the Pods in the StatefulSet by updating the restartedAt annotation on the Pod template.
+// The StatefulSet controller replaces the Pods according to the update strategy of the StatefulSet:
+// - RollingUpdate: the Pods are replaced one by one in the reverse ordinal order, honoring the partition and maxUnavailable.
+// - OnDelete: the Pods are NOT replaced automatically, and the new resource requests are applied only when each Pod is deleted.
+func (c *Service) RolloutRestart(ctx context.Context, sts *v1.StatefulSet, tortoise *autoscalingv1beta3.Tortoise, now time.Time) error {
+	if sts.Spec.Template.ObjectMeta.Annotations == nil {
+		sts.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	sts.Spec.Template.ObjectMeta.Annotations[annotation.UpdatedAtAnnotation] = now.Format(time.RFC3339)
+
+	if err := c.c.Update(ctx, sts); err != nil {
+		return fmt.Errorf("failed to update statefulset: %w", err)
+	}
+
+	msg := restartMessage(sts)
+	c.recorder.Event(tortoise, corev1.EventTypeNormal, event.RestartStatefulSet, msg)
+	log.FromContext(ctx).Info(msg, "tortoise", tortoise)
+
+	return nil
+}
+
+func restartMessage(sts *v1.StatefulSet) string {
+	if sts.Spec.UpdateStrategy.Type == v1.OnDeleteStatefulSetStrategyType {
+		return "StatefulSet's Pod template is updated to apply the recommendation from Tortoise, but the Pods will be updated only when they're deleted because of the OnDelete update strategy"
+	}
+
+	if ru := sts.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil && *ru.Partition > 0 {
+		return fmt.Sprintf("StatefulSet is restarted to apply the recommendation from Tortoise, but only the Pods with an ordinal greater than or equal to the partition (%d) will be updated", *ru.Partition)
+	}
+
+	return "StatefulSet is restarted to apply the recommendation from Tortoise"
+}
+
+// GetResourceRequests returns the resource requests of the containers in the statefulset.
+func (c *Service) GetResourceRequests(sts *v1.StatefulSet) ([]autoscalingv1beta3.ContainerResourceRequests, error) {
+	return utils.GetResourceRequestsFromPodTemplate(&sts.Spec.Template, c.sidecarInjectors)
+}
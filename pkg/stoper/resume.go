@@ -0,0 +1,139 @@
+package stoper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kyokomi/emoji/v2"
+	v1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+)
+
+type ResumeOption string
+
+var (
+	// RevertDeploymentPatch reverts the Deployment patched by "stop" with NoLoweringResource to the original resources.
+	RevertDeploymentPatch ResumeOption = "RevertDeploymentPatch"
+)
+
+var errTortoiseNotStopped = errors.New("tortoise is not stopped by tortoisectl")
+
+// Resume restores the tortoise(s) stopped by Stop, based on the state recorded in the StoppedStateAnnotation.
+func (s *Stopr) Resume(ctx context.Context, tortoiseNames []string, namespace string, all bool, writer io.Writer, opts ...ResumeOption) error {
+	// It assumes the validation is already done in the CLI layer.
+
+	targets := []types.NamespacedName{}
+	if all {
+		tortoises := &v1beta3.TortoiseList{}
+		opt := &client.ListOptions{}
+		if namespace != "" {
+			// resume all tortoises in the namespace
+			opt.Namespace = namespace
+		}
+
+		if err := s.c.List(ctx, tortoises, opt); err != nil {
+			return fmt.Errorf("failed to list tortoises: %w", err)
+		}
+
+		for _, t := range tortoises.Items {
+			if _, ok := t.Annotations[annotation.StoppedStateAnnotation]; !ok {
+				// skip tortoises which aren't stopped by tortoisectl.
+				continue
+			}
+			targets = append(targets, types.NamespacedName{Name: t.Name, Namespace: t.Namespace})
+		}
+	} else {
+		for _, name := range tortoiseNames {
+			targets = append(targets, types.NamespacedName{Name: name, Namespace: namespace})
+		}
+	}
+
+	var finalerr error
+	for _, target := range targets {
+		write(writer, fmt.Sprintf("\n%s resuming your tortoise %s ... ", emoji.Sprint(":turtle:"), &target))
+
+		// 1. Restore the update mode of Tortoise.
+		tortoise, state, err := s.resumeOne(ctx, target)
+		if err != nil {
+			if errors.Is(err, errTortoiseNotStopped) {
+				write(writer, fmt.Sprintf("this tortoise isn't stopped by tortoisectl, nothing to do %s\n", emoji.Sprint(":thinking:")))
+				continue
+			}
+			finalerr = errors.Join(finalerr, err)
+			write(writer, fmt.Sprintf("failed to resume your tortoise %s.\nError: %v\n", emoji.Sprint(":face_with_spiral_eyes:"), err))
+			continue
+		}
+		write(writer, fmt.Sprintf("Done, the update mode is back to %s %s\n", state.UpdateMode, emoji.Sprint(":running:")))
+
+		// 2. [when RevertDeploymentPatch is true] Revert the deployment patched by "stop".
+		if !containsResumeOption(opts, RevertDeploymentPatch) || state.OriginalDeployment == nil {
+			continue
+		}
+
+		write(writer, fmt.Sprintf("%s reverting the patch on your deployment ... ", emoji.Sprint(":hammer_and_wrench:")))
+		if err := s.revertDeployment(ctx, tortoise, state.OriginalDeployment); err != nil {
+			finalerr = errors.Join(finalerr, err)
+			write(writer, fmt.Sprintf("%s failed to revert your deployment %s.\nError: %v\n", emoji.Sprint(":face_with_spiral_eyes:"), &target, err))
+			continue
+		}
+		write(writer, fmt.Sprintf("Done, your Pods should get the resources from tortoise soon %s\n", emoji.Sprint(":muscle:")))
+	}
+
+	return finalerr
+}
+
+func containsResumeOption(opts []ResumeOption, opt ResumeOption) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// resumeOne restores the update mode of the tortoise and removes the stopped state.
+func (s *Stopr) resumeOne(ctx context.Context, target types.NamespacedName) (*v1beta3.Tortoise, *stoppedState, error) {
+	t := &v1beta3.Tortoise{}
+	if err := s.c.Get(ctx, target, t); err != nil {
+		return nil, nil, fmt.Errorf("failed to get tortoise: %w", err)
+	}
+
+	state, ok, err := getStoppedState(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return t, nil, errTortoiseNotStopped
+	}
+
+	t.Spec.UpdateMode = state.UpdateMode
+	delete(t.Annotations, annotation.StoppedStateAnnotation)
+
+	if err := s.c.Update(ctx, t); err != nil {
+		return nil, nil, fmt.Errorf("failed to update tortoise: %w", err)
+	}
+
+	return t, state, nil
+}
+
+// revertDeployment reverts the resources of the deployment to the original ones.
+// Changing the Pod template triggers the rollout, and the new Pods get the resources from tortoise via the webhook.
+func (s *Stopr) revertDeployment(ctx context.Context, tortoise *v1beta3.Tortoise, original *originalDeployment) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		dp := &v1.Deployment{}
+		if err := s.c.Get(ctx, types.NamespacedName{Namespace: tortoise.Namespace, Name: original.Name}, dp); err != nil {
+			return fmt.Errorf("failed to get deployment: %w", err)
+		}
+
+		original.revert(dp, s.podService.SidecarResourceAnnotations())
+
+		return s.c.Update(ctx, dp)
+	})
+}
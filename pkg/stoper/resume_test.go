@@ -0,0 +1,204 @@
+package stoper
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/config"
+	"github.com/mercari/tortoise/pkg/deployment"
+	"github.com/mercari/tortoise/pkg/pod"
+)
+
+func TestStopr_Resume(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := v1beta3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	originalResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	newObjects := func() (*v1beta3.Tortoise, *v1.Deployment) {
+		tortoise := &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+			Spec: v1beta3.TortoiseSpec{
+				UpdateMode: v1beta3.UpdateModeAuto,
+				TargetRefs: v1beta3.TargetRefs{
+					ScaleTargetRef: v1beta3.CrossVersionObjectReference{Kind: "Deployment", Name: "app"},
+				},
+			},
+			Status: v1beta3.TortoiseStatus{
+				TortoisePhase: v1beta3.TortoisePhaseWorking,
+				AutoscalingPolicy: []v1beta3.ContainerAutoscalingPolicy{
+					{
+						ContainerName: "app",
+						Policy: map[corev1.ResourceName]v1beta3.AutoscalingType{
+							corev1.ResourceCPU:    v1beta3.AutoscalingTypeVertical,
+							corev1.ResourceMemory: v1beta3.AutoscalingTypeVertical,
+						},
+					},
+				},
+				Conditions: v1beta3.Conditions{
+					ContainerResourceRequests: []v1beta3.ContainerResourceRequests{
+						{
+							ContainerName: "app",
+							Resource: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("3"),
+								corev1.ResourceMemory: resource.MustParse("3Gi"),
+							},
+						},
+					},
+				},
+				Recommendations: v1beta3.Recommendations{
+					Vertical: v1beta3.VerticalRecommendations{
+						ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+							{
+								ContainerName: "app",
+								RecommendedResource: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("3"),
+									corev1.ResourceMemory: resource.MustParse("3Gi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		dp := &v1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Spec: v1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "app", Image: "app", Resources: *originalResources.DeepCopy()},
+						},
+					},
+				},
+			},
+		}
+		return tortoise, dp
+	}
+
+	tests := []struct {
+		name            string
+		stopOpts        []StoprOption
+		resumeOpts      []ResumeOption
+		wantDPResources corev1.ResourceRequirements
+	}{
+		{
+			name:            "resume the update mode",
+			wantDPResources: originalResources,
+		},
+		{
+			name:     "resume the update mode without reverting the deployment patch",
+			stopOpts: []StoprOption{NoLoweringResource},
+			wantDPResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("3"),
+					corev1.ResourceMemory: resource.MustParse("3Gi"),
+				},
+			},
+		},
+		{
+			name:            "resume the update mode and revert the deployment patch",
+			stopOpts:        []StoprOption{NoLoweringResource},
+			resumeOpts:      []ResumeOption{RevertDeploymentPatch},
+			wantDPResources: originalResources,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tortoise, dp := newObjects()
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tortoise, dp).Build()
+			recorder := record.NewFakeRecorder(10)
+			podService, err := pod.New(map[string]int64{}, "", nil, map[string]config.SidecarInjector{config.IstioProxyContainerName: config.IstioSidecarInjector("", "")}, nil)
+			if err != nil {
+				t.Fatalf("failed to create pod service: %v", err)
+			}
+			s := New(c, deployment.New(c, nil, recorder), podService)
+			key := types.NamespacedName{Namespace: "default", Name: "tortoise"}
+
+			if err := s.Stop(context.Background(), []string{"tortoise"}, "default", false, io.Discard, tt.stopOpts...); err != nil {
+				t.Fatalf("Stop() error = %v", err)
+			}
+			stopped := &v1beta3.Tortoise{}
+			if err := c.Get(context.Background(), key, stopped); err != nil {
+				t.Fatal(err)
+			}
+			if stopped.Spec.UpdateMode != v1beta3.UpdateModeOff {
+				t.Fatalf("Stop() should change the update mode to Off, got %s", stopped.Spec.UpdateMode)
+			}
+
+			if err := s.Resume(context.Background(), []string{"tortoise"}, "default", false, io.Discard, tt.resumeOpts...); err != nil {
+				t.Fatalf("Resume() error = %v", err)
+			}
+
+			got := &v1beta3.Tortoise{}
+			if err := c.Get(context.Background(), key, got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Spec.UpdateMode != v1beta3.UpdateModeAuto {
+				t.Errorf("Resume() should restore the update mode to Auto, got %s", got.Spec.UpdateMode)
+			}
+			if _, ok := got.Annotations[annotation.StoppedStateAnnotation]; ok {
+				t.Errorf("Resume() should remove the %s annotation", annotation.StoppedStateAnnotation)
+			}
+
+			gotDP := &v1.Deployment{}
+			if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "app"}, gotDP); err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tt.wantDPResources, gotDP.Spec.Template.Spec.Containers[0].Resources, cmp.Comparer(func(a, b resource.Quantity) bool { return a.Cmp(b) == 0 })); d != "" {
+				t.Errorf("Resume() deployment resources diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestStopr_Resume_NotStopped(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1beta3.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	tortoise := &v1beta3.Tortoise{
+		ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+		Spec:       v1beta3.TortoiseSpec{UpdateMode: v1beta3.UpdateModeOff},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tortoise).Build()
+	s := New(c, nil, nil)
+
+	// The tortoise which is turned off manually shouldn't be changed.
+	if err := s.Resume(context.Background(), nil, "", true, io.Discard); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if err := s.Resume(context.Background(), []string{"tortoise"}, "default", false, io.Discard); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	got := &v1beta3.Tortoise{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "tortoise"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Spec.UpdateMode != v1beta3.UpdateModeOff {
+		t.Errorf("Resume() shouldn't change the update mode of the tortoise which isn't stopped by tortoisectl, got %s", got.Spec.UpdateMode)
+	}
+}
@@ -0,0 +1,102 @@
+package stoper
+
+import (
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/annotation"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+// stoppedState is the state before stopping the tortoise.
+// It's recorded in the StoppedStateAnnotation on the tortoise so that Resume can restore it.
+type stoppedState struct {
+	// UpdateMode is the update mode before stopping.
+	UpdateMode v1beta3.UpdateMode `json:"updateMode"`
+	// OriginalDeployment is the Deployment before being patched by NoLoweringResource.
+	// It's nil when the Deployment isn't patched.
+	OriginalDeployment *originalDeployment `json:"originalDeployment,omitempty"`
+}
+
+type originalDeployment struct {
+	Name       string              `json:"name"`
+	Containers []originalContainer `json:"containers"`
+	// SidecarAnnotations are the resource annotations of the injected sidecars (e.g., istio-proxy) on the Pod template.
+	// NoLoweringResource patches them as well when the sidecars are injected.
+	SidecarAnnotations map[string]string `json:"sidecarAnnotations,omitempty"`
+}
+
+type originalContainer struct {
+	Name      string                      `json:"name"`
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
+
+// newOriginalDeployment records the resources of the Deployment,
+// including sidecarResourceAnnotations, the resource annotations of the injected sidecars.
+func newOriginalDeployment(dp *v1.Deployment, sidecarResourceAnnotations []string) *originalDeployment {
+	o := &originalDeployment{Name: dp.Name}
+	for _, c := range utils.ScalableContainers(&dp.Spec.Template.Spec) {
+		o.Containers = append(o.Containers, originalContainer{Name: c.Name, Resources: *c.Resources.DeepCopy()})
+	}
+	for _, k := range sidecarResourceAnnotations {
+		if v, ok := dp.Spec.Template.Annotations[k]; ok {
+			if o.SidecarAnnotations == nil {
+				o.SidecarAnnotations = map[string]string{}
+			}
+			o.SidecarAnnotations[k] = v
+		}
+	}
+	return o
+}
+
+// revert reverts the Deployment's resources to the original ones.
+func (o *originalDeployment) revert(dp *v1.Deployment, sidecarResourceAnnotations []string) {
+	for _, c := range utils.ScalableContainers(&dp.Spec.Template.Spec) {
+		for _, oc := range o.Containers {
+			if oc.Name == c.Name {
+				c.Resources = *oc.Resources.DeepCopy()
+			}
+		}
+	}
+	for _, k := range sidecarResourceAnnotations {
+		v, ok := o.SidecarAnnotations[k]
+		if !ok {
+			delete(dp.Spec.Template.Annotations, k)
+			continue
+		}
+		if dp.Spec.Template.Annotations == nil {
+			dp.Spec.Template.Annotations = map[string]string{}
+		}
+		dp.Spec.Template.Annotations[k] = v
+	}
+}
+
+// getStoppedState returns the state recorded on the tortoise.
+// The second return value is false when the tortoise doesn't have the state, i.e., it's not stopped by tortoisectl.
+func getStoppedState(t *v1beta3.Tortoise) (*stoppedState, bool, error) {
+	v, ok := t.Annotations[annotation.StoppedStateAnnotation]
+	if !ok {
+		return nil, false, nil
+	}
+	state := &stoppedState{}
+	if err := json.Unmarshal([]byte(v), state); err != nil {
+		return nil, true, fmt.Errorf("failed to parse the %s annotation: %w", annotation.StoppedStateAnnotation, err)
+	}
+	return state, true, nil
+}
+
+func setStoppedState(t *v1beta3.Tortoise, state *stoppedState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the stopped state: %w", err)
+	}
+	if t.Annotations == nil {
+		t.Annotations = map[string]string{}
+	}
+	t.Annotations[annotation.StoppedStateAnnotation] = string(b)
+	return nil
+}
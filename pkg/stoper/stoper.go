@@ -11,6 +11,7 @@ import (
 	"github.com/kyokomi/emoji/v2"
 	v1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/mercari/tortoise/api/v1beta3"
@@ -93,6 +94,7 @@ func (s *Stopr) Stop(ctx context.Context, tortoiseNames []string, namespace stri
 		// 3. [when NoLoweringResource is true] Patch the deployment to keep the resource requests high.
 		if containsOption(opts, NoLoweringResource) {
 			write(writer, fmt.Sprintf("%s patching your deployment to keep the resource requests high ... ", emoji.Sprint(":hammer_and_wrench:")))
+			original := newOriginalDeployment(dp, s.podService.SidecarResourceAnnotations())
 			updated, err := s.patchDeploymentToKeepResources(ctx, dp, tortoise)
 			if err != nil {
 				finalerr = errors.Join(finalerr, err)
@@ -103,6 +105,11 @@ func (s *Stopr) Stop(ctx context.Context, tortoiseNames []string, namespace stri
 			write(writer, fmt.Sprintf("Done %s\n", emoji.Sprint(":hammer_and_wrench:")))
 
 			if updated {
+				// Record the original resources so that "resume" can revert the patch.
+				if err := s.recordOriginalDeployment(ctx, target, original); err != nil {
+					finalerr = errors.Join(finalerr, err)
+					write(writer, fmt.Sprintf("%s failed to record the original resources of your deployment on your tortoise %s.\nError: %v\n", emoji.Sprint(":face_with_spiral_eyes:"), &target, err))
+				}
 				// If the deployment is updated, we don't need to restart the deployment.
 				continue
 			}
@@ -146,7 +153,7 @@ func (s *Stopr) patchDeploymentToKeepResources(ctx context.Context, dp *v1.Deplo
 
 	tortoise.Spec.UpdateMode = v1beta3.UpdateModeOff
 	// If not updated, early return
-	if reflect.DeepEqual(originalDP.Spec.Template.Spec.Containers, dp.Spec.Template.Spec.Containers) {
+	if reflect.DeepEqual(originalDP.Spec.Template.Spec.Containers, dp.Spec.Template.Spec.Containers) && reflect.DeepEqual(originalDP.Spec.Template.Spec.InitContainers, dp.Spec.Template.Spec.InitContainers) {
 		return false, nil
 	}
 
@@ -170,6 +177,9 @@ func (s *Stopr) stopOne(ctx context.Context, target types.NamespacedName) (*v1be
 		return t, errTortoiseAlreadyStopped
 	}
 
+	if err := setStoppedState(t, &stoppedState{UpdateMode: t.Spec.UpdateMode}); err != nil {
+		return nil, err
+	}
 	t.Spec.UpdateMode = v1beta3.UpdateModeOff
 
 	if err := s.c.Update(ctx, t); err != nil {
@@ -178,3 +188,27 @@ func (s *Stopr) stopOne(ctx context.Context, target types.NamespacedName) (*v1be
 
 	return t, nil
 }
+
+// recordOriginalDeployment adds the original Deployment to the stopped state on the tortoise.
+func (s *Stopr) recordOriginalDeployment(ctx context.Context, target types.NamespacedName, original *originalDeployment) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		t := &v1beta3.Tortoise{}
+		if err := s.c.Get(ctx, target, t); err != nil {
+			return fmt.Errorf("failed to get tortoise: %w", err)
+		}
+		state, ok, err := getStoppedState(t)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// shouldn't reach here because stopOne always records the state.
+			state = &stoppedState{UpdateMode: v1beta3.UpdateModeOff}
+		}
+		state.OriginalDeployment = original
+		if err := setStoppedState(t, state); err != nil {
+			return err
+		}
+
+		return s.c.Update(ctx, t)
+	})
+}
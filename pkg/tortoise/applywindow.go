@@ -0,0 +1,88 @@
+package tortoise
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+// applyWindowsFromConfig converts the cluster-wide default windows in the config to the windows in Tortoise.
+func applyWindowsFromConfig(windows []config.ApplyWindow) []v1beta3.ApplyWindow {
+	if len(windows) == 0 {
+		return nil
+	}
+	ws := make([]v1beta3.ApplyWindow, 0, len(windows))
+	for _, w := range windows {
+		days := make([]v1beta3.Weekday, 0, len(w.Weekdays))
+		for _, d := range w.Weekdays {
+			days = append(days, v1beta3.Weekday(d))
+		}
+		ws = append(ws, v1beta3.ApplyWindow{
+			Weekdays:  days,
+			StartHour: w.StartHour,
+			EndHour:   w.EndHour,
+			TimeZone:  w.TimeZone,
+		})
+	}
+	return ws
+}
+
+// applyWindows returns the windows which the tortoise uses: .spec.applyWindows, or the cluster-wide default windows.
+func (c *Service) applyWindows(tortoise *v1beta3.Tortoise) []v1beta3.ApplyWindow {
+	if len(tortoise.Spec.ApplyWindows) != 0 {
+		return tortoise.Spec.ApplyWindows
+	}
+	return c.defaultApplyWindows
+}
+
+// inApplyWindow returns true if now is within any of the windows.
+// If it's false, it also returns the time when the next window opens.
+func inApplyWindow(windows []v1beta3.ApplyWindow, defaultTimeZone *time.Location, now time.Time) (bool, time.Time, error) {
+	var next time.Time
+	for _, w := range windows {
+		loc := defaultTimeZone
+		if w.TimeZone != "" {
+			l, err := time.LoadLocation(w.TimeZone)
+			if err != nil {
+				return false, time.Time{}, fmt.Errorf("load the time zone of the apply window: %w", err)
+			}
+			loc = l
+		}
+
+		local := now.In(loc)
+		// Check the windows which start from yesterday (it may still be open across midnight) to 7 days later.
+		for day := -1; day <= 7; day++ {
+			date := time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, loc)
+			if !opensOn(w, date.Weekday()) {
+				continue
+			}
+			start := time.Date(date.Year(), date.Month(), date.Day(), int(w.StartHour), 0, 0, 0, loc)
+			end := time.Date(date.Year(), date.Month(), date.Day(), int(w.EndHour), 0, 0, 0, loc)
+			if w.EndHour <= w.StartHour {
+				end = end.AddDate(0, 0, 1)
+			}
+
+			if !now.Before(start) && now.Before(end) {
+				return true, time.Time{}, nil
+			}
+			if start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return false, next, nil
+}
+
+func opensOn(w v1beta3.ApplyWindow, weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if string(d) == weekday.String() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package tortoise
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+func Test_inApplyWindow(t *testing.T) {
+	// 2023-01-01 is Sunday.
+	sunday := func(hour int) time.Time { return time.Date(2023, 1, 1, hour, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name     string
+		windows  []v1beta3.ApplyWindow
+		now      time.Time
+		wantOpen bool
+		wantNext time.Time
+	}{
+		{
+			name:     "within the window",
+			windows:  []v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}},
+			now:      sunday(3),
+			wantOpen: true,
+		},
+		{
+			name:     "the window is closed at EndHour",
+			windows:  []v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}},
+			now:      sunday(6),
+			wantNext: sunday(2).AddDate(0, 0, 1),
+		},
+		{
+			name:     "before the window opens",
+			windows:  []v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}},
+			now:      sunday(1),
+			wantNext: sunday(2),
+		},
+		{
+			name:     "within the window which opened yesterday",
+			windows:  []v1beta3.ApplyWindow{{Weekdays: []v1beta3.Weekday{"Saturday"}, StartHour: 22, EndHour: 6}},
+			now:      sunday(1),
+			wantOpen: true,
+		},
+		{
+			name:     "the window opens on the next Saturday",
+			windows:  []v1beta3.ApplyWindow{{Weekdays: []v1beta3.Weekday{"Saturday"}, StartHour: 2, EndHour: 6}},
+			now:      sunday(3),
+			wantNext: sunday(2).AddDate(0, 0, 6),
+		},
+		{
+			name:     "the window in another time zone",
+			windows:  []v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6, TimeZone: "Asia/Tokyo"}},
+			now:      sunday(18), // 3:00 on Monday in Asia/Tokyo
+			wantOpen: true,
+		},
+		{
+			name: "the earliest window is the next",
+			windows: []v1beta3.ApplyWindow{
+				{Weekdays: []v1beta3.Weekday{"Wednesday"}, StartHour: 2, EndHour: 6},
+				{Weekdays: []v1beta3.Weekday{"Monday", "Friday"}, StartHour: 20, EndHour: 24},
+			},
+			now:      sunday(3),
+			wantNext: sunday(20).AddDate(0, 0, 1),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotOpen, gotNext, err := inApplyWindow(tt.windows, time.UTC, tt.now)
+			if err != nil {
+				t.Fatalf("inApplyWindow() error = %v", err)
+			}
+			if gotOpen != tt.wantOpen {
+				t.Errorf("inApplyWindow() open = %v, want %v", gotOpen, tt.wantOpen)
+			}
+			if !gotNext.Equal(tt.wantNext) {
+				t.Errorf("inApplyWindow() next = %v, want %v", gotNext, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestService_UpdateResourceRequest_applyWindows(t *testing.T) {
+	now := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	requests := func(memory string) []v1beta3.ContainerResourceRequests {
+		return []v1beta3.ContainerResourceRequests{
+			{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(memory)}},
+		}
+	}
+	testTortoise := func(windows []v1beta3.ApplyWindow, recommendation string) *v1beta3.Tortoise {
+		return &v1beta3.Tortoise{
+			ObjectMeta: metav1.ObjectMeta{Name: "tortoise", Namespace: "default"},
+			Spec: v1beta3.TortoiseSpec{
+				UpdateMode:   v1beta3.UpdateModeAuto,
+				ApplyWindows: windows,
+			},
+			Status: v1beta3.TortoiseStatus{
+				Conditions: v1beta3.Conditions{
+					TortoiseConditions: []v1beta3.TortoiseCondition{
+						{
+							Type:               v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
+							Status:             corev1.ConditionTrue,
+							LastTransitionTime: metav1.NewTime(now.Add(-2 * time.Hour)),
+							LastUpdateTime:     metav1.NewTime(now.Add(-2 * time.Hour)),
+						},
+					},
+					ContainerResourceRequests: requests("2Gi"),
+				},
+				Recommendations: v1beta3.Recommendations{
+					Vertical: v1beta3.VerticalRecommendations{
+						ContainerResourceRecommendation: []v1beta3.RecommendedContainerResources{
+							{ContainerName: "app", RecommendedResource: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(recommendation)}},
+						},
+					},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		name                string
+		tortoise            *v1beta3.Tortoise
+		defaultApplyWindows []v1beta3.ApplyWindow
+		wantRequests        []v1beta3.ContainerResourceRequests
+		wantPending         []v1beta3.ContainerResourceRequests
+		wantNext            *metav1.Time
+	}{
+		{
+			name:         "the scale down is queued until the window opens",
+			tortoise:     testTortoise([]v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}}, "1Gi"),
+			wantRequests: requests("2Gi"),
+			wantPending:  requests("1Gi"),
+			wantNext:     &metav1.Time{Time: time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:                "the scale down is queued until the cluster-wide default window opens",
+			tortoise:            testTortoise(nil, "1Gi"),
+			defaultApplyWindows: []v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}},
+			wantRequests:        requests("2Gi"),
+			wantPending:         requests("1Gi"),
+			wantNext:            &metav1.Time{Time: time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:         "the scale down is applied within the window",
+			tortoise:     testTortoise([]v1beta3.ApplyWindow{{StartHour: 8, EndHour: 12}}, "1Gi"),
+			wantRequests: requests("1Gi"),
+		},
+		{
+			name:         "the scale up is applied immediately",
+			tortoise:     testTortoise([]v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}}, "3Gi"),
+			wantRequests: requests("3Gi"),
+		},
+		{
+			name: "the pending scale down is removed after the scale up is applied",
+			tortoise: func() *v1beta3.Tortoise {
+				t := testTortoise([]v1beta3.ApplyWindow{{StartHour: 2, EndHour: 6}}, "3Gi")
+				t.Status.Conditions.PendingContainerResourceRequests = requests("1Gi")
+				t.Status.Conditions.NextApplyWindowTime = &metav1.Time{Time: time.Date(2023, 1, 2, 2, 0, 0, 0, time.UTC)}
+				return t
+			}(),
+			wantRequests: requests("3Gi"),
+		},
+		{
+			name:         "the scale down is applied at any time without windows",
+			tortoise:     testTortoise(nil, "1Gi"),
+			wantRequests: requests("1Gi"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Service{
+				recorder:            record.NewFakeRecorder(10),
+				timeZone:            time.UTC,
+				defaultApplyWindows: tt.defaultApplyWindows,
+			}
+			got, err := c.UpdateResourceRequest(context.Background(), tt.tortoise, 10, now)
+			if err != nil {
+				t.Fatalf("Service.UpdateResourceRequest() error = %v", err)
+			}
+			if d := cmp.Diff(tt.wantRequests, got.Status.Conditions.ContainerResourceRequests); d != "" {
+				t.Errorf("ContainerResourceRequests mismatch (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantPending, got.Status.Conditions.PendingContainerResourceRequests); d != "" {
+				t.Errorf("PendingContainerResourceRequests mismatch (-want +got):\n%s", d)
+			}
+			if d := cmp.Diff(tt.wantNext, got.Status.Conditions.NextApplyWindowTime); d != "" {
+				t.Errorf("NextApplyWindowTime mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package tortoise
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/utils"
+)
+
+// replicasSlots is the layout of the time slots of the minReplicas/maxReplicas recommendation.
+type replicasSlots struct {
+	timeZone *time.Location
+	// minutes is the length of each time slot.
+	minutes int
+	period  v1beta3.GatheringDataPeriod
+}
+
+// replicasSlots returns the layout of the time slots which the tortoise uses:
+// .spec.replicasRecommendationSlots, or the cluster-wide configurations for the empty fields.
+func (s *Service) replicasSlots(tortoise *v1beta3.Tortoise) replicasSlots {
+	slots := replicasSlots{
+		timeZone: s.timeZone,
+		minutes:  s.rangeOfMinMaxReplicasRecommendationHour * 60,
+		period:   v1beta3.GatheringDataPeriod(s.gatheringDataDuration),
+	}
+	if s.rangeOfMinMaxReplicasRecommendationMinutes != 0 {
+		slots.minutes = s.rangeOfMinMaxReplicasRecommendationMinutes
+	}
+	spec := tortoise.Spec.ReplicasRecommendationSlots
+	if spec == nil {
+		return slots
+	}
+
+	if spec.TimeZone != "" {
+		// The time zone is validated by the webhook. If it's invalid anyway, we use the cluster-wide time zone.
+		if loc, err := time.LoadLocation(spec.TimeZone); err == nil {
+			slots.timeZone = loc
+		}
+	}
+	if spec.Minutes != nil {
+		slots.minutes = int(*spec.Minutes)
+	} else if spec.Hours != nil {
+		slots.minutes = int(*spec.Hours) * 60
+	}
+	if spec.GatheringDataPeriod != "" {
+		slots.period = spec.GatheringDataPeriod
+	}
+	return slots
+}
+
+// gatheringPeriod returns how long tortoise gathers the data before it starts to work.
+func (r replicasSlots) gatheringPeriod() time.Duration {
+	if r.period == v1beta3.GatheringDataPeriodDaily {
+		return 24 * time.Hour
+	}
+	return 7 * 24 * time.Hour
+}
+
+// newRecommendations returns the empty recommendations for all the time slots.
+// The time slots which are a multiple of an hour have FromMinute and ToMinute of 0, as they had before the minute resolution.
+func (r replicasSlots) newRecommendations() []v1beta3.ReplicasRecommendation {
+	recommendations := []v1beta3.ReplicasRecommendation{}
+	// from and to are the minutes of the day.
+	from := 0
+	to := r.minutes
+	weekDay := time.Sunday
+	for {
+		if r.period == v1beta3.GatheringDataPeriodDaily {
+			recommendations = append(recommendations, v1beta3.ReplicasRecommendation{
+				From:       from / 60,
+				FromMinute: from % 60,
+				To:         to / 60,
+				ToMinute:   to % 60,
+				TimeZone:   r.timeZone.String(),
+			})
+		} else if r.period == v1beta3.GatheringDataPeriodWeekly {
+			recommendations = append(recommendations, v1beta3.ReplicasRecommendation{
+				From:       from / 60,
+				FromMinute: from % 60,
+				To:         to / 60,
+				ToMinute:   to % 60,
+				TimeZone:   r.timeZone.String(),
+				WeekDay:    ptr.To(weekDay.String()),
+			})
+		}
+
+		if to >= 24*60 {
+			if weekDay == time.Saturday || r.period == v1beta3.GatheringDataPeriodDaily {
+				break
+			}
+
+			weekDay += 1
+			from = 0
+			to = r.minutes
+			continue
+		}
+		from += r.minutes
+		to += r.minutes
+	}
+	return recommendations
+}
+
+// reshapeReplicasRecommendations reshapes the minReplicas/maxReplicas recommendations into the time slots which the tortoise uses now,
+// if .spec.replicasRecommendationSlots is changed after the recommendations were initialized.
+//
+// The tortoises without .spec.replicasRecommendationSlots keep their time slots as they are,
+// even if the cluster-wide configurations are changed. (or .spec.replicasRecommendationSlots is removed.)
+func (s *Service) reshapeReplicasRecommendations(tortoise *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	if tortoise.Spec.ReplicasRecommendationSlots == nil {
+		return tortoise
+	}
+
+	slots := s.replicasSlots(tortoise)
+	want := slots.newRecommendations()
+	h := &tortoise.Status.Recommendations.Horizontal
+	if sameSlots(h.MinReplicas, want) && sameSlots(h.MaxReplicas, want) {
+		return tortoise
+	}
+
+	h.MinReplicas = reshape(h.MinReplicas, want, slots.timeZone, now)
+	h.MaxReplicas = reshape(h.MaxReplicas, want, slots.timeZone, now)
+	s.recorder.Event(tortoise, corev1.EventTypeNormal, event.ReplicasRecommendationReshaped, fmt.Sprintf("The minReplicas/maxReplicas recommendations are reshaped into %d time slots (time zone: %s, length: %s, period: %s)", len(want), slots.timeZone, time.Duration(slots.minutes)*time.Minute, slots.period))
+	return tortoise
+}
+
+// sameSlots returns true if the recommendations have the same time slots.
+func sameSlots(recommendations, want []v1beta3.ReplicasRecommendation) bool {
+	if len(recommendations) != len(want) {
+		return false
+	}
+	for i := range recommendations {
+		r, w := recommendations[i], want[i]
+		if r.From != w.From || r.FromMinute != w.FromMinute || r.To != w.To || r.ToMinute != w.ToMinute || r.TimeZone != w.TimeZone || ptr.Deref(r.WeekDay, "") != ptr.Deref(w.WeekDay, "") {
+			return false
+		}
+	}
+	return true
+}
+
+// reshape fills the new time slots with the old recommendations.
+// Each new time slot gets the maximum value of the old time slots overlapping with it in the current week,
+// so that the reshaped recommendation doesn't underestimate the replica number.
+func reshape(old, slots []v1beta3.ReplicasRecommendation, loc *time.Location, now time.Time) []v1beta3.ReplicasRecommendation {
+	local := now.In(loc)
+	// The Sunday of the current week.
+	sunday := time.Date(local.Year(), local.Month(), local.Day()-int(local.Weekday()), 0, 0, 0, 0, loc)
+
+	// step is small enough to hit every old time slot overlapping with a new time slot.
+	step := utils.ReplicasRecommendationStep(old, slots)
+	reshaped := make([]v1beta3.ReplicasRecommendation, 0, len(slots))
+	for _, slot := range slots {
+		days := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+		if slot.WeekDay != nil {
+			days = []time.Weekday{weekdayFromString(*slot.WeekDay)}
+		}
+
+		for _, d := range days {
+			date := sunday.AddDate(0, 0, int(d))
+			start := time.Date(date.Year(), date.Month(), date.Day(), slot.From, slot.FromMinute, 0, 0, loc)
+			end := time.Date(date.Year(), date.Month(), date.Day(), slot.To, slot.ToMinute, 0, 0, loc)
+			for t := start; t.Before(end); t = t.Add(step) {
+				r, ok := findReplicasRecommendation(old, t)
+				if !ok {
+					continue
+				}
+				slot.Value = max(slot.Value, r.Value)
+				if r.UpdatedAt.After(slot.UpdatedAt.Time) {
+					slot.UpdatedAt = r.UpdatedAt
+				}
+			}
+		}
+		reshaped = append(reshaped, slot)
+	}
+	return reshaped
+}
+
+// findReplicasRecommendation finds the recommendation whose time slot contains t.
+func findReplicasRecommendation(recommendations []v1beta3.ReplicasRecommendation, t time.Time) (v1beta3.ReplicasRecommendation, bool) {
+	for _, r := range recommendations {
+		if tz, err := time.LoadLocation(r.TimeZone); err == nil {
+			// if the timezone is invalid, just ignore it.
+			t = t.In(tz)
+		}
+		if utils.InReplicasRecommendationSlot(r, t, t.Weekday()) {
+			return r, true
+		}
+	}
+	return v1beta3.ReplicasRecommendation{}, false
+}
+
+func weekdayFromString(s string) time.Weekday {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String() == s {
+			return d
+		}
+	}
+	return time.Sunday
+}
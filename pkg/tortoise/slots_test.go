@@ -0,0 +1,190 @@
+package tortoise
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+func TestService_reshapeReplicasRecommendations(t *testing.T) {
+	// 2023-01-04 is Wednesday.
+	now := time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC)
+	updatedAt := metav1.NewTime(now.Add(-time.Hour))
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// old is the weekly recommendations with 12 hours slots in UTC.
+	// The value is 1 on Sunday 0-12, 2 on Sunday 12-24, 3 on Monday 0-12, and so on.
+	old := func() []v1beta3.ReplicasRecommendation {
+		recommendations := replicasSlots{timeZone: time.UTC, minutes: 12 * 60, period: v1beta3.GatheringDataPeriodWeekly}.newRecommendations()
+		for i := range recommendations {
+			recommendations[i].Value = int32(i + 1)
+			recommendations[i].UpdatedAt = updatedAt
+		}
+		return recommendations
+	}
+	slot := func(from, to int, weekDay *string, timeZone string, value int32) v1beta3.ReplicasRecommendation {
+		return v1beta3.ReplicasRecommendation{From: from, To: to, WeekDay: weekDay, TimeZone: timeZone, Value: value, UpdatedAt: updatedAt}
+	}
+
+	tests := []struct {
+		name  string
+		slots *v1beta3.ReplicasRecommendationSlots
+		// check is the subset of the slots to check.
+		check map[int]v1beta3.ReplicasRecommendation
+		want  int
+	}{
+		{
+			name:  "the slots without the spec are kept",
+			check: map[int]v1beta3.ReplicasRecommendation{0: slot(0, 12, ptr.To("Sunday"), "UTC", 1)},
+			want:  14,
+		},
+		{
+			name:  "the same slots are kept",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "UTC", Hours: ptr.To[int32](12), GatheringDataPeriod: v1beta3.GatheringDataPeriodWeekly},
+			check: map[int]v1beta3.ReplicasRecommendation{0: slot(0, 12, ptr.To("Sunday"), "UTC", 1)},
+			want:  14,
+		},
+		{
+			name:  "the shorter slots take over the value of the old slot",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "UTC", Hours: ptr.To[int32](6), GatheringDataPeriod: v1beta3.GatheringDataPeriodWeekly},
+			check: map[int]v1beta3.ReplicasRecommendation{
+				0: slot(0, 6, ptr.To("Sunday"), "UTC", 1),
+				1: slot(6, 12, ptr.To("Sunday"), "UTC", 1),
+				2: slot(12, 18, ptr.To("Sunday"), "UTC", 2),
+			},
+			want: 28,
+		},
+		{
+			name:  "the sub-hour slots take over the value of the old slot",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "UTC", Minutes: ptr.To[int32](15), GatheringDataPeriod: v1beta3.GatheringDataPeriodWeekly},
+			check: map[int]v1beta3.ReplicasRecommendation{
+				0:  {From: 0, FromMinute: 0, To: 0, ToMinute: 15, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 1, UpdatedAt: updatedAt},
+				47: {From: 11, FromMinute: 45, To: 12, ToMinute: 0, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 1, UpdatedAt: updatedAt},
+				48: {From: 12, FromMinute: 0, To: 12, ToMinute: 15, WeekDay: ptr.To("Sunday"), TimeZone: "UTC", Value: 2, UpdatedAt: updatedAt},
+			},
+			want: 7 * 96,
+		},
+		{
+			name:  "the minutes take precedence over the hours",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "UTC", Hours: ptr.To[int32](12), Minutes: ptr.To[int32](6 * 60), GatheringDataPeriod: v1beta3.GatheringDataPeriodWeekly},
+			check: map[int]v1beta3.ReplicasRecommendation{
+				1: slot(6, 12, ptr.To("Sunday"), "UTC", 1),
+			},
+			want: 28,
+		},
+		{
+			name:  "the longer slot takes the maximum of the old slots",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "UTC", Hours: ptr.To[int32](24), GatheringDataPeriod: v1beta3.GatheringDataPeriodWeekly},
+			check: map[int]v1beta3.ReplicasRecommendation{
+				0: slot(0, 24, ptr.To("Sunday"), "UTC", 2),
+				6: slot(0, 24, ptr.To("Saturday"), "UTC", 14),
+			},
+			want: 7,
+		},
+		{
+			name:  "the daily slot takes the maximum of all the days",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "UTC", Hours: ptr.To[int32](12), GatheringDataPeriod: v1beta3.GatheringDataPeriodDaily},
+			check: map[int]v1beta3.ReplicasRecommendation{
+				0: slot(0, 12, nil, "UTC", 13),
+				1: slot(12, 24, nil, "UTC", 14),
+			},
+			want: 2,
+		},
+		{
+			name:  "the slots in another time zone",
+			slots: &v1beta3.ReplicasRecommendationSlots{TimeZone: "Asia/Tokyo", Hours: ptr.To[int32](12), GatheringDataPeriod: v1beta3.GatheringDataPeriodWeekly},
+			check: map[int]v1beta3.ReplicasRecommendation{
+				// Monday 0-12 in Tokyo is Sunday 15-24 in UTC and Monday 0-3 in UTC.
+				2: slot(0, 12, ptr.To("Monday"), tokyo.String(), 3),
+				// Monday 12-24 in Tokyo is Monday 3-15 in UTC.
+				3: slot(12, 24, ptr.To("Monday"), tokyo.String(), 4),
+			},
+			want: 14,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{
+				recorder:                                record.NewFakeRecorder(10),
+				timeZone:                                time.UTC,
+				rangeOfMinMaxReplicasRecommendationHour: 12,
+				gatheringDataDuration:                   "weekly",
+			}
+			tortoise := &v1beta3.Tortoise{Spec: v1beta3.TortoiseSpec{ReplicasRecommendationSlots: tt.slots}}
+			tortoise.Status.Recommendations.Horizontal.MinReplicas = old()
+			tortoise.Status.Recommendations.Horizontal.MaxReplicas = old()
+
+			got := s.reshapeReplicasRecommendations(tortoise, now).Status.Recommendations.Horizontal
+			if len(got.MinReplicas) != tt.want || len(got.MaxReplicas) != tt.want {
+				t.Fatalf("the number of the slots = %d/%d, want %d", len(got.MinReplicas), len(got.MaxReplicas), tt.want)
+			}
+			for i, want := range tt.check {
+				if d := cmp.Diff(want, got.MinReplicas[i]); d != "" {
+					t.Errorf("MinReplicas[%d] diff = %s", i, d)
+				}
+			}
+		})
+	}
+}
+
+func TestService_initializeMinMaxReplicas_slots(t *testing.T) {
+	s := &Service{
+		timeZone:                                time.UTC,
+		rangeOfMinMaxReplicasRecommendationHour: 1,
+		gatheringDataDuration:                   "weekly",
+	}
+	tortoise := &v1beta3.Tortoise{
+		Spec: v1beta3.TortoiseSpec{
+			ReplicasRecommendationSlots: &v1beta3.ReplicasRecommendationSlots{
+				TimeZone:            "Asia/Tokyo",
+				Hours:               ptr.To[int32](8),
+				GatheringDataPeriod: v1beta3.GatheringDataPeriodDaily,
+			},
+		},
+	}
+	want := []v1beta3.ReplicasRecommendation{
+		{From: 0, To: 8, TimeZone: "Asia/Tokyo"},
+		{From: 8, To: 16, TimeZone: "Asia/Tokyo"},
+		{From: 16, To: 24, TimeZone: "Asia/Tokyo"},
+	}
+	got := s.initializeMinMaxReplicas(tortoise)
+	if d := cmp.Diff(want, got.Status.Recommendations.Horizontal.MinReplicas); d != "" {
+		t.Errorf("MinReplicas diff = %s", d)
+	}
+	if d := cmp.Diff(want, got.Status.Recommendations.Horizontal.MaxReplicas); d != "" {
+		t.Errorf("MaxReplicas diff = %s", d)
+	}
+}
+
+func TestService_initializeMinMaxReplicas_minutes(t *testing.T) {
+	s := &Service{
+		timeZone:                                   time.UTC,
+		rangeOfMinMaxReplicasRecommendationHour:    1,
+		rangeOfMinMaxReplicasRecommendationMinutes: 20,
+		gatheringDataDuration:                      "daily",
+	}
+	want := []v1beta3.ReplicasRecommendation{
+		{From: 0, FromMinute: 0, To: 0, ToMinute: 20, TimeZone: "UTC"},
+		{From: 0, FromMinute: 20, To: 0, ToMinute: 40, TimeZone: "UTC"},
+		{From: 0, FromMinute: 40, To: 1, ToMinute: 0, TimeZone: "UTC"},
+	}
+	got := s.initializeMinMaxReplicas(&v1beta3.Tortoise{}).Status.Recommendations.Horizontal.MinReplicas
+	if len(got) != 72 {
+		t.Fatalf("the number of the slots = %d, want 72", len(got))
+	}
+	if d := cmp.Diff(want, got[:3]); d != "" {
+		t.Errorf("MinReplicas diff = %s", d)
+	}
+	if d := cmp.Diff(v1beta3.ReplicasRecommendation{From: 23, FromMinute: 40, To: 24, ToMinute: 0, TimeZone: "UTC"}, got[71]); d != "" {
+		t.Errorf("the last MinReplicas diff = %s", d)
+	}
+}
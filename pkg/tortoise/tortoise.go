@@ -23,7 +23,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
 	"github.com/mercari/tortoise/pkg/event"
 	"github.com/mercari/tortoise/pkg/metrics"
 	"github.com/mercari/tortoise/pkg/utils"
@@ -36,8 +38,10 @@ type Service struct {
 	recorder record.EventRecorder
 
 	rangeOfMinMaxReplicasRecommendationHour int
-	timeZone                                *time.Location
-	tortoiseUpdateInterval                  time.Duration
+	// rangeOfMinMaxReplicasRecommendationMinutes takes precedence over rangeOfMinMaxReplicasRecommendationHour if it's not 0.
+	rangeOfMinMaxReplicasRecommendationMinutes int
+	timeZone                                   *time.Location
+	tortoiseUpdateInterval                     time.Duration
 	// GatheringDataPeriodType means how long do we gather data for minReplica/maxReplica or data from VPA. "daily" and "weekly" are only valid value.
 	// If "day", tortoise will consider all workload behaves very similarly every day.
 	// If your workload may behave differently on, for example, weekdays and weekends, set this to "weekly".
@@ -48,8 +52,12 @@ type Service struct {
 	globalDisableMode bool
 	// ExcludedNamespaces is a set of namespaces to exclude from Tortoise reconciliation.
 	excludedNamespaces sets.Set[string]
+	// defaultApplyWindows are the windows used by the tortoises which don't have .spec.applyWindows.
+	defaultApplyWindows []v1beta3.ApplyWindow
 	// scaleopsService provides ScaleOps CRD detection functionality
 	scaleopsService ScaleOpsService
+	// historyService records the applied changes in the recommendation history.
+	historyService HistoryService
 
 	mu sync.RWMutex
 	// TODO: Instead of here, we should store the last time of each tortoise in the status of the tortoise.
@@ -61,7 +69,12 @@ type ScaleOpsService interface {
 	IsScaleOpsManaged(ctx context.Context, tortoise *v1beta3.Tortoise) (bool, string, error)
 }
 
-func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRecommendationHour int, timeZone string, tortoiseUpdateInterval time.Duration, gatheringDataDuration string, globalDisableMode bool, excludedNamespaces []string, scaleopsService ScaleOpsService) (*Service, error) {
+// HistoryService records the changes applied by tortoise.
+type HistoryService interface {
+	Append(ctx context.Context, tortoise *v1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) error
+}
+
+func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRecommendationHour, rangeOfMinMaxReplicasRecommendationMinutes int, timeZone string, tortoiseUpdateInterval time.Duration, gatheringDataDuration string, globalDisableMode bool, excludedNamespaces []string, defaultApplyWindows []config.ApplyWindow, scaleopsService ScaleOpsService, historyService HistoryService) (*Service, error) {
 	jst, err := time.LoadLocation(timeZone)
 	if err != nil {
 		return nil, fmt.Errorf("load location: %w", err)
@@ -73,15 +86,18 @@ func New(c client.Client, recorder record.EventRecorder, rangeOfMinMaxReplicasRe
 	return &Service{
 		c: c,
 
-		recorder:                                recorder,
-		rangeOfMinMaxReplicasRecommendationHour: rangeOfMinMaxReplicasRecommendationHour,
-		gatheringDataDuration:                   gatheringDataDuration,
-		timeZone:                                jst,
-		tortoiseUpdateInterval:                  tortoiseUpdateInterval,
-		globalDisableMode:                       globalDisableMode,
-		excludedNamespaces:                      sets.New(excludedNamespaces...),
-		scaleopsService:                         scaleopsService,
-		lastTimeUpdateTortoise:                  map[client.ObjectKey]time.Time{},
+		recorder:                                   recorder,
+		rangeOfMinMaxReplicasRecommendationHour:    rangeOfMinMaxReplicasRecommendationHour,
+		rangeOfMinMaxReplicasRecommendationMinutes: rangeOfMinMaxReplicasRecommendationMinutes,
+		gatheringDataDuration:                      gatheringDataDuration,
+		timeZone:                                   jst,
+		tortoiseUpdateInterval:                     tortoiseUpdateInterval,
+		globalDisableMode:                          globalDisableMode,
+		excludedNamespaces:                         sets.New(excludedNamespaces...),
+		defaultApplyWindows:                        applyWindowsFromConfig(defaultApplyWindows),
+		scaleopsService:                            scaleopsService,
+		historyService:                             historyService,
+		lastTimeUpdateTortoise:                     map[client.ObjectKey]time.Time{},
 	}, nil
 }
 
@@ -113,11 +129,15 @@ func initializeContainerResourcePhase(tortoise *v1beta3.Tortoise, now time.Time)
 }
 
 func (s *Service) UpdateTortoisePhase(tortoise *v1beta3.Tortoise, now time.Time) *v1beta3.Tortoise {
+	if tortoise.Status.TortoisePhase != "" {
+		tortoise = s.reshapeReplicasRecommendations(tortoise, now)
+	}
+
 	switch tortoise.Status.TortoisePhase {
 	case "":
 		tortoise = s.initializeTortoise(tortoise, now)
 		r := "1 week"
-		if s.gatheringDataDuration == "daily" {
+		if s.replicasSlots(tortoise).period == v1beta3.GatheringDataPeriodDaily {
 			r = "1 day"
 		}
 		s.recorder.Event(tortoise, corev1.EventTypeNormal, event.Initialized, fmt.Sprintf("Tortoise is initialized and starts to gather data to make recommendations. It will take %s to finish gathering data and then tortoise starts to work actually", r))
@@ -223,12 +243,8 @@ func (s *Service) changeTortoisePhaseWorkingIfTortoiseFinishedGatheringData(tort
 				someAreWorking = true
 				continue
 			}
-			// If the last transition time is within 1 week, we consider it's still gathering data.
-			gatheringPeriod := 7 * 24 * time.Hour
-			if s.gatheringDataDuration == "daily" {
-				gatheringPeriod = 24 * time.Hour
-			}
-			if p.LastTransitionTime.Add(gatheringPeriod).After(now) {
+			// If the last transition time is within 1 week (or 1 day), we consider it's still gathering data.
+			if p.LastTransitionTime.Add(s.replicasSlots(tortoise).gatheringPeriod()).After(now) {
 				someAreGathering = true
 			} else {
 				// It's finish gathering data.
@@ -256,39 +272,7 @@ func (s *Service) changeTortoisePhaseWorkingIfTortoiseFinishedGatheringData(tort
 }
 
 func (s *Service) initializeMinMaxReplicas(tortoise *v1beta3.Tortoise) *v1beta3.Tortoise {
-	recommendations := []v1beta3.ReplicasRecommendation{}
-	from := 0
-	to := s.rangeOfMinMaxReplicasRecommendationHour
-	weekDay := time.Sunday
-	for {
-		if s.gatheringDataDuration == "daily" {
-			recommendations = append(recommendations, v1beta3.ReplicasRecommendation{
-				From:     from,
-				To:       to,
-				TimeZone: s.timeZone.String(),
-			})
-		} else if s.gatheringDataDuration == "weekly" {
-			recommendations = append(recommendations, v1beta3.ReplicasRecommendation{
-				From:     from,
-				To:       to,
-				TimeZone: s.timeZone.String(),
-				WeekDay:  ptr.To(weekDay.String()),
-			})
-		}
-
-		if to == 24 {
-			if weekDay == time.Saturday || s.gatheringDataDuration == "daily" {
-				break
-			}
-
-			weekDay += 1
-			from = 0
-			to = s.rangeOfMinMaxReplicasRecommendationHour
-			continue
-		}
-		from += s.rangeOfMinMaxReplicasRecommendationHour
-		to += s.rangeOfMinMaxReplicasRecommendationHour
-	}
+	recommendations := s.replicasSlots(tortoise).newRecommendations()
 	tortoise.Status.Recommendations.Horizontal.MinReplicas = recommendations
 	tortoise.Status.Recommendations.Horizontal.MaxReplicas = recommendations
 
@@ -377,19 +361,21 @@ func (s *Service) syncContainerRecommendationFromVPA(tortoise *v1beta3.Tortoise)
 	return tortoise
 }
 
-func (s *Service) UpdateContainerRecommendationFromVPA(tortoise *v1beta3.Tortoise, vpa *v1.VerticalPodAutoscaler, now time.Time) *v1beta3.Tortoise {
+// UpdateContainerRecommendationFromVPA updates tortoise.Status.Conditions.ContainerRecommendationFromVPA
+// with the recommendations from the recommendation source. (The monitor VPA by default.)
+func (s *Service) UpdateContainerRecommendationFromVPA(tortoise *v1beta3.Tortoise, recommendations []v1.RecommendedContainerResources, now time.Time) *v1beta3.Tortoise {
 	tortoise = s.syncContainerRecommendationFromVPA(tortoise)
 
-	upperMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(vpa.Status.Recommendation.ContainerRecommendations))
-	for _, c := range vpa.Status.Recommendation.ContainerRecommendations {
+	upperMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(recommendations))
+	for _, c := range recommendations {
 		upperMap[c.ContainerName] = make(map[corev1.ResourceName]resource.Quantity, len(c.UpperBound))
 		for rn, r := range c.UpperBound {
 			upperMap[c.ContainerName][rn] = r
 		}
 	}
 
-	targetMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(vpa.Status.Recommendation.ContainerRecommendations))
-	for _, c := range vpa.Status.Recommendation.ContainerRecommendations {
+	targetMap := make(map[string]map[corev1.ResourceName]resource.Quantity, len(recommendations))
+	for _, c := range recommendations {
 		targetMap[c.ContainerName] = make(map[corev1.ResourceName]resource.Quantity, len(c.UpperBound))
 		for rn, r := range c.Target {
 			targetMap[c.ContainerName][rn] = r
@@ -518,7 +504,15 @@ func (s *Service) UpdateTortoiseStatus(ctx context.Context, originalTortoise *v1
 			return fmt.Errorf("get tortoise to update status: %w", err)
 		}
 		// It should be OK to overwrite the status, because the controller is the only person to update it.
+		// The only exception is the constraints, which are managed by the recommender responsible for them (e.g., ScheduledScaling).
+		constraints := retTortoise.Status.Recommendations.Constraints
 		retTortoise.Status = originalTortoise.Status
+		if utils.HasRecommenderResponsibleFor(retTortoise, v1beta3.ResponsibleForConstraints) {
+			retTortoise.Status.Recommendations.Constraints = constraints
+		} else {
+			// No recommender is responsible for the constraints anymore.
+			retTortoise.Status.Recommendations.Constraints = nil
+		}
 		// Ensure required fields are initialized before updating to prevent CRD validation errors
 		ensureRequiredStatusFields(retTortoise)
 
@@ -650,8 +644,8 @@ func UpdateTortoiseAutoscalingPolicyInStatus(tortoise *v1beta3.Tortoise, hpa *v2
 		tortoise = utils.ChangeTortoiseContainerResourcePhase(tortoise, p, corev1.ResourceMemory, now, v1beta3.ContainerResourcePhaseGatheringData)
 	}
 
-	// And, if the existing HPA is attached, we modify the policy for resources managed by the HPA to Horizontal.
-	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName != nil {
+	// And, if the existing HPA (or ScaledObject) is attached, we modify the policy for resources managed by the HPA to Horizontal.
+	if tortoise.Spec.TargetRefs.HorizontalPodAutoscalerName != nil || tortoise.Spec.TargetRefs.ScaledObjectName != nil {
 		hpaManagedResourceAndContainer := sets.New[resourceNameAndContainerName]()
 		for _, m := range hpa.Spec.Metrics {
 			if m.Type != v2.ContainerResourceMetricSourceType {
@@ -724,7 +718,10 @@ type containerNameAndResource struct {
 // It updates ContainerResourceRequests in the status of the Tortoise, when ALL the following conditions are met:
 //   - UpdateMode is Auto
 //   - Any of the recommended resource request is increased,
-//     OR, all the recommended resource request is decreased, but it's been a while (1h) after the last update.
+//     OR, all the recommended resource request is decreased, but it's been a while (1h) after the last update,
+//     and it's within the apply windows (if any).
+//
+// The decreased resource requests outside of the apply windows are recorded in PendingContainerResourceRequests instead.
 func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.Tortoise, replica int32, now time.Time) (
 	*v1beta3.Tortoise,
 	error,
@@ -787,6 +784,11 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 					}
 				}
 			}
+
+			if floor, ok := utils.GetResourceRequestFloor(tortoise, r.ContainerName, resourcename); ok && floor.Cmp(recommendation[resourcename]) > 0 {
+				// The floor is raised because the Pods crashed with the lower resource request before.
+				recommendation[resourcename] = floor
+			}
 		}
 		newRequests = append(newRequests, v1beta3.ContainerResourceRequests{
 			ContainerName: r.ContainerName,
@@ -795,6 +797,7 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 	}
 	if tortoise.Spec.UpdateMode == v1beta3.UpdateModeOff {
 		// nothing to do.
+		clearPendingResourceRequests(tortoise)
 		tortoise = utils.ChangeTortoiseCondition(tortoise,
 			v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
 			corev1.ConditionFalse,
@@ -807,6 +810,7 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 
 	if disabled, reason := c.IsChangeApplicationDisabled(ctx, tortoise); disabled {
 		// Global disable mode, namespace exclusion, or ScaleOps management - don't apply recommendations but still update status
+		clearPendingResourceRequests(tortoise)
 		msg := fmt.Sprintf("The recommendation is not applied because %s is enabled", reason)
 		log.FromContext(ctx).Info("Skipping VPA recommendation application", "tortoise", klog.KObj(tortoise), "reason", reason)
 		tortoise = utils.ChangeTortoiseCondition(tortoise,
@@ -821,6 +825,7 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 
 	if tortoise.Status.Conditions.ContainerResourceRequests != nil && reflect.DeepEqual(newRequests, tortoise.Status.Conditions.ContainerResourceRequests) {
 		// If the recommendation is not changed at all, we don't need to update VPA and Pods.
+		clearPendingResourceRequests(tortoise)
 		return tortoise, nil
 	}
 
@@ -843,6 +848,24 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 		}
 	}
 
+	if windows := c.applyWindows(tortoise); len(windows) != 0 && !increased {
+		// All the recommended resources are decreased, which can wait for the next apply window.
+		open, next, err := inApplyWindow(windows, c.timeZone, now)
+		if err != nil {
+			return oldTortoise, fmt.Errorf("check the apply windows: %w", err)
+		}
+		if !open {
+			log.FromContext(ctx).Info("Queue the vertical recommendation until the next apply window opens", "tortoise", klog.KObj(tortoise), "nextApplyWindowTime", next)
+			oldTortoise.Status.Conditions.PendingContainerResourceRequests = newRequests
+			oldTortoise.Status.Conditions.NextApplyWindowTime = nil
+			if !next.IsZero() {
+				oldTortoise.Status.Conditions.NextApplyWindowTime = ptr.To(metav1.NewTime(next))
+			}
+			return oldTortoise, nil
+		}
+	}
+	clearPendingResourceRequests(tortoise)
+
 	tortoise = utils.ChangeTortoiseCondition(tortoise,
 		v1beta3.TortoiseConditionTypeVerticalRecommendationUpdated,
 		corev1.ConditionTrue,
@@ -852,6 +875,7 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 	)
 
 	c.recorder.Event(tortoise, corev1.EventTypeNormal, event.VerticalRecommendationUpdated, "The vertical recommendation is updated and the Pods should also be updated with new resources soon")
+	c.appendHistory(ctx, tortoise, resourceRequestHistoryEntries(oldRequestMap, newRequests, now), now)
 
 	for _, r := range tortoise.Status.Conditions.ContainerResourceRequests {
 		// only record metrics once in every reconcile loop.
@@ -879,6 +903,63 @@ func (c *Service) UpdateResourceRequest(ctx context.Context, tortoise *v1beta3.T
 	return tortoise, nil
 }
 
+// clearPendingResourceRequests removes the resource requests queued until the next apply window,
+// which are applied or no longer needed.
+func clearPendingResourceRequests(tortoise *v1beta3.Tortoise) {
+	tortoise.Status.Conditions.PendingContainerResourceRequests = nil
+	tortoise.Status.Conditions.NextApplyWindowTime = nil
+}
+
+// resourceRequestHistoryEntries returns the history entries for the resource requests changed from oldRequestMap to newRequests.
+func resourceRequestHistoryEntries(oldRequestMap map[string]map[corev1.ResourceName]resource.Quantity, newRequests []v1beta3.ContainerResourceRequests, now time.Time) []v1alpha1.RecommendationHistoryEntry {
+	var entries []v1alpha1.RecommendationHistoryEntry
+	for _, r := range newRequests {
+		resourceNames := make([]corev1.ResourceName, 0, len(r.Resource))
+		for rn := range r.Resource {
+			resourceNames = append(resourceNames, rn)
+		}
+		sort.Slice(resourceNames, func(i, j int) bool { return resourceNames[i] < resourceNames[j] })
+
+		for _, rn := range resourceNames {
+			newValue := r.Resource[rn]
+			e := v1alpha1.RecommendationHistoryEntry{
+				Time:          metav1.NewTime(now),
+				Target:        v1alpha1.HistoryTargetResourceRequest,
+				ContainerName: r.ContainerName,
+				ResourceName:  rn,
+				NewValue:      newValue.String(),
+			}
+			oldValue, ok := oldRequestMap[r.ContainerName][rn]
+			switch {
+			case !ok:
+				e.Reason = "the first resource request from the vertical recommendation"
+			case oldValue.Cmp(newValue) < 0:
+				e.OldValue = oldValue.String()
+				e.Reason = "increased based on the vertical recommendation"
+			case oldValue.Cmp(newValue) > 0:
+				e.OldValue = oldValue.String()
+				e.Reason = "decreased based on the vertical recommendation"
+			default:
+				// not changed.
+				continue
+			}
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// appendHistory records the entries in the recommendation history.
+// It only logs the error because failing to record the history shouldn't block the reconciliation.
+func (c *Service) appendHistory(ctx context.Context, tortoise *v1beta3.Tortoise, entries []v1alpha1.RecommendationHistoryEntry, now time.Time) {
+	if c.historyService == nil || len(entries) == 0 {
+		return
+	}
+	if err := c.historyService.Append(ctx, tortoise, entries, now); err != nil {
+		log.FromContext(ctx).Error(err, "failed to record the recommendation history", "tortoise", klog.KObj(tortoise))
+	}
+}
+
 func recommendationIncreaseAnyResource(oldTortoise, newTortoise *v1beta3.Tortoise) bool {
 	if newTortoise.Status.Conditions.ContainerResourceRequests == nil {
 		// if newVPA doesn't have recommendation, it means we're going to remove the recommendation.
@@ -21,6 +21,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
+	"github.com/mercari/tortoise/api/v1alpha1"
 	"github.com/mercari/tortoise/api/v1beta3"
 )
 
@@ -514,7 +515,7 @@ func TestService_updateUpperRecommendation(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &Service{}
-			got := s.UpdateContainerRecommendationFromVPA(tt.tortoise, tt.vpa, time.Now())
+			got := s.UpdateContainerRecommendationFromVPA(tt.tortoise, tt.vpa.Status.Recommendation.ContainerRecommendations, time.Now())
 			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreTypes(metav1.Time{})); diff != "" {
 				t.Fatalf("diff: %s", diff)
 			}
@@ -5139,3 +5140,71 @@ func TestService_UpdateTortoisePhase(t *testing.T) {
 		})
 	}
 }
+
+func Test_resourceRequestHistoryEntries(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		oldRequestMap map[string]map[corev1.ResourceName]resource.Quantity
+		newRequests   []v1beta3.ContainerResourceRequests
+		want          []v1alpha1.RecommendationHistoryEntry
+	}{
+		{
+			name: "only changed resources are recorded",
+			oldRequestMap: map[string]map[corev1.ResourceName]resource.Quantity{
+				"app":         {corev1.ResourceCPU: resource.MustParse("500m"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+				"istio-proxy": {corev1.ResourceCPU: resource.MustParse("200m"), corev1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+			newRequests: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("600m"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+				{ContainerName: "istio-proxy", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("512Mi")}},
+				{ContainerName: "new", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}},
+			},
+			want: []v1alpha1.RecommendationHistoryEntry{
+				{
+					Time:          metav1.NewTime(now),
+					Target:        v1alpha1.HistoryTargetResourceRequest,
+					ContainerName: "app",
+					ResourceName:  corev1.ResourceCPU,
+					OldValue:      "500m",
+					NewValue:      "600m",
+					Reason:        "increased based on the vertical recommendation",
+				},
+				{
+					Time:          metav1.NewTime(now),
+					Target:        v1alpha1.HistoryTargetResourceRequest,
+					ContainerName: "istio-proxy",
+					ResourceName:  corev1.ResourceCPU,
+					OldValue:      "200m",
+					NewValue:      "100m",
+					Reason:        "decreased based on the vertical recommendation",
+				},
+				{
+					Time:          metav1.NewTime(now),
+					Target:        v1alpha1.HistoryTargetResourceRequest,
+					ContainerName: "new",
+					ResourceName:  corev1.ResourceCPU,
+					NewValue:      "100m",
+					Reason:        "the first resource request from the vertical recommendation",
+				},
+			},
+		},
+		{
+			name: "nothing is recorded when nothing is changed",
+			oldRequestMap: map[string]map[corev1.ResourceName]resource.Quantity{
+				"app": {corev1.ResourceCPU: resource.MustParse("500m")},
+			},
+			newRequests: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resourceRequestHistoryEntries(tt.oldRequestMap, tt.newRequests, now)
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("resourceRequestHistoryEntries() diff = %s", d)
+			}
+		})
+	}
+}
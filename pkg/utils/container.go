@@ -0,0 +1,38 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// IsNativeSidecar returns true if the init container is a native sidecar,
+// that is, the init container with restartPolicy: Always which keeps running alongside the containers.
+// https://kubernetes.io/docs/concepts/workloads/pods/sidecar-containers/
+func IsNativeSidecar(c corev1.Container) bool {
+	return c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// ScalableContainers returns the containers in the Pod spec which Tortoise scales:
+// all the containers and the native sidecars in the init containers.
+// The returned pointers refer to the elements of the Pod spec so that the caller can modify them in place.
+func ScalableContainers(spec *corev1.PodSpec) []*corev1.Container {
+	containers := make([]*corev1.Container, 0, len(spec.Containers)+len(spec.InitContainers))
+	for i := range spec.Containers {
+		containers = append(containers, &spec.Containers[i])
+	}
+	for i := range spec.InitContainers {
+		if IsNativeSidecar(spec.InitContainers[i]) {
+			containers = append(containers, &spec.InitContainers[i])
+		}
+	}
+	return containers
+}
+
+// HasNativeSidecar returns true if the Pod spec has the native sidecar with the name.
+func HasNativeSidecar(spec *corev1.PodSpec, name string) bool {
+	for _, c := range spec.InitContainers {
+		if c.Name == name && IsNativeSidecar(c) {
+			return true
+		}
+	}
+	return false
+}
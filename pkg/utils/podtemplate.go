@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+// GetResourceRequestsFromPodTemplate returns the resource requests of the containers in the given Pod template.
+// The native sidecars in the init containers are included as well as the containers.
+// If the injection of a sidecar in sidecarInjectors (e.g., istio-proxy) is enabled on the template, the sidecar container is also included
+// based on the annotations, or the default values of the sidecar injector when the annotations are missing.
+// When the sidecar is declared as a native sidecar in the template (e.g., Istio's native sidecar mode with the custom injection),
+// its resource requests are taken from the container spec instead of the annotations.
+func GetResourceRequestsFromPodTemplate(template *corev1.PodTemplateSpec, sidecarInjectors map[string]config.SidecarInjector) ([]v1beta3.ContainerResourceRequests, error) {
+	actualContainerResource := []v1beta3.ContainerResourceRequests{}
+
+	containerIndex := map[string]int{}
+	for i, c := range ScalableContainers(&template.Spec) {
+		rcr := v1beta3.ContainerResourceRequests{
+			ContainerName: c.Name,
+			Resource:      corev1.ResourceList{},
+		}
+		for name, r := range c.Resources.Requests {
+			rcr.Resource[name] = r
+		}
+		actualContainerResource = append(actualContainerResource, rcr)
+		containerIndex[c.Name] = i
+	}
+
+	// Sort the names so that the injected sidecars are always appended in the same order.
+	names := make([]string, 0, len(sidecarInjectors))
+	for name := range sidecarInjectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		injector := sidecarInjectors[name]
+		if !injector.Injected(template.Annotations) || HasNativeSidecar(&template.Spec, name) {
+			continue
+		}
+		// The sidecar injection is enabled.
+		// Because the sidecar container spec is not in the workload spec, we need to get it from the Pod template's annotation.
+		requests := corev1.ResourceList{}
+		for _, k := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			requestAnnotation, _ := injector.ResourceAnnotations(k)
+			req, ok := template.Annotations[requestAnnotation]
+			if !ok || requestAnnotation == "" {
+				req = injector.DefaultRequest(k)
+			}
+			if req == "" {
+				// The request of the sidecar is unknown.
+				continue
+			}
+			q, err := resource.ParseQuantity(req)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s request of sidecar %s: %w", k, name, err)
+			}
+			requests[k] = q
+		}
+
+		i, ok := containerIndex[name]
+		if !ok {
+			// If the workload has the sidecar injection annotation, the Pods will have the sidecar container in addition.
+			actualContainerResource = append(actualContainerResource, v1beta3.ContainerResourceRequests{
+				ContainerName: name,
+				Resource:      requests,
+			})
+			continue
+		}
+		// the workload has the sidecar injection annotation and it's using the custom injection:
+		// https://istio.io/latest/docs/setup/additional-setup/sidecar-injection/#customizing-injection
+		for k, q := range requests {
+			actualContainerResource[i].Resource[k] = q
+		}
+	}
+
+	return actualContainerResource, nil
+}
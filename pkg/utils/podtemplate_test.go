@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+	"github.com/mercari/tortoise/pkg/config"
+)
+
+func TestGetResourceRequestsFromPodTemplate(t *testing.T) {
+	sidecarInjectors := map[string]config.SidecarInjector{
+		config.IstioProxyContainerName: config.IstioSidecarInjector("100m", "200Mi"),
+		"linkerd-proxy": {
+			InjectionAnnotation:      "linkerd.io/inject",
+			InjectionAnnotationValue: "enabled",
+			CPURequestAnnotation:     "config.linkerd.io/proxy-cpu-request",
+			MemoryRequestAnnotation:  "config.linkerd.io/proxy-memory-request",
+			DefaultCPURequest:        "10m",
+		},
+	}
+	app := corev1.Container{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		},
+	}
+	tests := []struct {
+		name     string
+		template *corev1.PodTemplateSpec
+		want     []v1beta3.ContainerResourceRequests
+		wantErr  bool
+	}{
+		{
+			name: "no sidecar is injected",
+			template: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{app}},
+			},
+			want: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+			},
+		},
+		{
+			name: "the sidecars are injected with the annotations and the default values",
+			template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"sidecar.istio.io/inject":                "true",
+						"sidecar.istio.io/proxyCPU":              "300m",
+						"linkerd.io/inject":                      "enabled",
+						"config.linkerd.io/proxy-memory-request": "20Mi",
+					},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{app}},
+			},
+			want: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+				{ContainerName: "istio-proxy", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("300m"), corev1.ResourceMemory: resource.MustParse("200Mi")}},
+				{ContainerName: "linkerd-proxy", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("10m"), corev1.ResourceMemory: resource.MustParse("20Mi")}},
+			},
+		},
+		{
+			name: "the injection annotation with the other value",
+			template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"linkerd.io/inject": "disabled"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{app}},
+			},
+			want: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+			},
+		},
+		{
+			name: "the sidecar in the template is overridden by the annotations",
+			template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"sidecar.istio.io/inject": "true"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{app, {Name: "istio-proxy"}}},
+			},
+			want: []v1beta3.ContainerResourceRequests{
+				{ContainerName: "app", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")}},
+				{ContainerName: "istio-proxy", Resource: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("200Mi")}},
+			},
+		},
+		{
+			name: "invalid annotation",
+			template: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{"sidecar.istio.io/inject": "true", "sidecar.istio.io/proxyCPU": "invalid"},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{app}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetResourceRequestsFromPodTemplate(tt.template, sidecarInjectors)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetResourceRequestsFromPodTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d := cmp.Diff(tt.want, got); d != "" {
+				t.Errorf("GetResourceRequestsFromPodTemplate() diff = %s", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+// InReplicasRecommendationSlot returns true if t is within the time slot of the recommendation.
+// t is expected to be in the time zone of the recommendation.
+// weekday is the day of the week of t, which may be replaced by the calendar on the special days.
+func InReplicasRecommendationSlot(r v1beta3.ReplicasRecommendation, t time.Time, weekday time.Weekday) bool {
+	m := t.Hour()*60 + t.Minute()
+	return m >= r.From*60+r.FromMinute && m < r.To*60+r.ToMinute && (r.WeekDay == nil || weekday.String() == *r.WeekDay)
+}
+
+// ReplicasRecommendationStep returns the interval to look at the time slots of the recommendations one by one.
+// It's small enough to hit every time slot in any time zone, whose offset is a multiple of 15 minutes.
+func ReplicasRecommendationStep(recommendations ...[]v1beta3.ReplicasRecommendation) time.Duration {
+	step := 15
+	for _, rs := range recommendations {
+		for _, r := range rs {
+			step = gcd(step, r.From*60+r.FromMinute)
+			step = gcd(step, r.To*60+r.ToMinute)
+		}
+	}
+	return time.Duration(step) * time.Minute
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
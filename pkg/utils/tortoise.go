@@ -95,3 +95,27 @@ func GetRequestFromTortoise(t *v1beta3.Tortoise, containerName string, resourceN
 
 	return resource.Quantity{}, false
 }
+
+// GetResourceRequestFloor returns the floor of the resource request from the tortoise.Status.Conditions.ResourceRequestFloors.
+func GetResourceRequestFloor(t *v1beta3.Tortoise, containerName string, resourceName v1.ResourceName) (resource.Quantity, bool) {
+	for _, f := range t.Status.Conditions.ResourceRequestFloors {
+		if f.ContainerName == containerName {
+			floor, ok := f.Resource[resourceName]
+			return floor, ok
+		}
+	}
+
+	return resource.Quantity{}, false
+}
+
+// HasRecommenderResponsibleFor returns true if any recommender in .spec.recommenders is responsible for the given kind of recommendation.
+func HasRecommenderResponsibleFor(t *v1beta3.Tortoise, responsibleFor v1beta3.ResponsibleFor) bool {
+	for _, r := range t.Spec.Recommenders {
+		for _, rf := range r.ResponsibleFor {
+			if rf == responsibleFor {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -18,6 +18,7 @@ import (
 
 	autoscalingv1beta3 "github.com/mercari/tortoise/api/v1beta3"
 	"github.com/mercari/tortoise/pkg/event"
+	"github.com/mercari/tortoise/pkg/rollout"
 	"github.com/mercari/tortoise/pkg/utils"
 )
 
@@ -36,6 +37,14 @@ func New(c *rest.Config, recorder record.EventRecorder) (*Service, error) {
 
 const tortoiseMonitorVPANamePrefix = "tortoise-monitor-"
 
+// scaleTargetAPIVersion returns the API version of the scale target of the tortoise.
+func scaleTargetAPIVersion(tortoise *autoscalingv1beta3.Tortoise) string {
+	if tortoise.Spec.TargetRefs.ScaleTargetRef.Kind == rollout.Kind {
+		return rollout.GroupVersionKind.GroupVersion().String()
+	}
+	return "apps/v1"
+}
+
 func TortoiseMonitorVPAName(tortoiseName string) string {
 	return tortoiseMonitorVPANamePrefix + tortoiseName
 }
@@ -94,9 +103,9 @@ func (c *Service) CreateTortoiseMonitorVPA(ctx context.Context, tortoise *autosc
 		},
 		Spec: v1.VerticalPodAutoscalerSpec{
 			TargetRef: &autoscaling.CrossVersionObjectReference{
-				Kind:       "Deployment",
+				Kind:       tortoise.Spec.TargetRefs.ScaleTargetRef.Kind,
 				Name:       tortoise.Spec.TargetRefs.ScaleTargetRef.Name,
-				APIVersion: "apps/v1",
+				APIVersion: scaleTargetAPIVersion(tortoise),
 			},
 			UpdatePolicy: &v1.PodUpdatePolicy{
 				UpdateMode: &off,
@@ -0,0 +1,89 @@
+package workload
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mercari/tortoise/api/v1beta3"
+)
+
+// Adapter handles one kind of the scale target, such as Deployment.
+// The controller handles the scale target only via Adapter and the scale subresource,
+// so that a new kind can be supported by registering its Adapter in Service without changing the controller.
+type Adapter interface {
+	// Get returns the scale target of the tortoise.
+	Get(ctx context.Context, tortoise *v1beta3.Tortoise) (client.Object, error)
+	// PodTemplate returns the Pod template of the workload.
+	PodTemplate(workload client.Object) (*corev1.PodTemplateSpec, error)
+	// ResourceRequests returns the resource requests of the containers in the Pods of the workload.
+	ResourceRequests(workload client.Object) ([]v1beta3.ContainerResourceRequests, error)
+	// Restart restarts the Pods of the workload so that they get the resource requests from the tortoise.
+	Restart(ctx context.Context, workload client.Object, tortoise *v1beta3.Tortoise, now time.Time) error
+	// RolloutCompleted returns true if all the Pods of the workload are updated and available.
+	RolloutCompleted(workload client.Object) (bool, error)
+}
+
+// Service finds the Adapter for the scale target of tortoises, and reads the scale subresource of the scale target.
+type Service struct {
+	c client.Client
+	// adapters is the map from the kind of the scale target to its Adapter.
+	adapters map[string]Adapter
+}
+
+func New(c client.Client, adapters map[string]Adapter) *Service {
+	return &Service{c: c, adapters: adapters}
+}
+
+// Adapter returns the Adapter for the kind of the scale target.
+func (s *Service) Adapter(kind string) (Adapter, error) {
+	a, ok := s.adapters[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported scale target kind: %s", kind)
+	}
+	return a, nil
+}
+
+// Scale is the number of replicas and the Pod selector of the scale target.
+type Scale struct {
+	// Replicas is the desired number of replicas.
+	Replicas int32
+	// Selector is the label selector of the Pods.
+	Selector *metav1.LabelSelector
+}
+
+// GetScale reads the scale subresource of the workload,
+// which is available on any kind which HPA can scale, including the custom resources.
+// It returns an error if the scale subresource doesn't have the selector.
+func (s *Service) GetScale(ctx context.Context, workload client.Object) (*Scale, error) {
+	scale := &autoscalingv1.Scale{}
+	if err := s.c.SubResource("scale").Get(ctx, workload, scale); err != nil {
+		return nil, fmt.Errorf("failed to get the scale subresource of %s: %w", client.ObjectKeyFromObject(workload), err)
+	}
+
+	if scale.Status.Selector == "" {
+		// The empty selector would match all the Pods in the namespace.
+		// The selector is empty until the controller of the workload fills it (e.g., Argo Rollouts),
+		// or forever if the custom resource doesn't have labelSelectorPath in its scale subresource.
+		return nil, fmt.Errorf("the scale subresource of %s doesn't have the selector yet", client.ObjectKeyFromObject(workload))
+	}
+	selector, err := metav1.ParseToLabelSelector(scale.Status.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the selector in the scale subresource of %s: %w", client.ObjectKeyFromObject(workload), err)
+	}
+	return &Scale{Replicas: scale.Spec.Replicas, Selector: selector}, nil
+}
+
+// As returns the workload as the type which the Adapter handles.
+func As[T client.Object](workload client.Object) (T, error) {
+	w, ok := workload.(T)
+	if !ok {
+		return w, fmt.Errorf("unexpected scale target type: %T", workload)
+	}
+	return w, nil
+}
@@ -0,0 +1,101 @@
+package workload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestService_GetScale(t *testing.T) {
+	tests := []struct {
+		name     string
+		workload client.Object
+		// selector is the selector in the scale subresource, which the fake client doesn't fill.
+		selector string
+		want     *Scale
+		wantErr  bool
+	}{
+		{
+			name: "the replicas and the selector in the scale subresource",
+			workload: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"},
+				Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](5)},
+			},
+			selector: "app=app",
+			want:     &Scale{Replicas: 5, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}}},
+		},
+		{
+			name: "the default replicas",
+			workload: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"},
+			},
+			selector: "app=app",
+			want:     &Scale{Replicas: 1, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "app"}}},
+		},
+		{
+			name: "the scale subresource without the selector",
+			workload: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"},
+				Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](5)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "the kind without the scale subresource",
+			workload: &appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app"},
+			},
+			selector: "app=app",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithObjects(tt.workload).WithInterceptorFuncs(interceptor.Funcs{
+				SubResourceGet: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+					if err := c.SubResource(subResourceName).Get(ctx, obj, subResource, opts...); err != nil {
+						return err
+					}
+					subResource.(*autoscalingv1.Scale).Status.Selector = tt.selector
+					return nil
+				},
+			}).Build()
+			s := New(c, nil)
+			got, err := s.GetScale(context.Background(), tt.workload)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetScale() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d := cmp.Diff(tt.want, got, cmpopts.EquateEmpty()); d != "" {
+				t.Errorf("GetScale() diff = %s", d)
+			}
+		})
+	}
+}
+
+func TestService_Adapter(t *testing.T) {
+	s := New(nil, map[string]Adapter{"Deployment": nil})
+	if _, err := s.Adapter("Deployment"); err != nil {
+		t.Errorf("Adapter() error = %v", err)
+	}
+	if _, err := s.Adapter("DaemonSet"); err == nil {
+		t.Errorf("Adapter() should fail for the unregistered kind")
+	}
+}
+
+func TestAs(t *testing.T) {
+	if _, err := As[*appsv1.Deployment](&appsv1.Deployment{}); err != nil {
+		t.Errorf("As() error = %v", err)
+	}
+	if _, err := As[*appsv1.Deployment](&appsv1.StatefulSet{}); err == nil {
+		t.Errorf("As() should fail for the unexpected type")
+	}
+}